@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/shlex"
+	"github.com/oriser/bolt/service"
+)
+
+const channelConfigUsage = "USAGE: /bolt-config [show | split equal|proportional|host | dont-join-after <HH:MM>|off | destination-emoji <emoji> | debts on|off | reminders <duration> | debts-max-duration <duration> | progress-updates on|off | live-roster on|off]\n" +
+	"Use `default` as the value to go back to the default of a setting"
+
+func (s *SlackBot) handleChannelConfigCommand(_ context.Context, r *http.Request, w http.ResponseWriter) (responseWritten bool, err error) {
+	if err := r.ParseForm(); err != nil {
+		return false, fmt.Errorf("parse form: %w", err)
+	}
+
+	if r.Form.Get("command") != "/bolt-config" {
+		return false, fmt.Errorf("unknown command %q", r.Form.Get("command"))
+	}
+
+	splitted, err := shlex.Split(r.Form.Get("text"))
+	if err != nil {
+		return false, fmt.Errorf("shlex split %q: %w", r.Form.Get("text"), err)
+	}
+
+	userID := r.Form.Get("user_id")
+	_, isAdmin := s.adminsUserIds[userID]
+	response, err := s.service.HandleChannelConfigCommand(r.Form.Get("channel_id"), userID, isAdmin, splitted)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBadChannelConfigUsage):
+			_, _ = w.Write([]byte(channelConfigUsage))
+		case errors.Is(err, service.ErrChannelConfigNotAllowed):
+			_, _ = w.Write([]byte("Sorry, you are not allowed to change the configuration of this channel"))
+		case errors.Is(err, service.ErrInvalidSetting):
+			_, _ = w.Write([]byte(fmt.Sprintf("%v\n%s", err, channelConfigUsage)))
+		default:
+			_, _ = w.Write([]byte(fmt.Sprintf("Error handling channel config: %v", err)))
+		}
+		return true, err
+	}
+
+	_, _ = w.Write([]byte(response))
+	return true, nil
+}
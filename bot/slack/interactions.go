@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/oriser/bolt/service"
+	"github.com/slack-go/slack"
+)
+
+// interactionsEndpoint handles interactions with Bolt's messages, like clicking a button of a prompt
+func (s *SlackBot) interactionsEndpoint(w http.ResponseWriter, r *http.Request) {
+	body, err := s.verifiedBody(w, r)
+	if err != nil {
+		log.Println("Error verifying interaction: ", err)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("Error parsing interaction form: ", err)
+		return
+	}
+
+	var callback slack.InteractionCallback
+	if err = json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("Error parsing interaction payload: ", err)
+		return
+	}
+
+	if callback.Type != slack.InteractionTypeBlockActions || len(callback.ActionCallback.BlockActions) == 0 {
+		return
+	}
+
+	// Slack expects an acknowledgment within 3 seconds, so the answer is handled in the background
+	go func() {
+		if err := s.handlePromptAnswer(callback); err != nil {
+			log.Println("Error handling prompt answer:", err)
+		}
+	}()
+}
+
+func (s *SlackBot) handlePromptAnswer(callback slack.InteractionCallback) error {
+	action := callback.ActionCallback.BlockActions[0]
+	response, err := s.service.HandlePromptAnswer(action.BlockID, action.Value, callback.User.ID)
+	if errors.Is(err, service.ErrPromptNotForUser) {
+		// Keeping the prompt for its recipient
+		if err = slack.PostWebhook(callback.ResponseURL, &slack.WebhookMessage{
+			Text:         "Sorry, this question is for someone else",
+			ResponseType: slack.ResponseTypeEphemeral,
+		}); err != nil {
+			return fmt.Errorf("reply to non recipient: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		if !errors.Is(err, service.ErrPromptExpired) {
+			return fmt.Errorf("prompt answer handler: %w", err)
+		}
+		response = "Sorry, this question is no longer relevant. Questions expire after a while, or when I restart"
+	}
+
+	if err = slack.PostWebhook(callback.ResponseURL, &slack.WebhookMessage{
+		Text:            response,
+		ReplaceOriginal: true,
+	}); err != nil {
+		return fmt.Errorf("replace prompt: %w", err)
+	}
+	return nil
+}
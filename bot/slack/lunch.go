@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/oriser/bolt/service"
+)
+
+const lunchUsage = "USAGE: /lunch <venue link, ID or name> [@host]"
+
+// escapedUserRe matches a user mention as sent in slash commands with escaping
+var escapedUserRe = regexp.MustCompile(`^<@([A-Z0-9]+)(\|[^>]*)?>$`)
+
+func (s *SlackBot) handleLunchCommand(_ context.Context, r *http.Request, w http.ResponseWriter) (responseWritten bool, err error) {
+	if err := r.ParseForm(); err != nil {
+		return false, fmt.Errorf("parse form: %w", err)
+	}
+
+	if r.Form.Get("command") != "/lunch" {
+		return false, fmt.Errorf("unknown command %q", r.Form.Get("command"))
+	}
+
+	fields := strings.Fields(r.Form.Get("text"))
+	host := ""
+	if len(fields) > 1 {
+		if match := escapedUserRe.FindStringSubmatch(fields[len(fields)-1]); match != nil {
+			host = match[1]
+			fields = fields[:len(fields)-1]
+		}
+	}
+	venueRef := strings.Join(fields, " ")
+	if venueRef == "" {
+		_, _ = w.Write([]byte(lunchUsage))
+		return true, fmt.Errorf("bad usage")
+	}
+
+	response, err := s.service.HandleLunch(r.Form.Get("channel_id"), r.Form.Get("user_id"), venueRef, host)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVenue) {
+			_, _ = w.Write([]byte(fmt.Sprintf("%v\n%s", err, lunchUsage)))
+			return true, err
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf("Error starting a group order: %v", err)))
+		return true, err
+	}
+
+	_, _ = w.Write([]byte(response))
+	return true, nil
+}
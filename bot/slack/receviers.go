@@ -3,6 +3,7 @@ package slack
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -29,6 +30,10 @@ func (s *SlackBot) ListenAndServe(ctx context.Context) error {
 		go s.reactionsAddWorker(ctx)
 	}
 
+	for i := 0; i < s.appHomeWorkers; i++ {
+		go s.appHomeWorker(ctx)
+	}
+
 	http.HandleFunc("/events-endpoint", s.eventsEndpoint)
 	http.HandleFunc("/add-user", func(w http.ResponseWriter, r *http.Request) {
 		responseWritten, err := s.handleAddUserCommand(ctx, r, w)
@@ -39,6 +44,52 @@ func (s *SlackBot) ListenAndServe(ctx context.Context) error {
 			}
 		}
 	})
+	http.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		responseWritten, err := s.handleUsersCommand(ctx, r, w)
+		if err != nil {
+			log.Printf("handleUsersCommand: %v\n", err)
+			if !responseWritten {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	})
+	http.HandleFunc("/bolt-settings", func(w http.ResponseWriter, r *http.Request) {
+		responseWritten, err := s.handleSettingsCommand(ctx, r, w)
+		if err != nil {
+			log.Printf("handleSettingsCommand: %v\n", err)
+			if !responseWritten {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	})
+	http.HandleFunc("/interactions", s.interactionsEndpoint)
+	http.HandleFunc("/bolt-config", func(w http.ResponseWriter, r *http.Request) {
+		responseWritten, err := s.handleChannelConfigCommand(ctx, r, w)
+		if err != nil {
+			log.Printf("handleChannelConfigCommand: %v\n", err)
+			if !responseWritten {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	})
+	http.HandleFunc("/watch-venue", func(w http.ResponseWriter, r *http.Request) {
+		responseWritten, err := s.handleWatchVenueCommand(ctx, r, w)
+		if err != nil {
+			log.Printf("handleWatchVenueCommand: %v\n", err)
+			if !responseWritten {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	})
+	http.HandleFunc("/lunch", func(w http.ResponseWriter, r *http.Request) {
+		responseWritten, err := s.handleLunchCommand(ctx, r, w)
+		if err != nil {
+			log.Printf("handleLunchCommand: %v\n", err)
+			if !responseWritten {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	})
 
 	log.Println("Server listening on port", s.port)
 	return http.ListenAndServe(fmt.Sprintf(":%d", s.port), nil)
@@ -80,15 +131,35 @@ func (s *SlackBot) eventsEndpoint(w http.ResponseWriter, r *http.Request) {
 			case <-time.After(1 * time.Second):
 				w.WriteHeader(http.StatusTooManyRequests)
 			}
+		case *slackevents.TeamJoinEvent:
+			if ev.User != nil {
+				s.directory.Upsert(*ev.User)
+			}
+		case *slackevents.UserChangeEvent:
+			go func() {
+				if err := s.directory.UpdateUser(context.Background(), ev.User.ID); err != nil {
+					log.Printf("Error updating user %s in directory: %v\n", ev.User.ID, err)
+				}
+			}()
+		case *slackevents.AppHomeOpenedEvent:
+			if ev.Tab != "home" {
+				return
+			}
+			select {
+			case s.appHomeCh <- ev:
+			case <-time.After(1 * time.Second):
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
 		}
 	}
 }
 
-func (s *SlackBot) parseMessage(w http.ResponseWriter, r *http.Request) ([]byte, slackevents.EventsAPIEvent, error) {
+// verifiedBody reads the body of a request from Slack, verifying it's signed by Slack
+func (s *SlackBot) verifiedBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		return nil, slackevents.EventsAPIEvent{}, fmt.Errorf("read body: %w", err)
+		return nil, fmt.Errorf("read body: %w", err)
 	}
 
 	if !s.disableSecretVerification {
@@ -96,18 +167,27 @@ func (s *SlackBot) parseMessage(w http.ResponseWriter, r *http.Request) ([]byte,
 		sv, err := slack.NewSecretsVerifier(r.Header, s.signinSecret)
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			return body, slackevents.EventsAPIEvent{}, fmt.Errorf("create secret verifier: %w", err)
+			return body, fmt.Errorf("create secret verifier: %w", err)
 		}
 		if _, err := sv.Write(body); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
-			return body, slackevents.EventsAPIEvent{}, fmt.Errorf("write to secret verifier: %w", err)
+			return body, fmt.Errorf("write to secret verifier: %w", err)
 		}
 		if err := sv.Ensure(); err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
-			return body, slackevents.EventsAPIEvent{}, fmt.Errorf("ensure message signature: %w", err)
+			return body, fmt.Errorf("ensure message signature: %w", err)
 		}
 	}
 
+	return body, nil
+}
+
+func (s *SlackBot) parseMessage(w http.ResponseWriter, r *http.Request) ([]byte, slackevents.EventsAPIEvent, error) {
+	body, err := s.verifiedBody(w, r)
+	if err != nil {
+		return body, slackevents.EventsAPIEvent{}, err
+	}
+
 	eventsAPIEvent, err := slackevents.ParseEvent(body, slackevents.OptionNoVerifyToken())
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -129,8 +209,37 @@ func (s *SlackBot) handleURLVerification(body []byte, w http.ResponseWriter) err
 	return nil
 }
 
-func (s *SlackBot) handleMention(_ *slackevents.AppMentionEvent) error {
-	// Currently unimplemented
+const deadlineUsage = "USAGE: @Bolt deadline <HH:MM> | off"
+
+func (s *SlackBot) handleMention(event *slackevents.AppMentionEvent) error {
+	threadTS := event.ThreadTimeStamp
+	if threadTS == "" {
+		threadTS = event.TimeStamp
+	}
+
+	response, err := s.service.HandleMention(service.MentionRequest{
+		Text:      event.Text,
+		Channel:   event.Channel,
+		User:      event.User,
+		MessageID: event.TimeStamp,
+		ThreadID:  event.ThreadTimeStamp,
+	})
+	switch {
+	case errors.Is(err, service.ErrUnknownMention):
+		// Bolt may be mentioned just in conversation, not every mention is a command
+		return nil
+	case errors.Is(err, service.ErrInvalidDeadline):
+		response = fmt.Sprintf("%v\n%s", err, deadlineUsage)
+	case err != nil:
+		return fmt.Errorf("mention handler: %w", err)
+	}
+
+	if response != "" {
+		if _, _, err := s.PostMessage(event.Channel, slack.MsgOptionText(response, false), slack.MsgOptionTS(threadTS)); err != nil {
+			return fmt.Errorf("post message: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -167,6 +276,7 @@ func (s *SlackBot) handleLink(linkEvent *slackevents.LinkSharedEvent) error {
 		Links:     links,
 		MessageID: linkEvent.MessageTimeStamp,
 		Channel:   linkEvent.Channel,
+		User:      linkEvent.User,
 	})
 	if err != nil {
 		return fmt.Errorf("link handler: %w", err)
@@ -214,6 +324,7 @@ func (s *SlackBot) handleReactionAdd(event *slackevents.ReactionAddedEvent) erro
 		Channel:       event.Item.Channel,
 		MessageUserID: event.ItemUser,
 		MessageText:   msgs[0].Text,
+		MessageID:     event.Item.Timestamp,
 	})
 	if err != nil {
 		return fmt.Errorf("reaction add handler: %w", err)
@@ -242,22 +353,43 @@ func (s *SlackBot) reactionsAddWorker(ctx context.Context) {
 	}
 }
 
-func (s *SlackBot) getUserByUserName(ctx context.Context, userName string) (slack.User, error) {
-	var err error
-	paginatedUsers := s.GetUsersPaginated()
+func (s *SlackBot) appHomeWorker(ctx context.Context) {
 	for {
-		paginatedUsers, err = paginatedUsers.Next(ctx)
+		select {
+		case event := <-s.appHomeCh:
+			if err := s.service.HandleAppHomeOpened(event.User); err != nil {
+				log.Println("Error handling app home opened:", err)
+			}
+		case <-ctx.Done():
+			log.Println("Finishing app home worker due to context cancellation")
+			return
+		}
+	}
+}
+
+func (s *SlackBot) getUserByUserName(ctx context.Context, userName string) (slack.User, error) {
+	for refreshed := false; ; refreshed = true {
+		users, version, err := s.directory.Users(ctx)
 		if err != nil {
-			break
+			return slack.User{}, fmt.Errorf("list users: %w", err)
 		}
-		for _, user := range paginatedUsers.Users {
+		for _, user := range users {
 			if user.Name == userName {
 				return user, nil
 			}
 		}
-	}
-	if err = paginatedUsers.Failure(err); err != nil {
-		return slack.User{}, fmt.Errorf("list users: %w", err)
+		if refreshed {
+			break
+		}
+
+		// The user might have joined Slack after the directory was refreshed
+		changed, err := s.directory.RefreshOnMiss(ctx, version)
+		if err != nil {
+			return slack.User{}, fmt.Errorf("refresh directory: %w", err)
+		}
+		if !changed {
+			break
+		}
 	}
 
 	return slack.User{}, fmt.Errorf("user %q not found", userName)
@@ -0,0 +1,52 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/shlex"
+	"github.com/oriser/bolt/service"
+)
+
+const settingsUsage = "USAGE: /bolt-settings [show | reminders <duration>|default | quiet-hours <from>-<to>|off|default | paid-notifications on|off | mode immediate|digest]"
+
+func (s *SlackBot) handleSettingsCommand(_ context.Context, r *http.Request, w http.ResponseWriter) (responseWritten bool, err error) {
+	if err := r.ParseForm(); err != nil {
+		return false, fmt.Errorf("parse form: %w", err)
+	}
+
+	if r.Form.Get("command") != "/bolt-settings" {
+		return false, fmt.Errorf("unknown command %q", r.Form.Get("command"))
+	}
+
+	userID := r.Form.Get("user_id")
+	splitted, err := shlex.Split(r.Form.Get("text"))
+	if err != nil {
+		return false, fmt.Errorf("shlex split %q: %w", r.Form.Get("text"), err)
+	}
+
+	var response string
+	switch {
+	case len(splitted) == 0 || (len(splitted) == 1 && splitted[0] == "show"):
+		response, err = s.service.HandleShowPreferences(userID)
+	case len(splitted) == 2:
+		response, err = s.service.HandleSetPreference(userID, splitted[0], splitted[1])
+	default:
+		_, _ = w.Write([]byte(settingsUsage))
+		return true, fmt.Errorf("bad usage")
+	}
+
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSetting) {
+			_, _ = w.Write([]byte(fmt.Sprintf("%v\n%s", err, settingsUsage)))
+			return true, err
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf("Error handling settings: %v", err)))
+		return true, err
+	}
+
+	_, _ = w.Write([]byte(response))
+	return true, nil
+}
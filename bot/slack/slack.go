@@ -2,8 +2,11 @@ package slack
 
 import (
 	"fmt"
+	"log"
+	"strings"
 
 	"github.com/oriser/bolt/service"
+	slackstorage "github.com/oriser/bolt/storage/slack"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 )
@@ -15,6 +18,7 @@ type Config struct {
 	MaxConcurrentLinks        int      `env:"SLACK_MAX_CONCURRENT_LINKS" envDefault:"100"`
 	MaxConcurrentMentions     int      `env:"SLACK_MAX_CONCURRENT_MENTIONS" envDefault:"100"`
 	MaxConcurrentReactions    int      `env:"SLACK_MAX_CONCURRENT_REACTIONS" envDefault:"100"`
+	MaxConcurrentAppHome      int      `env:"SLACK_MAX_CONCURRENT_APP_HOME" envDefault:"10"`
 	AdminSlackUserID          []string `env:"ADMIN_SLACK_USER_IDS"`
 	SlackAPIUrl               string   `env:"SLACK_API_URL"`                                  // only for testing
 	DisableSecretVerification bool     `env:"DISABLE_SECRET_VERIFICATION" envDefault:"false"` // only for testing
@@ -28,11 +32,14 @@ type SlackBot struct {
 	mentionsWorkers           int
 	linksWorkers              int
 	reactionsWorkers          int
+	appHomeWorkers            int
 	disableSecretVerification bool
 	adminsUserIds             map[string]interface{}
 	mentionsCh                chan *slackevents.AppMentionEvent
 	linksCh                   chan *slackevents.LinkSharedEvent
 	reactionsAddCh            chan *slackevents.ReactionAddedEvent
+	appHomeCh                 chan *slackevents.AppHomeOpenedEvent
+	directory                 *slackstorage.Directory
 }
 
 type Client struct {
@@ -94,7 +101,116 @@ func (c *Client) AddReaction(receiver, messageID, reaction string) error {
 	return nil
 }
 
-func (c *Client) ServiceBot(serviceHandler *service.Service) *SlackBot {
+// Slack's limits of the text of a section block, and of the blocks of a view
+const (
+	maxSectionTextLength = 3000
+	maxViewBlocks        = 100
+)
+
+// linesToSectionBlocks joins the lines into as few section blocks as possible, each within Slack's text length limit
+func linesToSectionBlocks(lines []string) []slack.Block {
+	var blocks []slack.Block
+	var sb strings.Builder
+	flush := func() {
+		if sb.Len() == 0 {
+			return
+		}
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, sb.String(), false, false), nil, nil))
+		sb.Reset()
+	}
+
+	for _, line := range lines {
+		if len(line) > maxSectionTextLength {
+			// Lengths are counted in bytes, which is stricter than Slack's count of characters
+			runes := []rune(line)
+			for len(string(runes))+len("…") > maxSectionTextLength {
+				runes = runes[:len(runes)-1]
+			}
+			line = string(runes) + "…"
+		}
+		if sb.Len() > 0 && sb.Len()+len("\n")+len(line) > maxSectionTextLength {
+			flush()
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(line)
+	}
+	flush()
+	return blocks
+}
+
+func (c *Client) PublishHome(receiver string, view *service.HomeView) error {
+	blocks := make([]slack.Block, 0, len(view.Sections)*3)
+	for i, section := range view.Sections {
+		sectionBlocks := []slack.Block{slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, section.Title, true, false))}
+		if i > 0 {
+			sectionBlocks = append([]slack.Block{slack.NewDividerBlock()}, sectionBlocks...)
+		}
+		sectionBlocks = append(sectionBlocks, linesToSectionBlocks(section.Lines)...)
+
+		if len(blocks)+len(sectionBlocks) > maxViewBlocks {
+			log.Printf("Home view of %s is too long, leaving out sections from %q\n", receiver, section.Title)
+			break
+		}
+		blocks = append(blocks, sectionBlocks...)
+	}
+
+	if _, err := c.PublishView(receiver, slack.HomeTabViewRequest{
+		Type:   slack.VTHomeTab,
+		Blocks: slack.Blocks{BlockSet: blocks},
+	}, ""); err != nil {
+		return fmt.Errorf("publish view: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) UserChannels(user string) ([]string, error) {
+	var channels []string
+	params := &slack.GetConversationsForUserParameters{
+		UserID:          user,
+		Types:           []string{"public_channel", "private_channel"},
+		ExcludeArchived: true,
+		Limit:           200,
+	}
+	for {
+		page, nextCursor, err := c.GetConversationsForUser(params)
+		if err != nil {
+			return nil, fmt.Errorf("get conversations for user: %w", err)
+		}
+		for _, channel := range page {
+			channels = append(channels, channel.ID)
+		}
+		if nextCursor == "" {
+			return channels, nil
+		}
+		params.Cursor = nextCursor
+	}
+}
+
+func (c *Client) SendPrompt(receiver, recipient, messageID string, prompt *service.ChoicePrompt) error {
+	buttons := make([]slack.BlockElement, len(prompt.Choices))
+	for i, choice := range prompt.Choices {
+		buttons[i] = slack.NewButtonBlockElement(fmt.Sprintf("%s-%s", prompt.ID, choice.ID), choice.ID,
+			slack.NewTextBlockObject(slack.PlainTextType, choice.Text, false, false))
+	}
+
+	text := slack.NewTextBlockObject(slack.MarkdownType, prompt.Text, false, false)
+	options := []slack.MsgOption{
+		slack.MsgOptionText(prompt.Text, false),
+		slack.MsgOptionBlocks(slack.NewSectionBlock(text, nil, nil), slack.NewActionBlock(prompt.ID, buttons...)),
+	}
+	if messageID != "" {
+		options = append(options, slack.MsgOptionTS(messageID))
+	}
+
+	if _, err := c.PostEphemeral(receiver, recipient, options...); err != nil {
+		return fmt.Errorf("post ephemeral: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ServiceBot(serviceHandler *service.Service, directory *slackstorage.Directory) *SlackBot {
 	sb := &SlackBot{
 		Client:                    c.Client,
 		signinSecret:              c.cfg.SigninSecret,
@@ -102,12 +218,15 @@ func (c *Client) ServiceBot(serviceHandler *service.Service) *SlackBot {
 		mentionsWorkers:           c.cfg.MaxConcurrentMentions,
 		linksWorkers:              c.cfg.MaxConcurrentLinks,
 		reactionsWorkers:          c.cfg.MaxConcurrentReactions,
+		appHomeWorkers:            c.cfg.MaxConcurrentAppHome,
 		disableSecretVerification: c.cfg.DisableSecretVerification,
 		mentionsCh:                make(chan *slackevents.AppMentionEvent),
 		linksCh:                   make(chan *slackevents.LinkSharedEvent),
 		reactionsAddCh:            make(chan *slackevents.ReactionAddedEvent),
+		appHomeCh:                 make(chan *slackevents.AppHomeOpenedEvent),
 		adminsUserIds:             make(map[string]interface{}),
 		service:                   serviceHandler,
+		directory:                 directory,
 	}
 
 	for _, userID := range c.cfg.AdminSlackUserID {
@@ -0,0 +1,52 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/shlex"
+	"github.com/oriser/bolt/service"
+	userDomain "github.com/oriser/bolt/user"
+)
+
+const usersUsage = "USAGE: /users list | show \"<name>\" | remove \"<name>\" | rename \"<name>\" \"<new name>\" | " +
+	"alias add \"<name>\" \"<wolt name>\" | alias remove \"<wolt name>\""
+
+func (s *SlackBot) handleUsersCommand(_ context.Context, r *http.Request, w http.ResponseWriter) (responseWritten bool, err error) {
+	if err := r.ParseForm(); err != nil {
+		return false, fmt.Errorf("parse form: %w", err)
+	}
+
+	if r.Form.Get("command") != "/users" {
+		return false, fmt.Errorf("unknown command %q", r.Form.Get("command"))
+	}
+
+	splitted, err := shlex.Split(r.Form.Get("text"))
+	if err != nil {
+		return false, fmt.Errorf("shlex split %q: %w", r.Form.Get("text"), err)
+	}
+
+	_, isAdmin := s.adminsUserIds[r.Form.Get("user_id")]
+	response, err := s.service.HandleUsersCommand(isAdmin, splitted)
+	if err != nil {
+		var notFound *userDomain.ErrNotFound
+		var alreadyExists *userDomain.ErrAlreadyExists
+		switch {
+		case errors.Is(err, service.ErrUsersNotAllowed):
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("Unauthorized"))
+		case errors.Is(err, service.ErrBadUsersUsage):
+			_, _ = w.Write([]byte(usersUsage))
+		case errors.As(err, &notFound) || errors.As(err, &alreadyExists):
+			_, _ = w.Write([]byte(err.Error()))
+		default:
+			_, _ = w.Write([]byte(fmt.Sprintf("Error handling users command: %v", err)))
+		}
+		return true, err
+	}
+
+	_, _ = w.Write([]byte(response))
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/oriser/bolt/service"
+)
+
+const watchVenueUsage = "USAGE: /watch-venue <venue link, ID or name>"
+
+func (s *SlackBot) handleWatchVenueCommand(_ context.Context, r *http.Request, w http.ResponseWriter) (responseWritten bool, err error) {
+	if err := r.ParseForm(); err != nil {
+		return false, fmt.Errorf("parse form: %w", err)
+	}
+
+	if r.Form.Get("command") != "/watch-venue" {
+		return false, fmt.Errorf("unknown command %q", r.Form.Get("command"))
+	}
+
+	venueRef := strings.TrimSpace(r.Form.Get("text"))
+	if venueRef == "" {
+		_, _ = w.Write([]byte(watchVenueUsage))
+		return true, fmt.Errorf("bad usage")
+	}
+
+	response, err := s.service.HandleWatchVenue(r.Form.Get("channel_id"), r.Form.Get("user_id"), venueRef)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVenue) {
+			_, _ = w.Write([]byte(fmt.Sprintf("%v\n%s", err, watchVenueUsage)))
+			return true, err
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf("Error watching venue: %v", err)))
+		return true, err
+	}
+
+	_, _ = w.Write([]byte(response))
+	return true, nil
+}
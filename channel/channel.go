@@ -0,0 +1,55 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SplitStrategy defines how the delivery rate is split between the order participants
+type SplitStrategy int
+
+const (
+	SplitEqual        SplitStrategy = iota // Every participant pays the same part of the delivery rate
+	SplitProportional                      // Every participant pays a part of the delivery rate proportional to the amount ordered
+	SplitHost                              // The host pays the whole delivery rate
+)
+
+var splitStrategiesString = map[SplitStrategy]string{
+	SplitEqual:        "equal",
+	SplitProportional: "proportional",
+	SplitHost:         "host",
+}
+
+func (s SplitStrategy) String() string {
+	return splitStrategiesString[s]
+}
+
+func ParseSplitStrategy(strategy string) (SplitStrategy, error) {
+	for s, str := range splitStrategiesString {
+		if str == strategy {
+			return s, nil
+		}
+	}
+	return SplitEqual, fmt.Errorf("unknown split strategy %q", strategy)
+}
+
+// Config is the configuration overrides of a single channel (or any other transport receiver).
+// Nil fields aren't overridden, meaning the global configuration is used for them.
+type Config struct {
+	ChannelID            string         `db:"channel_id"`
+	SplitStrategy        *SplitStrategy `db:"split_strategy"`
+	DontJoinAfter        *string        `db:"dont_join_after"` // HH:MM format, empty string means joining at any time
+	DestinationEmoji     *string        `db:"destination_emoji"`
+	DebtTracking         *bool          `db:"debt_tracking"`
+	DebtReminderInterval *time.Duration `db:"debt_reminder_interval"`
+	DebtMaximumDuration  *time.Duration `db:"debt_maximum_duration"`
+	ProgressUpdates      *bool          `db:"progress_updates"`
+	LiveRoster           *bool          `db:"live_roster"`
+}
+
+type Store interface {
+	// GetChannelConfig returns the configuration of the channel, or a configuration without overrides if it was never set
+	GetChannelConfig(ctx context.Context, channelID string) (*Config, error)
+	SaveChannelConfig(ctx context.Context, config *Config) error
+}
@@ -8,6 +8,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "wolt-login" {
+		if err := run.WoltLogin(os.Stdin, os.Stdout); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error signing in to Wolt: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run.Run(); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error running wolt bot: %v", err)
 		os.Exit(1)
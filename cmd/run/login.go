@@ -0,0 +1,57 @@
+package run
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/oriser/bolt/service"
+)
+
+type WoltLoginConfig struct {
+	Handler    service.Config
+	DBLocation string `env:"DB_LOCATION" envDefault:"/var/sqlite/store.db"`
+}
+
+// WoltLogin signs Bolt in to its Wolt account, with a refresh token read from in.
+// The refresh token can be copied from the cookies of a browser signed in to the account.
+func WoltLogin(in io.Reader, out io.Writer) error {
+	cfg := WoltLoginConfig{}
+	if err := env.Parse(&cfg); err != nil {
+		return fmt.Errorf("parsing config: %w", err)
+	}
+	if cfg.Handler.WoltSessionSecret == "" {
+		return fmt.Errorf("WOLT_SESSION_SECRET must be set for storing the session")
+	}
+
+	dbStorage, err := openDBStore(cfg.DBLocation)
+	if err != nil {
+		return err
+	}
+
+	woltClient, err := service.NewWoltClient(cfg.Handler)
+	if err != nil {
+		return fmt.Errorf("new wolt client: %w", err)
+	}
+	sessionManager, err := woltClient.NewSessionManager(dbStorage, cfg.Handler.WoltSessionSecret)
+	if err != nil {
+		return fmt.Errorf("new wolt session manager: %w", err)
+	}
+
+	_, _ = fmt.Fprint(out, "Refresh token of the Wolt account: ")
+	scanner := bufio.NewScanner(in)
+	if !scanner.Scan() {
+		if err = scanner.Err(); err != nil {
+			return fmt.Errorf("read refresh token: %w", err)
+		}
+		return fmt.Errorf("no refresh token")
+	}
+
+	if err = sessionManager.Login(context.Background(), scanner.Text()); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	_, _ = fmt.Fprintln(out, "\nSigned in to Wolt")
+	return nil
+}
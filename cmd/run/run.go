@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
@@ -13,6 +14,7 @@ import (
 	"github.com/oriser/bolt/service"
 	"github.com/oriser/bolt/storage/combined"
 	db2 "github.com/oriser/bolt/storage/db"
+	"github.com/oriser/bolt/storage/file"
 	"github.com/oriser/bolt/storage/slack"
 )
 
@@ -20,7 +22,10 @@ type Config struct {
 	Bot        slack2.Config
 	Handler    service.Config
 	SlackSore  slack.Config
+	UsersFile  file.Config
 	DBLocation string `env:"DB_LOCATION" envDefault:"/var/sqlite/store.db"`
+
+	UserStoresStatsInterval time.Duration `env:"USER_STORES_STATS_INTERVAL" envDefault:"1h"`
 }
 
 func (c Config) String() string {
@@ -42,30 +47,66 @@ func Run() error {
 		return fmt.Errorf("get bot self ID: %w", err)
 	}
 
+	dbStorage, err := openDBStore(cfg.DBLocation)
+	if err != nil {
+		return err
+	}
+
+	// Matches of Wolt users are saved in the DB, for all the stores
+	cfg.SlackSore.WoltMatches = dbStorage
 	slackStorage := slack.New(cfg.SlackSore)
 
-	db, err := sqlx.Connect("sqlite3", cfg.DBLocation)
-	if err != nil {
-		return fmt.Errorf("connect DB: %w", err)
+	ctx := context.Background()
+	links := []combined.Link{{Name: "db", Store: dbStorage, Policy: combined.PolicyAuthoritative}}
+	if cfg.UsersFile.Path != "" {
+		cfg.UsersFile.Nicknames = cfg.SlackSore.Nicknames
+		cfg.UsersFile.WoltMatches = dbStorage
+		fileStorage, err := file.New(cfg.UsersFile)
+		if err != nil {
+			return fmt.Errorf("new fileStorage: %w", err)
+		}
+		go fileStorage.Run(ctx)
+		// Users from the file are preferred over the ones from Slack
+		links = append(links, combined.Link{Name: "file", Store: fileStorage, Policy: combined.PolicyFallback})
 	}
-	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	links = append(links, combined.Link{Name: "slack", Store: slackStorage, Policy: combined.PolicyFallback})
+	userStore, err := combined.NewChain(links...)
 	if err != nil {
-		return fmt.Errorf("new sqlite3 migration driver: %w", err)
+		return fmt.Errorf("new user store chain: %w", err)
 	}
-	dbStorage, err := db2.New(db, driver, "")
-	if err != nil {
-		return fmt.Errorf("new dbStorage: %w", err)
+	if cfg.UserStoresStatsInterval > 0 {
+		go userStore.LogStats(ctx, cfg.UserStoresStatsInterval)
 	}
 
-	serviceHandler, err := service.New(cfg.Handler, combined.NewPrioritizedUserStore(dbStorage, slackStorage), dbStorage, dbStorage, id, slackClient)
+	serviceHandler, err := service.New(cfg.Handler, userStore, dbStorage, dbStorage, dbStorage, dbStorage, dbStorage, dbStorage, id, slackClient)
 	if err != nil {
 		return fmt.Errorf("new service: %w", err)
 	}
 
-	slackBot := slackClient.ServiceBot(serviceHandler)
-	if err := slackBot.ListenAndServe(context.Background()); err != nil {
+	go serviceHandler.RunScheduledJobs(ctx)
+	serviceHandler.ResumeVenueWatches(ctx)
+	go slackStorage.Directory().Run(ctx)
+
+	slackBot := slackClient.ServiceBot(serviceHandler, slackStorage.Directory())
+	if err := slackBot.ListenAndServe(ctx); err != nil {
 		return fmt.Errorf("ListenAndServe: %w", err)
 	}
 
 	return nil
 }
+
+func openDBStore(location string) (*db2.DBStore, error) {
+	db, err := sqlx.Connect("sqlite3", location)
+	if err != nil {
+		return nil, fmt.Errorf("connect DB: %w", err)
+	}
+	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("new sqlite3 migration driver: %w", err)
+	}
+	dbStorage, err := db2.New(db, driver, "")
+	if err != nil {
+		return nil, fmt.Errorf("new dbStorage: %w", err)
+	}
+	return dbStorage, nil
+}
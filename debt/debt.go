@@ -21,6 +21,14 @@ type Store interface {
 	AddDebt(debt *Debt) error
 	RemoveDebtInOrderID(orderID, debtID string) error
 	ListDebtsForOrderID(orderID string) ([]*Debt, error)
+	ListDebts(filter ListFilter) ([]*Debt, error)
+}
+
+// ListFilter filters debts by borrowers or lenders, and by when they were created. Empty filter means all debts.
+type ListFilter struct {
+	BorrowerIDs []string
+	LenderIDs   []string
+	Since       time.Time // Debts created since that time, zero means no limit
 }
 
 func NewDebt(borrowerID, lenderID, orderID, initiatedTransportID, messageID string, amount float64) *Debt {
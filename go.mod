@@ -20,6 +20,9 @@ require (
 	github.com/slack-go/slack v0.14.0
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/net v0.29.0
+	golang.org/x/sync v0.3.0
+	golang.org/x/time v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -44,5 +47,4 @@ require (
 	golang.org/x/crypto v0.27.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -37,4 +37,10 @@ type Order struct {
 
 type Store interface {
 	SaveOrder(ctx context.Context, order *Order) error
+	ListOrders(ctx context.Context, filter ListFilter) ([]*Order, error)
+}
+
+type ListFilter struct {
+	Receiver string
+	Since    time.Time // Orders saved since that time, zero means no limit
 }
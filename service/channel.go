@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/oriser/bolt/channel"
+)
+
+const (
+	ChannelSettingSplit            = "split"
+	ChannelSettingDontJoinAfter    = "dont-join-after"
+	ChannelSettingDestinationEmoji = "destination-emoji"
+	ChannelSettingDebts            = "debts"
+	ChannelSettingReminders        = "reminders"
+	ChannelSettingDebtsMaxDuration = "debts-max-duration"
+	ChannelSettingProgressUpdates  = "progress-updates"
+	ChannelSettingLiveRoster       = "live-roster"
+)
+
+const (
+	// ChannelConfigPolicyAdmins allows just Bolt's admins to change channels configuration
+	ChannelConfigPolicyAdmins = "admins"
+	// ChannelConfigPolicyMembers allows every member of a channel to change its configuration
+	ChannelConfigPolicyMembers = "members"
+)
+
+var (
+	// ErrBadChannelConfigUsage is returned when the channel config command arguments don't match any of its usages
+	ErrBadChannelConfigUsage = errors.New("bad channel config usage")
+	// ErrChannelConfigNotAllowed is returned when the user isn't allowed to change the configuration of the channel
+	ErrChannelConfigNotAllowed = errors.New("not allowed to change the configuration of this channel")
+)
+
+// channelSettings are the effective settings for orders in a channel, after applying the channel overrides on the global config
+type channelSettings struct {
+	splitStrategy        channel.SplitStrategy
+	dontJoinAfter        time.Time // Zero means joining at any time
+	destinationEmoji     string
+	debtTracking         bool
+	debtReminderInterval time.Duration
+	debtMaximumDuration  time.Duration
+	progressUpdates      bool
+	liveRoster           bool
+}
+
+func (h *Service) defaultChannelSettings() *channelSettings {
+	return &channelSettings{
+		splitStrategy:        channel.SplitEqual,
+		dontJoinAfter:        h.dontJoinAfter,
+		destinationEmoji:     h.cfg.OrderDestinationEmoji,
+		debtTracking:         h.debtStore != nil,
+		debtReminderInterval: h.cfg.DebtReminderInterval,
+		debtMaximumDuration:  h.cfg.DebtMaximumDuration,
+		progressUpdates:      true,
+		liveRoster:           h.cfg.LiveRoster,
+	}
+}
+
+func parseDontJoinAfter(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("15:04", value)
+}
+
+// channelSettings returns the effective settings for the given channel, falling back to the global config on error
+func (h *Service) channelSettings(channelID string) *channelSettings {
+	settings := h.defaultChannelSettings()
+	if h.channelStore == nil {
+		return settings
+	}
+
+	config, err := h.channelStore.GetChannelConfig(context.Background(), channelID)
+	if err != nil {
+		log.Printf("Error getting config for channel %q, using the global config: %v\n", channelID, err)
+		return settings
+	}
+
+	if config.SplitStrategy != nil {
+		settings.splitStrategy = *config.SplitStrategy
+	}
+	if config.DontJoinAfter != nil {
+		dontJoinAfter, err := parseDontJoinAfter(*config.DontJoinAfter)
+		if err != nil {
+			log.Printf("Error parsing dont join after %q of channel %q: %v\n", *config.DontJoinAfter, channelID, err)
+		} else {
+			settings.dontJoinAfter = dontJoinAfter
+		}
+	}
+	if config.DestinationEmoji != nil {
+		settings.destinationEmoji = *config.DestinationEmoji
+	}
+	if config.DebtTracking != nil {
+		settings.debtTracking = *config.DebtTracking && h.debtStore != nil
+	}
+	if config.DebtReminderInterval != nil {
+		settings.debtReminderInterval = *config.DebtReminderInterval
+	}
+	if config.DebtMaximumDuration != nil {
+		settings.debtMaximumDuration = *config.DebtMaximumDuration
+	}
+	if config.ProgressUpdates != nil {
+		settings.progressUpdates = *config.ProgressUpdates
+	}
+	if config.LiveRoster != nil {
+		settings.liveRoster = *config.LiveRoster
+	}
+
+	return settings
+}
+
+func formatOnOff(value bool) string {
+	if value {
+		return "on"
+	}
+	return "off"
+}
+
+func (h *Service) buildChannelConfigMessage(config *channel.Config) string {
+	settings := h.channelSettings(config.ChannelID)
+
+	// Marking which of the settings are overridden in this channel
+	source := func(overridden bool) string {
+		if overridden {
+			return ""
+		}
+		return " (default)"
+	}
+
+	dontJoinAfter := "never"
+	if !settings.dontJoinAfter.IsZero() {
+		dontJoinAfter = settings.dontJoinAfter.Format("15:04")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Bolt settings for <#%s>:\n", config.ChannelID))
+	sb.WriteString(fmt.Sprintf("• Delivery rate split (`%s`): %s%s\n", ChannelSettingSplit, settings.splitStrategy, source(config.SplitStrategy != nil)))
+	sb.WriteString(fmt.Sprintf("• Don't join orders after (`%s`): %s%s\n", ChannelSettingDontJoinAfter, dontJoinAfter, source(config.DontJoinAfter != nil)))
+	sb.WriteString(fmt.Sprintf("• Destination emoji (`%s`): :%s:%s\n", ChannelSettingDestinationEmoji, settings.destinationEmoji, source(config.DestinationEmoji != nil)))
+	sb.WriteString(fmt.Sprintf("• Debts tracking (`%s`): %s%s\n", ChannelSettingDebts, formatOnOff(settings.debtTracking), source(config.DebtTracking != nil)))
+	sb.WriteString(fmt.Sprintf("• Debt reminders (`%s`): every %s%s\n", ChannelSettingReminders, settings.debtReminderInterval, source(config.DebtReminderInterval != nil)))
+	sb.WriteString(fmt.Sprintf("• Stop tracking debts after (`%s`): %s%s\n", ChannelSettingDebtsMaxDuration, settings.debtMaximumDuration, source(config.DebtMaximumDuration != nil)))
+	sb.WriteString(fmt.Sprintf("• Delivery progress updates (`%s`): %s%s\n", ChannelSettingProgressUpdates, formatOnOff(settings.progressUpdates), source(config.ProgressUpdates != nil)))
+	sb.WriteString(fmt.Sprintf("• Live roster of open groups (`%s`): %s%s\n", ChannelSettingLiveRoster, formatOnOff(settings.liveRoster), source(config.LiveRoster != nil)))
+
+	return sb.String()
+}
+
+func (h *Service) HandleShowChannelConfig(channelID string) (string, error) {
+	if h.channelStore == nil {
+		return "", fmt.Errorf("channel configuration is not supported")
+	}
+
+	config, err := h.channelStore.GetChannelConfig(context.Background(), channelID)
+	if err != nil {
+		return "", fmt.Errorf("get channel config: %w", err)
+	}
+	return h.buildChannelConfigMessage(config), nil
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%w: %q is not a valid duration (for example 6h)", ErrInvalidSetting, value)
+	}
+	return duration, nil
+}
+
+// canConfigureChannel returns whether the user can change the configuration of the channel, according to CHANNEL_CONFIG_POLICY
+func (h *Service) canConfigureChannel(channelID, transportID string, isAdmin bool) (bool, error) {
+	if isAdmin {
+		return true, nil
+	}
+	if h.cfg.ChannelConfigPolicy != ChannelConfigPolicyMembers {
+		return false, nil
+	}
+
+	channels, err := h.eventNotification.UserChannels(transportID)
+	if err != nil {
+		return false, fmt.Errorf("user channels: %w", err)
+	}
+	for _, channel := range channels {
+		if channel == channelID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HandleChannelConfigCommand shows the configuration of the channel when called without arguments (or with "show"),
+// or changes one of its settings when called with the setting and its value
+func (h *Service) HandleChannelConfigCommand(channelID, requestedBy string, isAdmin bool, args []string) (string, error) {
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "show"):
+		return h.HandleShowChannelConfig(channelID)
+	case len(args) == 2:
+		allowed, err := h.canConfigureChannel(channelID, requestedBy, isAdmin)
+		if err != nil {
+			return "", fmt.Errorf("check channel config permission: %w", err)
+		}
+		if !allowed {
+			return "", ErrChannelConfigNotAllowed
+		}
+		return h.HandleSetChannelConfig(channelID, args[0], args[1])
+	default:
+		return "", ErrBadChannelConfigUsage
+	}
+}
+
+// HandleSetChannelConfig overrides a setting for the channel, "default" value removes the override
+func (h *Service) HandleSetChannelConfig(channelID, setting, value string) (string, error) {
+	if h.channelStore == nil {
+		return "", fmt.Errorf("channel configuration is not supported")
+	}
+
+	config, err := h.channelStore.GetChannelConfig(context.Background(), channelID)
+	if err != nil {
+		return "", fmt.Errorf("get channel config: %w", err)
+	}
+
+	reset := value == "default"
+	switch setting {
+	case ChannelSettingSplit:
+		config.SplitStrategy = nil
+		if reset {
+			break
+		}
+		strategy, err := channel.ParseSplitStrategy(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidSetting, err)
+		}
+		config.SplitStrategy = &strategy
+	case ChannelSettingDontJoinAfter:
+		config.DontJoinAfter = nil
+		if reset {
+			break
+		}
+		if value == "off" {
+			value = ""
+		}
+		if _, err := parseDontJoinAfter(value); err != nil {
+			return "", fmt.Errorf("%w: %q is not a valid time (HH:MM format)", ErrInvalidSetting, value)
+		}
+		config.DontJoinAfter = &value
+	case ChannelSettingDestinationEmoji:
+		config.DestinationEmoji = nil
+		if reset {
+			break
+		}
+		emoji := strings.Trim(value, ":")
+		if emoji == "" {
+			return "", fmt.Errorf("%w: empty emoji", ErrInvalidSetting)
+		}
+		config.DestinationEmoji = &emoji
+	case ChannelSettingDebts:
+		config.DebtTracking = nil
+		if reset {
+			break
+		}
+		debtTracking, err := parseOnOff(value)
+		if err != nil {
+			return "", err
+		}
+		config.DebtTracking = &debtTracking
+	case ChannelSettingReminders:
+		config.DebtReminderInterval = nil
+		if reset {
+			break
+		}
+		interval, err := parsePositiveDuration(value)
+		if err != nil {
+			return "", err
+		}
+		config.DebtReminderInterval = &interval
+	case ChannelSettingDebtsMaxDuration:
+		config.DebtMaximumDuration = nil
+		if reset {
+			break
+		}
+		duration, err := parsePositiveDuration(value)
+		if err != nil {
+			return "", err
+		}
+		config.DebtMaximumDuration = &duration
+	case ChannelSettingProgressUpdates:
+		config.ProgressUpdates = nil
+		if reset {
+			break
+		}
+		progressUpdates, err := parseOnOff(value)
+		if err != nil {
+			return "", err
+		}
+		config.ProgressUpdates = &progressUpdates
+	case ChannelSettingLiveRoster:
+		config.LiveRoster = nil
+		if reset {
+			break
+		}
+		liveRoster, err := parseOnOff(value)
+		if err != nil {
+			return "", err
+		}
+		config.LiveRoster = &liveRoster
+	default:
+		return "", fmt.Errorf("%w: unknown setting %q", ErrInvalidSetting, setting)
+	}
+
+	if err = h.channelStore.SaveChannelConfig(context.Background(), config); err != nil {
+		return "", fmt.Errorf("save channel config: %w", err)
+	}
+	return "OK, saved.\n" + h.buildChannelConfigMessage(config), nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/channel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChannelConfigTestService(policy string) (*Service, *fakeChannelStore) {
+	channelStore := &fakeChannelStore{}
+	return &Service{
+		cfg: Config{
+			OrderDestinationEmoji: "house",
+			DebtReminderInterval:  3 * time.Hour,
+			DebtMaximumDuration:   24 * time.Hour,
+			ChannelConfigPolicy:   policy,
+		},
+		debtStore:         &fakeDebtStore{},
+		channelStore:      channelStore,
+		eventNotification: &fakeNotification{channels: map[string][]string{"U1": {"C1", "C2"}, "U2": {"C2"}}},
+	}, channelStore
+}
+
+func TestHandleChannelConfigCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		policy        string
+		requestedBy   string
+		isAdmin       bool
+		args          []string
+		expectedError error
+		expectedSaved bool
+	}{
+		{name: "Show without arguments", policy: ChannelConfigPolicyAdmins, requestedBy: "U2"},
+		{name: "Show", policy: ChannelConfigPolicyAdmins, requestedBy: "U2", args: []string{"show"}},
+		{name: "Setting without a value", policy: ChannelConfigPolicyAdmins, isAdmin: true, args: []string{"split"}, expectedError: ErrBadChannelConfigUsage},
+		{name: "Too many arguments", policy: ChannelConfigPolicyAdmins, isAdmin: true, args: []string{"split", "host", "now"}, expectedError: ErrBadChannelConfigUsage},
+		{name: "Admin", policy: ChannelConfigPolicyAdmins, requestedBy: "U3", isAdmin: true, args: []string{"split", "host"}, expectedSaved: true},
+		{name: "Member with admins policy", policy: ChannelConfigPolicyAdmins, requestedBy: "U1", args: []string{"split", "host"}, expectedError: ErrChannelConfigNotAllowed},
+		{name: "Member with default policy", requestedBy: "U1", args: []string{"split", "host"}, expectedError: ErrChannelConfigNotAllowed},
+		{name: "Member with members policy", policy: ChannelConfigPolicyMembers, requestedBy: "U1", args: []string{"split", "host"}, expectedSaved: true},
+		{name: "Non member with members policy", policy: ChannelConfigPolicyMembers, requestedBy: "U2", args: []string{"split", "host"}, expectedError: ErrChannelConfigNotAllowed},
+		{name: "Admin which isn't a member with members policy", policy: ChannelConfigPolicyMembers, requestedBy: "U3", isAdmin: true, args: []string{"split", "host"}, expectedSaved: true},
+		{name: "Unknown setting", policy: ChannelConfigPolicyAdmins, isAdmin: true, args: []string{"show", "all"}, expectedError: ErrInvalidSetting},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, channelStore := newChannelConfigTestService(tc.policy)
+
+			response, err := h.HandleChannelConfigCommand("C1", tc.requestedBy, tc.isAdmin, tc.args)
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v but got %v", tc.expectedError, err)
+				assert.Empty(t, channelStore.configs)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, response, "Bolt settings for <#C1>:\n")
+
+			if !tc.expectedSaved {
+				assert.Empty(t, channelStore.configs)
+				return
+			}
+			assert.Contains(t, response, "OK, saved.\n")
+			require.Contains(t, channelStore.configs, "C1")
+			assert.Equal(t, channel.SplitHost, *channelStore.configs["C1"].SplitStrategy)
+		})
+	}
+}
+
+func TestHandleSetChannelConfig(t *testing.T) {
+	split := channel.SplitProportional
+	dontJoinAfter := "12:30"
+	noDontJoinAfter := ""
+	emoji := "pizza"
+	on, off := true, false
+	sixHours := 6 * time.Hour
+	twoDays := 48 * time.Hour
+
+	for _, tc := range []struct {
+		name          string
+		setting       string
+		value         string
+		expected      channel.Config
+		expectedError bool
+	}{
+		{name: "Split", setting: ChannelSettingSplit, value: "proportional", expected: channel.Config{SplitStrategy: &split}},
+		{name: "Unknown split", setting: ChannelSettingSplit, value: "random", expectedError: true},
+		{name: "Don't join after", setting: ChannelSettingDontJoinAfter, value: "12:30", expected: channel.Config{DontJoinAfter: &dontJoinAfter}},
+		{name: "Always join", setting: ChannelSettingDontJoinAfter, value: "off", expected: channel.Config{DontJoinAfter: &noDontJoinAfter}},
+		{name: "Invalid don't join after", setting: ChannelSettingDontJoinAfter, value: "25:00", expectedError: true},
+		{name: "Destination emoji", setting: ChannelSettingDestinationEmoji, value: ":pizza:", expected: channel.Config{DestinationEmoji: &emoji}},
+		{name: "Empty destination emoji", setting: ChannelSettingDestinationEmoji, value: "::", expectedError: true},
+		{name: "Debts", setting: ChannelSettingDebts, value: "off", expected: channel.Config{DebtTracking: &off}},
+		{name: "Invalid debts", setting: ChannelSettingDebts, value: "maybe", expectedError: true},
+		{name: "Reminders", setting: ChannelSettingReminders, value: "6h", expected: channel.Config{DebtReminderInterval: &sixHours}},
+		{name: "Negative reminders", setting: ChannelSettingReminders, value: "-6h", expectedError: true},
+		{name: "Debts max duration", setting: ChannelSettingDebtsMaxDuration, value: "48h", expected: channel.Config{DebtMaximumDuration: &twoDays}},
+		{name: "Invalid debts max duration", setting: ChannelSettingDebtsMaxDuration, value: "two days", expectedError: true},
+		{name: "Progress updates", setting: ChannelSettingProgressUpdates, value: "off", expected: channel.Config{ProgressUpdates: &off}},
+		{name: "Live roster", setting: ChannelSettingLiveRoster, value: "on", expected: channel.Config{LiveRoster: &on}},
+		{name: "Unknown setting", setting: "color", value: "blue", expectedError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, channelStore := newChannelConfigTestService(ChannelConfigPolicyAdmins)
+
+			_, err := h.HandleSetChannelConfig("C1", tc.setting, tc.value)
+			if tc.expectedError {
+				assert.True(t, errors.Is(err, ErrInvalidSetting), "expected invalid setting but got %v", err)
+				assert.Empty(t, channelStore.configs)
+				return
+			}
+			require.NoError(t, err)
+			tc.expected.ChannelID = "C1"
+			assert.Equal(t, &tc.expected, channelStore.configs["C1"])
+		})
+	}
+}
+
+func TestHandleSetChannelConfigDefault(t *testing.T) {
+	h, channelStore := newChannelConfigTestService(ChannelConfigPolicyAdmins)
+	_, err := h.HandleSetChannelConfig("C1", ChannelSettingSplit, "host")
+	require.NoError(t, err)
+	_, err = h.HandleSetChannelConfig("C1", ChannelSettingLiveRoster, "on")
+	require.NoError(t, err)
+
+	response, err := h.HandleSetChannelConfig("C1", ChannelSettingSplit, "default")
+	require.NoError(t, err)
+	assert.Nil(t, channelStore.configs["C1"].SplitStrategy)
+	assert.NotNil(t, channelStore.configs["C1"].LiveRoster, "other overrides should be kept")
+	assert.Contains(t, response, "• Delivery rate split (`split`): equal (default)\n")
+	assert.Contains(t, response, "• Live roster of open groups (`live-roster`): on\n")
+}
+
+func TestChannelSettings(t *testing.T) {
+	h, channelStore := newChannelConfigTestService(ChannelConfigPolicyAdmins)
+	defaults := &channelSettings{
+		splitStrategy:        channel.SplitEqual,
+		destinationEmoji:     "house",
+		debtTracking:         true,
+		debtReminderInterval: 3 * time.Hour,
+		debtMaximumDuration:  24 * time.Hour,
+		progressUpdates:      true,
+	}
+
+	// Channels without overrides get the global config
+	assert.Equal(t, defaults, h.channelSettings("C1"))
+
+	split := channel.SplitHost
+	dontJoinAfter := "13:00"
+	emoji := "office"
+	on, off := true, false
+	interval := time.Hour
+	channelStore.configs = map[string]*channel.Config{
+		"C2": {
+			ChannelID:            "C2",
+			SplitStrategy:        &split,
+			DontJoinAfter:        &dontJoinAfter,
+			DestinationEmoji:     &emoji,
+			DebtTracking:         &off,
+			DebtReminderInterval: &interval,
+			ProgressUpdates:      &off,
+			LiveRoster:           &on,
+		},
+	}
+	assert.Equal(t, &channelSettings{
+		splitStrategy:        channel.SplitHost,
+		dontJoinAfter:        time.Date(0, time.January, 1, 13, 0, 0, 0, time.UTC),
+		destinationEmoji:     "office",
+		debtTracking:         false,
+		debtReminderInterval: time.Hour,
+		debtMaximumDuration:  24 * time.Hour,
+		progressUpdates:      false,
+		liveRoster:           true,
+	}, h.channelSettings("C2"))
+
+	// Invalid overrides fall back to the global config
+	invalid := "noon"
+	channelStore.configs["C3"] = &channel.Config{ChannelID: "C3", DontJoinAfter: &invalid}
+	assert.Equal(t, defaults, h.channelSettings("C3"))
+
+	// Debts can't be tracked without a debt store, even if the channel asks for it
+	h.debtStore = nil
+	channelStore.configs["C4"] = &channel.Config{ChannelID: "C4", DebtTracking: &on}
+	assert.False(t, h.channelSettings("C4").debtTracking)
+
+	// Without a channel store every channel gets the global config
+	h.channelStore = nil
+	assert.Equal(t, h.defaultChannelSettings(), h.channelSettings("C2"))
+}
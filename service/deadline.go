@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oriser/bolt/wolt"
+)
+
+// JoinIntentReaction is the reaction to the message with the group link, of people who are going to join the group
+const JoinIntentReaction = "raised_hand"
+
+var mentionRe = regexp.MustCompile(`<@[A-Z0-9]+>`)
+
+var (
+	// ErrInvalidDeadline is returned when a deadline isn't a valid time of the rest of the day
+	ErrInvalidDeadline = errors.New("invalid deadline")
+	// ErrUnknownMention is returned when Bolt is mentioned without starting with a command it knows, such mentions aren't answered
+	ErrUnknownMention = errors.New("unknown mention command")
+)
+
+type MentionRequest struct {
+	Text      string
+	Channel   string
+	User      string
+	MessageID string
+	ThreadID  string // The thread the mention was sent in, empty if it's not in a thread
+}
+
+// orderDeadline is the time the orders of the group linked in a message close at
+type orderDeadline struct {
+	at        time.Time
+	receiver  string
+	messageID string // The message with the group link
+	setBy     string // Transport ID of whoever set the deadline, warned if the group isn't purchased when it passes
+	joiners   sync.Map
+	cancel    context.CancelFunc
+}
+
+// HandleMention handles commands Bolt is mentioned with, the command is the first word after the mentions
+func (h *Service) HandleMention(req MentionRequest) (string, error) {
+	fields := strings.Fields(mentionRe.ReplaceAllString(req.Text, " "))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "deadline") {
+		return "", ErrUnknownMention
+	}
+	if len(fields) != 2 {
+		return "", fmt.Errorf("%w: expected just the time", ErrInvalidDeadline)
+	}
+
+	linkMessageID := req.MessageID
+	if req.ThreadID != "" {
+		linkMessageID = req.ThreadID
+	}
+	key := req.Channel + "/" + linkMessageID
+
+	if strings.EqualFold(fields[1], "off") {
+		h.orderDeadlinesLock.Lock()
+		existing, ok := h.orderDeadlines[key]
+		delete(h.orderDeadlines, key)
+		h.orderDeadlinesLock.Unlock()
+		if ok {
+			existing.cancel()
+			return "OK, I removed the deadline.", nil
+		}
+		return "There's no deadline to remove.", nil
+	}
+
+	at, err := h.parseDeadline(fields[1], time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), at.Add(time.Minute))
+	deadline := &orderDeadline{
+		at:        at,
+		receiver:  req.Channel,
+		messageID: linkMessageID,
+		setBy:     req.User,
+		cancel:    cancel,
+	}
+	// Replacing the existing deadline under the lock, so concurrent mentions won't both keep running
+	h.orderDeadlinesLock.Lock()
+	if existing, ok := h.orderDeadlines[key]; ok {
+		existing.cancel()
+	}
+	h.orderDeadlines[key] = deadline
+	h.orderDeadlinesLock.Unlock()
+	go h.runDeadline(ctx, key, deadline)
+
+	reminders := make([]string, 0, len(h.cfg.DeadlineReminders))
+	for _, reminder := range h.sortedDeadlineReminders() {
+		if time.Until(at) > reminder {
+			reminders = append(reminders, formatTimeUntil(reminder))
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":alarm_clock: Orders close at %s (in %s).", formatVenueTime(at, at.Location()), formatTimeUntil(time.Until(at))))
+	if len(reminders) > 0 {
+		sb.WriteString(fmt.Sprintf(" I'll remind here %s before.", strings.Join(reminders, " and ")))
+	}
+	sb.WriteString(fmt.Sprintf(" Going to join? React with :%s: to the group link and I'll make sure you don't miss it.", JoinIntentReaction))
+	return sb.String(), nil
+}
+
+// parseDeadline parses a HH:MM time of the rest of the day, in the timezone of DONT_JOIN_AFTER_TZ
+func (h *Service) parseDeadline(value string, now time.Time) (time.Time, error) {
+	timeOfDay, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a valid time (HH:MM format)", ErrInvalidDeadline, value)
+	}
+
+	location := time.Local
+	if h.dontJoinAfterTZ != nil {
+		location = h.dontJoinAfterTZ
+	}
+	now = now.In(location)
+	at := time.Date(now.Year(), now.Month(), now.Day(), timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, location)
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("%w: %s already passed", ErrInvalidDeadline, value)
+	}
+	return at, nil
+}
+
+// sortedDeadlineReminders returns how long before deadlines to remind about them, the earliest reminder first
+func (h *Service) sortedDeadlineReminders() []time.Duration {
+	reminders := make([]time.Duration, 0, len(h.cfg.DeadlineReminders))
+	for _, reminder := range h.cfg.DeadlineReminders {
+		if reminder > 0 {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		return reminders[i] > reminders[j]
+	})
+	return reminders
+}
+
+// addJoinIntent remembers who is going to join the group linked in the message, if it has a deadline
+func (h *Service) addJoinIntent(req ReactionAddRequest) {
+	h.orderDeadlinesLock.Lock()
+	deadline, ok := h.orderDeadlines[req.Channel+"/"+req.MessageID]
+	h.orderDeadlinesLock.Unlock()
+	if !ok {
+		return
+	}
+	deadline.joiners.Store(req.FromUserID, true)
+}
+
+// runDeadline reminds about the deadline before it, and warns whoever set it if the group isn't purchased when it passes
+func (h *Service) runDeadline(ctx context.Context, key string, deadline *orderDeadline) {
+	defer func() {
+		deadline.cancel()
+		// The deadline may have been replaced by a new one meanwhile
+		h.orderDeadlinesLock.Lock()
+		if h.orderDeadlines[key] == deadline {
+			delete(h.orderDeadlines, key)
+		}
+		h.orderDeadlinesLock.Unlock()
+	}()
+
+	for _, reminder := range h.sortedDeadlineReminders() {
+		remindAt := deadline.at.Add(-reminder)
+		if time.Now().After(remindAt) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(remindAt)):
+		}
+
+		if len(h.ordersOfMessage(deadline.receiver, deadline.messageID)) == 0 {
+			// The group isn't tracked (yet or anymore), so there's nothing to remind about
+			continue
+		}
+		purchased, missing := h.deadlineProgress(ctx, deadline)
+		if purchased {
+			return
+		}
+		message := fmt.Sprintf(":alarm_clock: Orders close in %s (at %s)", formatTimeUntil(time.Until(deadline.at)), formatVenueTime(deadline.at, deadline.at.Location()))
+		if len(missing) > 0 {
+			message += fmt.Sprintf("\n%s you said you'd join, you're not in the group yet", strings.Join(missing, " "))
+		}
+		_, _ = h.informEvent(deadline.receiver, message, "", deadline.messageID)
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(time.Until(deadline.at)):
+	}
+
+	orders := h.ordersOfMessage(deadline.receiver, deadline.messageID)
+	if len(orders) == 0 {
+		return
+	}
+	if purchased, _ := h.deadlineProgress(ctx, deadline); !purchased {
+		_, _ = h.informEvent(deadline.receiver, fmt.Sprintf(":rotating_light: <@%s> the deadline passed and the group wasn't purchased yet", deadline.setBy), "", deadline.messageID)
+	}
+}
+
+// deadlineProgress returns whether all the groups linked in the message were purchased, and mentions of whoever said they'd join but didn't join yet.
+// If the groups aren't tracked (yet), none of them is considered purchased.
+func (h *Service) deadlineProgress(ctx context.Context, deadline *orderDeadline) (bool, []string) {
+	orders := h.ordersOfMessage(deadline.receiver, deadline.messageID)
+	joined := make(map[string]bool)
+	purchased := len(orders) > 0
+	for _, order := range orders {
+		details, err := order.woltGroup.Details(ctx)
+		if err != nil {
+			log.Printf("Error getting details of order %q for its deadline: %v\n", order.id, err)
+			purchased = false
+			continue
+		}
+		if details.Status == wolt.StatusActive {
+			purchased = false
+		}
+		for _, participant := range details.Participants {
+			user := h.lookupUser(ctx, participant.Name(), participant.UserID, h.participantEmail(participant.Name(), participant.Email))
+			if user != nil {
+				joined[user.TransportID] = true
+			}
+		}
+	}
+
+	missing := make([]string, 0)
+	deadline.joiners.Range(func(key, _ any) bool {
+		if !joined[key.(string)] {
+			missing = append(missing, fmt.Sprintf("<@%s>", key))
+		}
+		return true
+	})
+	sort.Strings(missing)
+	return purchased, missing
+}
+
+// ordersOfMessage returns the tracked group orders linked in the message
+func (h *Service) ordersOfMessage(receiver, messageID string) []*groupOrder {
+	orders := make([]*groupOrder, 0)
+	h.currentlyWorkingOrders.Range(func(_, value any) bool {
+		order, ok := value.(*groupOrder)
+		if ok && order != nil && order.receiver == receiver && order.messageID == messageID {
+			orders = append(orders, order)
+		}
+		return true
+	})
+	return orders
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/testing/woltserver"
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/oriser/bolt/wolt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deadlineTest struct {
+	h            *Service
+	notification *fakeNotification
+	woltServer   *woltserver.WoltServer
+	orderID      string
+}
+
+// newDeadlineTest returns a service tracking a group order of Yossi (the host) and Dana, linked in message M1 of channel C1
+func newDeadlineTest(t *testing.T) *deadlineTest {
+	woltServer := woltserver.NewWoltServer(t)
+	woltServer.Start()
+	t.Cleanup(woltServer.Stop)
+
+	venueID := woltServer.CreateVenue(woltserver.Coordinate{})
+	shortID, orderID := woltServer.CreateOrder("Yossi Host", venueID, woltserver.Coordinate{})
+	_, err := woltServer.AddParticipant(orderID, "Dana Levi")
+	require.NoError(t, err)
+
+	woltClient, err := wolt.NewClient(wolt.WoltAddr{
+		BaseAddr:    "http://" + woltServer.Addr(),
+		APIBaseAddr: "http://" + woltServer.Addr(),
+	}, wolt.RetryConfig{
+		HTTPMaxRetries:       1000, // The test server returns 502 randomly
+		HTTPMinRetryDuration: time.Millisecond,
+		HTTPMaxRetryDuration: 5 * time.Millisecond,
+	}, wolt.ClientConfig{})
+	require.NoError(t, err)
+	group, err := woltClient.GroupWithExistingID(shortID)
+	require.NoError(t, err)
+	require.NoError(t, group.Join(context.Background()))
+
+	notification := &fakeNotification{}
+	h := &Service{
+		cfg: Config{DeadlineReminders: []time.Duration{200 * time.Millisecond}},
+		userStore: &fakeUserStore{users: []*userDomain.User{
+			{ID: "U1", FullName: "Dana Levi", TransportID: "U1"},
+			{ID: "U2", FullName: "Noa Cohen", TransportID: "U2"},
+			{ID: "U3", FullName: "Yossi Host", TransportID: "U3"},
+		}},
+		eventNotification: notification,
+		orderDeadlines:    make(map[string]*orderDeadline),
+	}
+	h.currentlyWorkingOrders.Store(shortID, &groupOrder{id: orderID, receiver: "C1", messageID: "M1", woltGroup: group})
+	return &deadlineTest{h: h, notification: notification, woltServer: woltServer, orderID: orderID}
+}
+
+// startDeadline sets a deadline for the group linked in M1 like a mention of U5 does, returning a channel closed once the deadline is done
+func (d *deadlineTest) startDeadline(at time.Time) (*orderDeadline, <-chan struct{}) {
+	ctx, cancel := context.WithDeadline(context.Background(), at.Add(time.Minute))
+	deadline := &orderDeadline{at: at, receiver: "C1", messageID: "M1", setBy: "U5", cancel: cancel}
+	d.h.orderDeadlinesLock.Lock()
+	d.h.orderDeadlines["C1/M1"] = deadline
+	d.h.orderDeadlinesLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.h.runDeadline(ctx, "C1/M1", deadline)
+		close(done)
+	}()
+	return deadline, done
+}
+
+func waitForDeadline(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadline didn't finish")
+	}
+}
+
+func TestDeadlineReminderAndWarning(t *testing.T) {
+	d := newDeadlineTest(t)
+
+	_, done := d.startDeadline(time.Now().Add(400 * time.Millisecond))
+	// Reacting to the group link with the join intent reaction
+	d.h.addJoinIntent(ReactionAddRequest{Reaction: JoinIntentReaction, FromUserID: "U1", Channel: "C1", MessageID: "M1"})
+	d.h.addJoinIntent(ReactionAddRequest{Reaction: JoinIntentReaction, FromUserID: "U2", Channel: "C1", MessageID: "M1"})
+	// Reactions to other messages aren't join intents of this group
+	d.h.addJoinIntent(ReactionAddRequest{Reaction: JoinIntentReaction, FromUserID: "U4", Channel: "C1", MessageID: "M2"})
+	waitForDeadline(t, done)
+
+	messages := d.notification.takeMessages()
+	require.Len(t, messages, 2)
+	reminder := messages[0]
+	assert.Equal(t, "C1", reminder.receiver)
+	assert.Equal(t, "M1", reminder.messageID)
+	assert.True(t, strings.HasPrefix(reminder.text, ":alarm_clock: Orders close in"), reminder.text)
+	// Dana already joined the group, just Noa is missing
+	assert.True(t, strings.HasSuffix(reminder.text, "\n<@U2> you said you'd join, you're not in the group yet"), reminder.text)
+
+	// Whoever set the deadline is warned, even if it isn't the host
+	assert.Equal(t, sentMessage{receiver: "C1", text: ":rotating_light: <@U5> the deadline passed and the group wasn't purchased yet", messageID: "M1"}, messages[1])
+
+	d.h.orderDeadlinesLock.Lock()
+	assert.Empty(t, d.h.orderDeadlines, "the deadline should be removed once it passed")
+	d.h.orderDeadlinesLock.Unlock()
+}
+
+func TestDeadlinePurchasedGroup(t *testing.T) {
+	d := newDeadlineTest(t)
+	require.NoError(t, d.woltServer.UpdateOrderStatus(d.orderID, woltserver.StatusPurchased))
+
+	_, done := d.startDeadline(time.Now().Add(400 * time.Millisecond))
+	waitForDeadline(t, done)
+	assert.Empty(t, d.notification.takeMessages(), "purchased groups shouldn't be reminded about or warned")
+}
+
+func TestDeadlineWithoutTrackedGroup(t *testing.T) {
+	d := newDeadlineTest(t)
+	d.h.currentlyWorkingOrders = sync.Map{}
+
+	_, done := d.startDeadline(time.Now().Add(400 * time.Millisecond))
+	waitForDeadline(t, done)
+	assert.Empty(t, d.notification.takeMessages(), "there's nothing to remind about without a tracked group")
+}
+
+func TestDeadlineOff(t *testing.T) {
+	d := newDeadlineTest(t)
+
+	deadline, done := d.startDeadline(time.Now().Add(time.Hour))
+	response, err := d.h.HandleMention(MentionRequest{Text: "<@U0BOLT> deadline off", Channel: "C1", User: "U3", MessageID: "M3", ThreadID: "M1"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK, I removed the deadline.", response)
+	waitForDeadline(t, done)
+	assert.Empty(t, d.notification.takeMessages())
+
+	// Join intents of a removed deadline are ignored
+	d.h.addJoinIntent(ReactionAddRequest{Reaction: JoinIntentReaction, FromUserID: "U2", Channel: "C1", MessageID: "M1"})
+	_, ok := deadline.joiners.Load("U2")
+	assert.False(t, ok)
+}
+
+func TestHandleMention(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		text          string
+		expectedError error
+	}{
+		{name: "Not a command", text: "<@U0BOLT> hi", expectedError: ErrUnknownMention},
+		{name: "Just a mention", text: "<@U0BOLT>", expectedError: ErrUnknownMention},
+		{name: "Deadline not at the start", text: "<@U0BOLT> what's the deadline 12:00", expectedError: ErrUnknownMention},
+		{name: "Mentioned in conversation", text: "ask <@U0BOLT> about the deadline", expectedError: ErrUnknownMention},
+		{name: "Deadline without time", text: "<@U0BOLT> deadline", expectedError: ErrInvalidDeadline},
+		{name: "Deadline with more than the time", text: "<@U0BOLT> deadline 12:00 please", expectedError: ErrInvalidDeadline},
+		{name: "Invalid time", text: "<@U0BOLT> Deadline 25:00", expectedError: ErrInvalidDeadline},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Service{orderDeadlines: make(map[string]*orderDeadline)}
+			_, err := h.HandleMention(MentionRequest{Text: tc.text, Channel: "C1", User: "U1", MessageID: "M1"})
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.Empty(t, h.orderDeadlines)
+		})
+	}
+}
+
+func TestHandleMentionSetsDeadline(t *testing.T) {
+	now := time.Now()
+	at := now.Add(2 * time.Minute)
+	if at.Day() != now.Day() {
+		t.Skip("deadlines are for the rest of the day, and the day is about to end")
+	}
+
+	h := &Service{orderDeadlines: make(map[string]*orderDeadline)}
+	response, err := h.HandleMention(MentionRequest{Text: "<@U0BOLT> deadline " + at.Format("15:04"), Channel: "C1", User: "U5", MessageID: "M2", ThreadID: "M1"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(response, ":alarm_clock: Orders close at"), response)
+
+	h.orderDeadlinesLock.Lock()
+	deadline, ok := h.orderDeadlines["C1/M1"]
+	h.orderDeadlinesLock.Unlock()
+	require.True(t, ok, "the deadline should be set for the message with the group link")
+	assert.Equal(t, "U5", deadline.setBy)
+
+	response, err = h.HandleMention(MentionRequest{Text: "<@U0BOLT> deadline off", Channel: "C1", User: "U1", MessageID: "M3", ThreadID: "M1"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK, I removed the deadline.", response)
+}
@@ -14,10 +14,25 @@ import (
 
 var groupFromMessageRe = regroup.MustCompile(`Wolt order ID (?P<id>[A-Z0-9]+?)[\s\.$]`)
 
-const NoMessagesAfterHour = 21
-const NoMessagesBeforeHour = 9
+const NoMessagesAfterHour = userDomain.DefaultQuietHoursStart
+const NoMessagesBeforeHour = userDomain.DefaultQuietHoursEnd
+
+type debtsRemovalReason string
+
+const (
+	removalReasonHostRequest debtsRemovalReason = "the host requested to cancel debts tracking"
+	removalReasonTimeout     debtsRemovalReason = "timeout has been reached"
+)
 
 func (h *Service) HandleReactionAdded(req ReactionAddRequest) (string, error) {
+	switch req.Reaction {
+	case HostNudgeReaction:
+		h.requestNudge(req)
+		return "", nil
+	case JoinIntentReaction:
+		h.addJoinIntent(req)
+		return "", nil
+	}
 	if h.debtStore == nil {
 		return "", nil
 	}
@@ -61,7 +76,7 @@ func (h *Service) HandleReactionAdded(req ReactionAddRequest) (string, error) {
 			_, _ = h.informEvent(req.FromUserID, fmt.Sprintf("Nice try :stuck_out_tongue_winking_eye: Only the host (<@%s>) can cancel debts for this order", hostForOrder), "", "")
 			return "", nil
 		}
-		if err := h.removeAllDebtsForOrder(parsedID.ID, "the host requested to cancel debts tracking"); err != nil {
+		if err := h.removeAllDebtsForOrder(parsedID.ID, removalReasonHostRequest); err != nil {
 			log.Println(fmt.Sprintf("Error removing all debts for order ID %s: %v", parsedID.ID, err))
 		}
 	}
@@ -69,14 +84,16 @@ func (h *Service) HandleReactionAdded(req ReactionAddRequest) (string, error) {
 	return "", nil
 }
 
-func (h *Service) DebtWorker(ctx context.Context, orderID string) {
+func (h *Service) DebtWorker(ctx context.Context, orderID string, interval time.Duration) {
 	if h.debtStore == nil {
 		return
 	}
 
-	reminderInterval := time.NewTicker(h.cfg.DebtReminderInterval)
+	reminderInterval := time.NewTicker(interval)
 	defer reminderInterval.Stop()
 
+	lastReminders := make(map[string]time.Time) // Debt ID to the last time a reminder was sent for it
+
 	for {
 		select {
 		case <-reminderInterval.C:
@@ -90,12 +107,17 @@ func (h *Service) DebtWorker(ctx context.Context, orderID string) {
 				return
 			}
 			for _, debt := range debts {
-				if err := h.remindDebt(debt); err != nil {
+				reminded, err := h.remindDebt(debt, lastReminders[debt.ID], interval)
+				if err != nil {
 					log.Printf("Reminding about debt: %#v; error: %v\n", debt, err)
+					continue
+				}
+				if reminded {
+					lastReminders[debt.ID] = time.Now()
 				}
 			}
 		case <-ctx.Done():
-			if err := h.removeAllDebtsForOrder(orderID, "timeout has been reached"); err != nil {
+			if err := h.removeAllDebtsForOrder(orderID, removalReasonTimeout); err != nil {
 				log.Println("Error removing all debts on context cancellation:", err)
 			}
 			return
@@ -103,10 +125,23 @@ func (h *Service) DebtWorker(ctx context.Context, orderID string) {
 	}
 }
 
-func (h *Service) remindDebt(debt *debtDomain.Debt) error {
+// remindDebt sends a reminder about the debt to the borrower, according to the borrower's preferences.
+// It returns whether a reminder was actually sent.
+func (h *Service) remindDebt(debt *debtDomain.Debt, lastReminded time.Time, workerInterval time.Duration) (bool, error) {
 	borrower, err := h.userStore.GetUser(context.Background(), debt.BorrowerID)
 	if err != nil {
-		return fmt.Errorf("get borrower user: %w", err)
+		return false, fmt.Errorf("get borrower user: %w", err)
+	}
+
+	preferences := h.userPreferences(borrower.TransportID)
+	if h.digestMode(preferences) {
+		// The borrower will get the debt in the digest
+		return false, nil
+	}
+
+	// The worker wakes up every workerInterval, so allowing half of it as a tolerance to avoid skipping a reminder due to small delays
+	if preferences.ReminderInterval > 0 && time.Since(lastReminded)+workerInterval/2 < preferences.ReminderInterval {
+		return false, nil
 	}
 
 	timeAtBorrower := time.Now()
@@ -117,9 +152,9 @@ func (h *Service) remindDebt(debt *debtDomain.Debt) error {
 		}
 	}
 
-	if timeAtBorrower.Hour() >= NoMessagesAfterHour || timeAtBorrower.Hour() < NoMessagesBeforeHour {
-		log.Printf("Not reminding in aftertimes for user %q (%s). Timezone at borrower: %s\n", borrower.FullName, borrower.ID, borrower.Timezone)
-		return nil
+	if preferences.InQuietHours(timeAtBorrower) {
+		log.Printf("Not reminding in quiet hours for user %q (%s). Timezone at borrower: %s\n", borrower.FullName, borrower.ID, borrower.Timezone)
+		return false, nil
 	}
 
 	_, _ = h.informEvent(borrower.TransportID,
@@ -127,7 +162,7 @@ func (h *Service) remindDebt(debt *debtDomain.Debt) error {
 			"If you paid, you can mark yourself as paid by adding :%s: reaction to this message \\ the original rates message.",
 			debt.Amount, debt.LenderID, debt.OrderID, MarkAsPaidReaction),
 		MarkAsPaidReaction, "")
-	return nil
+	return true, nil
 }
 
 func (h *Service) createDebt(amount float64, initiatedTransport, orderID, messageID string, borrowerUser *userDomain.User, lenderUser *userDomain.User) error {
@@ -143,8 +178,8 @@ func (h *Service) createDebt(amount float64, initiatedTransport, orderID, messag
 	return nil
 }
 
-func (h *Service) addDebts(initiatedTransport, orderID string, rates GroupRate, messageID string) error {
-	if h.debtStore == nil {
+func (h *Service) addDebts(initiatedTransport, orderID string, rates GroupRate, messageID string, settings *channelSettings) error {
+	if h.debtStore == nil || !settings.debtTracking {
 		return nil
 	}
 
@@ -159,12 +194,21 @@ func (h *Service) addDebts(initiatedTransport, orderID string, rates GroupRate,
 			MarkAsPaidReaction, rates.HostUser.TransportID, HostRemoveDebts, orderID),
 		"", messageID)
 
+	debtUserIDs := []string{rates.HostUser.ID}
+	defer func() {
+		go h.refreshHomes(debtUserIDs...)
+	}()
+
 	for _, rate := range rates.Rates {
-		if rate.WoltName == rates.HostWoltUser {
+		if rate.WoltName == rates.HostWoltUser || (rate.User != nil && rate.User.ID == rates.HostUser.ID) {
 			// Don't create debt for the lender
 			continue
 		}
 
+		if rate.User == nil && len(rate.Candidates) > 0 {
+			_, _ = h.informEvent(initiatedTransport, fmt.Sprintf("I'm not sure who %q is, I'll track the payment once I know.", rate.WoltName), "", messageID)
+			continue
+		}
 		if rate.User == nil {
 			_, _ = h.informEvent(initiatedTransport, fmt.Sprintf("I won't track %q payment because I can't find his user.", rate.WoltName), "", messageID)
 			continue
@@ -173,15 +217,27 @@ func (h *Service) addDebts(initiatedTransport, orderID string, rates GroupRate,
 			log.Println(fmt.Sprintf("Error creating debt for user %q in order ID %q: %v", rate.WoltName, orderID, err))
 			continue
 		}
+		debtUserIDs = append(debtUserIDs, rate.User.ID)
 	}
 
-	//goland:noinspection ALL
-	ctx, _ := context.WithTimeout(context.Background(), h.cfg.DebtMaximumDuration) // nolint
-	go h.DebtWorker(ctx, orderID)
-
+	h.startDebtWorker(orderID, time.Now().Add(settings.debtMaximumDuration), settings.debtReminderInterval)
 	return nil
 }
 
+// startDebtWorker starts a debt worker for the order until the deadline, unless one is already running
+func (h *Service) startDebtWorker(orderID string, deadline time.Time, interval time.Duration) {
+	if _, running := h.debtWorkers.LoadOrStore(orderID, nil); running {
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	go func() {
+		defer h.debtWorkers.Delete(orderID)
+		defer cancel()
+		h.DebtWorker(ctx, orderID, interval)
+	}()
+}
+
 func (h *Service) hostForOrderID(orderID string) (string, error) {
 	debts, err := h.debtStore.ListDebtsForOrderID(orderID)
 	if err != nil {
@@ -193,7 +249,7 @@ func (h *Service) hostForOrderID(orderID string) (string, error) {
 	return debts[0].LenderID, nil
 }
 
-func (h *Service) removeAllDebtsForOrder(orderID, reason string) error {
+func (h *Service) removeAllDebtsForOrder(orderID string, reason debtsRemovalReason) error {
 	if h.debtStore == nil {
 		return nil
 	}
@@ -207,13 +263,31 @@ func (h *Service) removeAllDebtsForOrder(orderID, reason string) error {
 	}
 
 	lender := debts[0].LenderID
+	debtUserIDs := []string{lender}
+	defer func() {
+		go h.refreshHomes(debtUserIDs...)
+	}()
 	for _, debt := range debts {
 		if err := h.debtStore.RemoveDebtInOrderID(orderID, debt.ID); err != nil {
 			return fmt.Errorf("remove debt: %w", err)
 		}
+		debtUserIDs = append(debtUserIDs, debt.BorrowerID)
+	}
+
+	lenderTransportID := lender
+	lenderUser, err := h.userStore.GetUser(context.Background(), lender)
+	if err != nil {
+		log.Printf("Error getting lender user with id %s: %v\n", lender, err)
+	} else {
+		lenderTransportID = lenderUser.TransportID
 	}
 
-	_, _ = h.informEvent(lender, fmt.Sprintf("I removed all debts for order ID %s because %s", orderID, reason), "", "")
+	if reason != removalReasonHostRequest && h.digestMode(h.userPreferences(lenderTransportID)) {
+		// Lenders in digest mode will just stop seeing these debts in the digest
+		return nil
+	}
+
+	_, _ = h.informEvent(lenderTransportID, fmt.Sprintf("I removed all debts for order ID %s because %s", orderID, reason), "", "")
 	return nil
 }
 
@@ -244,6 +318,7 @@ func (h *Service) markDebtAsPaid(orderID, reactedTransportID, initialChannel str
 		if err := h.debtStore.RemoveDebtInOrderID(orderID, debt.ID); err != nil {
 			return fmt.Errorf("remove debt: %w", err)
 		}
+		go h.refreshHomes(debt.BorrowerID, debt.LenderID)
 
 		_, _ = h.informEvent(borrower.TransportID, fmt.Sprintf("OK! I removed your debt for order %s", debt.OrderID), "", "")
 
@@ -254,6 +329,11 @@ func (h *Service) markDebtAsPaid(orderID, reactedTransportID, initialChannel str
 		if err != nil {
 			log.Println(fmt.Sprintf("Error getting lender user with id %s: %s", debt.LenderID, err.Error()))
 		} else {
+			preferences := h.userPreferences(lender.TransportID)
+			if !preferences.HostPaidNotifications || h.digestMode(preferences) {
+				// The host doesn't want to be notified about paid debts
+				return nil
+			}
 			recipient = lender.TransportID
 			messageID = ""
 		}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"math"
 	"strings"
 	"time"
@@ -10,7 +11,7 @@ import (
 	"github.com/oriser/bolt/wolt"
 )
 
-func (h *Service) buildProgressEmojiArt(startedAt time.Time, deliveryEta time.Time, timezone *time.Location) string {
+func (h *Service) buildProgressEmojiArt(destinationEmoji string, startedAt time.Time, deliveryEta time.Time, timezone *time.Location) string {
 	const (
 		numberOfSpacesBetweenTimes           = 23
 		numberOfSpacesBeforeDestinationEmoji = 3
@@ -38,7 +39,7 @@ func (h *Service) buildProgressEmojiArt(startedAt time.Time, deliveryEta time.Ti
 	deliveryPercentage := math.Min(time.Since(startedAt).Seconds()/deliveryEta.Sub(startedAt).Seconds(), 1)
 	numberOfRoadTilesBehindCourier := int(math.Round(deliveryPercentage * numberOfRoadTiles))
 	secondLine := strings.Repeat(" ", numberOfSpacesBeforeDestinationEmoji) +
-		fmt.Sprintf(":%s:", h.cfg.OrderDestinationEmoji) +
+		fmt.Sprintf(":%s:", destinationEmoji) +
 		strings.Repeat(roadTileAsciiArt, numberOfRoadTiles-numberOfRoadTilesBehindCourier) +
 		CourierEmoji +
 		strings.Repeat(roadTileAsciiArt, numberOfRoadTilesBehindCourier) +
@@ -51,7 +52,7 @@ func (h *Service) buildProgressEmojiArt(startedAt time.Time, deliveryEta time.Ti
 func (h *Service) updateDeliveryProgressMessage(initiatedTransport string, order *groupOrder, details *wolt.OrderDetails, ratesMessage string) error {
 	var err error
 
-	if IsUnixZero(details.PurchaseDatetime) {
+	if !order.settings.progressUpdates || IsUnixZero(details.PurchaseDatetime) {
 		return nil
 	}
 
@@ -71,7 +72,7 @@ func (h *Service) updateDeliveryProgressMessage(initiatedTransport string, order
 
 	err = h.eventNotification.EditMessage(
 		initiatedTransport,
-		strings.TrimSuffix(ratesMessage, "\n")+"\n\n"+h.buildProgressEmojiArt(details.PurchaseDatetime, deliveryTime, order.venue.TimezoneLocation),
+		strings.TrimSuffix(ratesMessage, "\n")+"\n\n"+h.buildProgressEmojiArt(order.settings.destinationEmoji, details.PurchaseDatetime, deliveryTime, order.venue.TimezoneLocation),
 		order.detailsMessageId)
 	if err != nil {
 		return fmt.Errorf("updating details message %s: %w", order.detailsMessageId, err)
@@ -80,13 +81,14 @@ func (h *Service) updateDeliveryProgressMessage(initiatedTransport string, order
 	return err
 }
 
-func (h *Service) monitorDelivery(initiatedTransport string, order *groupOrder, ctx context.Context, waitBetweenStatusCheck time.Duration, messageID string, ratesMessage string) error {
-	details, err := order.fetchDetails()
+func (h *Service) monitorDelivery(initiatedTransport string, order *groupOrder, ctx context.Context, messageID string, ratesMessage string) error {
+	details, err := order.fetchDetails(ctx)
 	if err != nil {
 		return fmt.Errorf("get group details: %w", err)
 	}
 
 	getReadyMessageSent := false
+	failures := 0
 	for details.Status != wolt.StatusCanceled {
 		err = h.updateDeliveryProgressMessage(initiatedTransport, order, details, ratesMessage)
 		if err != nil {
@@ -94,25 +96,33 @@ func (h *Service) monitorDelivery(initiatedTransport string, order *groupOrder,
 		}
 
 		if details.IsDelivered() {
-			if !getReadyMessageSent {
+			if !getReadyMessageSent && order.settings.progressUpdates {
 				_, _ = h.informEvent(initiatedTransport, "Delivery arrived", "", messageID)
 				getReadyMessageSent = true //nolint:ineffassign
 			}
 			return nil
 		} else if !IsUnixZero(details.DeliveryEta) {
 			timeToDelivery := time.Until(details.DeliveryEta)
-			if !getReadyMessageSent && timeToDelivery < h.cfg.TimeTillGetReadyMessage {
+			if !getReadyMessageSent && order.settings.progressUpdates && timeToDelivery < h.cfg.TimeTillGetReadyMessage {
 				_, _ = h.informEvent(initiatedTransport, "Get ready, delivery coming soon", "", messageID)
 				getReadyMessageSent = true
 			}
 		}
 
 		select {
-		case <-time.After(waitBetweenStatusCheck):
-			details, err = order.fetchDetails()
+		case <-h.poller.after(ctx, h.poller.backoff(h.poller.deliveryInterval(details, h.cfg.TimeTillGetReadyMessage), failures)):
+			latest, err := order.fetchDetails(ctx)
 			if err != nil {
-				return fmt.Errorf("get group details: %w", err)
+				if ctx.Err() != nil {
+					return fmt.Errorf("context canceled while waiting for group to progress: %w", err)
+				}
+				if failures++; failures >= maxPollFailures {
+					return fmt.Errorf("get group details: %w", err)
+				}
+				log.Printf("Error getting details of order %q, checking again later: %v\n", order.id, err)
+				continue
 			}
+			details, failures = latest, 0
 		case <-ctx.Done():
 			return fmt.Errorf("context canceled while waiting for group to progress")
 		}
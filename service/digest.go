@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	debtDomain "github.com/oriser/bolt/debt"
+	orderDomain "github.com/oriser/bolt/order"
+	userDomain "github.com/oriser/bolt/user"
+)
+
+// RunScheduledJobs runs the scheduled digests until the context is done
+func (h *Service) RunScheduledJobs(ctx context.Context) {
+	scheduler := &Scheduler{}
+	if h.debtsDigestSchedule != nil {
+		scheduler.Add("debts digest", h.debtsDigestSchedule, h.sendDebtsDigests)
+	}
+	if h.channelDigestSchedule != nil {
+		scheduler.Add("channels digest", h.channelDigestSchedule, h.sendChannelsDigests)
+	}
+	scheduler.Run(ctx)
+}
+
+// usersByID gets users from the user store, caching the results for a single digest run
+type usersByID struct {
+	ctx   context.Context
+	store userDomain.Store
+	users map[string]*userDomain.User
+}
+
+func (u *usersByID) get(id string) (*userDomain.User, error) {
+	if user, ok := u.users[id]; ok {
+		return user, nil
+	}
+	user, err := u.store.GetUser(u.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.users[id] = user
+	return user, nil
+}
+
+// mention returns a mention of the user with the given ID, or the ID itself if the user can't be found
+func (u *usersByID) mention(id string) string {
+	user, err := u.get(id)
+	if err != nil {
+		return id
+	}
+	return fmt.Sprintf("<@%s>", user.TransportID)
+}
+
+func (h *Service) buildLenderDigestMessage(debts []*debtDomain.Debt, users *usersByID) string {
+	var sb strings.Builder
+	sb.WriteString("Your debts digest, these are still owed to you:\n")
+
+	total := 0.0
+	for _, debt := range debts {
+		sb.WriteString(fmt.Sprintf("• %s owes you %.2f nis for Wolt order ID %s\n", users.mention(debt.BorrowerID), debt.Amount, debt.OrderID))
+		total += debt.Amount
+	}
+	sb.WriteString(fmt.Sprintf("Total: %.2f nis\n", total))
+
+	return sb.String()
+}
+
+func (h *Service) buildBorrowerDigestMessage(debts []*debtDomain.Debt, users *usersByID) string {
+	var sb strings.Builder
+	sb.WriteString("Your debts digest, you still owe:\n")
+
+	total := 0.0
+	for _, debt := range debts {
+		sb.WriteString(fmt.Sprintf("• %.2f nis to %s for Wolt order ID %s\n", debt.Amount, users.mention(debt.LenderID), debt.OrderID))
+		total += debt.Amount
+	}
+	sb.WriteString(fmt.Sprintf("Total: %.2f nis\n", total))
+	sb.WriteString(fmt.Sprintf("If you paid, you can mark yourself as paid by adding :%s: reaction to the rates message of the order.", MarkAsPaidReaction))
+
+	return sb.String()
+}
+
+// sendDebtsDigests sends a digest of the open debts to every lender and borrower who chose the digest notifications mode
+func (h *Service) sendDebtsDigests(ctx context.Context) {
+	if h.debtStore == nil {
+		return
+	}
+
+	debts, err := h.debtStore.ListDebts(debtDomain.ListFilter{})
+	if err != nil {
+		log.Println("Error listing debts for digest:", err)
+		return
+	}
+
+	byLender := make(map[string][]*debtDomain.Debt)
+	byBorrower := make(map[string][]*debtDomain.Debt)
+	for _, debt := range debts {
+		byLender[debt.LenderID] = append(byLender[debt.LenderID], debt)
+		byBorrower[debt.BorrowerID] = append(byBorrower[debt.BorrowerID], debt)
+	}
+
+	users := &usersByID{ctx: ctx, store: h.userStore, users: make(map[string]*userDomain.User)}
+	sendDigest := func(userID string, buildMessage func() string) {
+		user, err := users.get(userID)
+		if err != nil {
+			log.Printf("Error getting user %s for digest: %v\n", userID, err)
+			return
+		}
+		if !h.digestMode(h.userPreferences(user.TransportID)) {
+			return
+		}
+		if _, err = h.informEvent(user.TransportID, buildMessage(), "", ""); err != nil {
+			log.Printf("Error sending debts digest to %s: %v\n", user.TransportID, err)
+		}
+	}
+
+	for _, lenderID := range getSortedKeys(byLender) {
+		sendDigest(lenderID, func() string { return h.buildLenderDigestMessage(byLender[lenderID], users) })
+	}
+	for _, borrowerID := range getSortedKeys(byBorrower) {
+		sendDigest(borrowerID, func() string { return h.buildBorrowerDigestMessage(byBorrower[borrowerID], users) })
+	}
+}
+
+func orderTotal(order *orderDomain.Order) float64 {
+	total := 0.0
+	for _, participant := range order.Participants {
+		total += participant.Amount
+	}
+	return total
+}
+
+func formatPeriod(period time.Duration) string {
+	if period%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%d days", period/(24*time.Hour))
+	}
+	return period.String()
+}
+
+func (h *Service) buildChannelDigestMessage(orders []*orderDomain.Order, unpaid []*debtDomain.Debt) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Orders summary for the last %s:\n", formatPeriod(h.cfg.ChannelDigestPeriod)))
+
+	totalSpend := 0.0
+	purchased := make([]*orderDomain.Order, 0, len(orders))
+	for _, order := range orders {
+		if order.Status != orderDomain.StatusDone {
+			continue
+		}
+		purchased = append(purchased, order)
+		totalSpend += orderTotal(order)
+	}
+
+	totalUnpaid := 0.0
+	for _, debt := range unpaid {
+		totalUnpaid += debt.Amount
+	}
+
+	sb.WriteString(fmt.Sprintf("• %d orders, total spend %.2f nis\n", len(purchased), totalSpend))
+	sb.WriteString(fmt.Sprintf("• Still unpaid: %.2f nis\n", totalUnpaid))
+
+	if len(purchased) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, order := range purchased {
+		sb.WriteString(fmt.Sprintf("%s (hosted by %s): %.2f nis – Wolt order ID %s\n", order.VenueName, order.Host, orderTotal(order), order.OriginalID))
+	}
+
+	return sb.String()
+}
+
+// sendChannelsDigests sends to every channel a summary of the orders placed in it during the digest period, and of their unpaid debts
+func (h *Service) sendChannelsDigests(ctx context.Context) {
+	periodStart := time.Now().Add(-h.cfg.ChannelDigestPeriod)
+	orders, err := h.orderStore.ListOrders(ctx, orderDomain.ListFilter{Since: periodStart})
+	if err != nil {
+		log.Println("Error listing orders for digest:", err)
+		return
+	}
+
+	byChannel := make(map[string][]*orderDomain.Order)
+	for _, order := range orders {
+		byChannel[order.Receiver] = append(byChannel[order.Receiver], order)
+	}
+
+	unpaidByChannel := make(map[string][]*debtDomain.Debt)
+	if h.debtStore != nil {
+		debts, err := h.debtStore.ListDebts(debtDomain.ListFilter{Since: periodStart})
+		if err != nil {
+			log.Println("Error listing debts for digest:", err)
+			return
+		}
+		for _, debt := range debts {
+			unpaidByChannel[debt.InitiatedTransportID] = append(unpaidByChannel[debt.InitiatedTransportID], debt)
+			if _, ok := byChannel[debt.InitiatedTransportID]; !ok {
+				byChannel[debt.InitiatedTransportID] = nil
+			}
+		}
+	}
+
+	for _, channel := range getSortedKeys(byChannel) {
+		message := h.buildChannelDigestMessage(byChannel[channel], unpaidByChannel[channel])
+		if _, err = h.informEvent(channel, message, "", ""); err != nil {
+			log.Printf("Error sending channel digest to %s: %v\n", channel, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	debtDomain "github.com/oriser/bolt/debt"
+	orderDomain "github.com/oriser/bolt/order"
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDigestTestUsers() *usersByID {
+	store := &fakeUserStore{users: []*userDomain.User{
+		{ID: "1", FullName: "Dana Levi", TransportID: "U1"},
+		{ID: "2", FullName: "Noa Cohen", TransportID: "U2"},
+	}}
+	return &usersByID{ctx: context.Background(), store: store, users: make(map[string]*userDomain.User)}
+}
+
+func TestBuildLenderDigestMessage(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		debts    []*debtDomain.Debt
+		expected string
+	}{
+		{
+			name:  "Single debt",
+			debts: []*debtDomain.Debt{{BorrowerID: "2", LenderID: "1", OrderID: "order-1", Amount: 42.5}},
+			expected: "Your debts digest, these are still owed to you:\n" +
+				"• <@U2> owes you 42.50 nis for Wolt order ID order-1\n" +
+				"Total: 42.50 nis\n",
+		},
+		{
+			name: "Unknown borrower is shown by ID",
+			debts: []*debtDomain.Debt{
+				{BorrowerID: "2", LenderID: "1", OrderID: "order-1", Amount: 10},
+				{BorrowerID: "3", LenderID: "1", OrderID: "order-2", Amount: 20.25},
+			},
+			expected: "Your debts digest, these are still owed to you:\n" +
+				"• <@U2> owes you 10.00 nis for Wolt order ID order-1\n" +
+				"• 3 owes you 20.25 nis for Wolt order ID order-2\n" +
+				"Total: 30.25 nis\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, (&Service{}).buildLenderDigestMessage(tc.debts, newDigestTestUsers()))
+		})
+	}
+}
+
+func TestBuildBorrowerDigestMessage(t *testing.T) {
+	debts := []*debtDomain.Debt{
+		{BorrowerID: "2", LenderID: "1", OrderID: "order-1", Amount: 10},
+		{BorrowerID: "2", LenderID: "1", OrderID: "order-2", Amount: 5.5},
+	}
+	assert.Equal(t, "Your debts digest, you still owe:\n"+
+		"• 10.00 nis to <@U1> for Wolt order ID order-1\n"+
+		"• 5.50 nis to <@U1> for Wolt order ID order-2\n"+
+		"Total: 15.50 nis\n"+
+		"If you paid, you can mark yourself as paid by adding :"+MarkAsPaidReaction+": reaction to the rates message of the order.",
+		(&Service{}).buildBorrowerDigestMessage(debts, newDigestTestUsers()))
+}
+
+func TestBuildChannelDigestMessage(t *testing.T) {
+	doneOrder := &orderDomain.Order{
+		OriginalID:   "order-1",
+		VenueName:    "Pizza Place",
+		Host:         "Dana Levi",
+		Status:       orderDomain.StatusDone,
+		Participants: []orderDomain.Participant{{Name: "Dana Levi", Amount: 50}, {Name: "Noa Cohen", Amount: 30.5}},
+	}
+	canceledOrder := &orderDomain.Order{
+		OriginalID:   "order-2",
+		VenueName:    "Burger Place",
+		Host:         "Noa Cohen",
+		Status:       orderDomain.StatusCanceled,
+		Participants: []orderDomain.Participant{{Name: "Noa Cohen", Amount: 60}},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		period   time.Duration
+		orders   []*orderDomain.Order
+		unpaid   []*debtDomain.Debt
+		expected string
+	}{
+		{
+			name:   "No orders",
+			period: 7 * 24 * time.Hour,
+			expected: "Orders summary for the last 7 days:\n" +
+				"• 0 orders, total spend 0.00 nis\n" +
+				"• Still unpaid: 0.00 nis\n",
+		},
+		{
+			name:   "Only done orders are counted",
+			period: 7 * 24 * time.Hour,
+			orders: []*orderDomain.Order{doneOrder, canceledOrder},
+			unpaid: []*debtDomain.Debt{{Amount: 30.5}},
+			expected: "Orders summary for the last 7 days:\n" +
+				"• 1 orders, total spend 80.50 nis\n" +
+				"• Still unpaid: 30.50 nis\n" +
+				"\n" +
+				"Pizza Place (hosted by Dana Levi): 80.50 nis – Wolt order ID order-1\n",
+		},
+		{
+			name:   "Unpaid debts without orders in the period",
+			period: 36 * time.Hour,
+			unpaid: []*debtDomain.Debt{{Amount: 10}, {Amount: 2.5}},
+			expected: "Orders summary for the last 36h0m0s:\n" +
+				"• 0 orders, total spend 0.00 nis\n" +
+				"• Still unpaid: 12.50 nis\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Service{cfg: Config{ChannelDigestPeriod: tc.period}}
+			assert.Equal(t, tc.expected, h.buildChannelDigestMessage(tc.orders, tc.unpaid))
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oriser/bolt/channel"
+	debtDomain "github.com/oriser/bolt/debt"
+	orderDomain "github.com/oriser/bolt/order"
+	userDomain "github.com/oriser/bolt/user"
+)
+
+// fakeUserStore keeps users in memory, finding them by their exact names (or aliases), emails, transport IDs or saved Wolt matches,
+// empty filter finds all of them. Methods it doesn't implement panic.
+// It's the Wolt match store as well.
+type fakeUserStore struct {
+	userDomain.Store
+	users       []*userDomain.User
+	directory   map[string]bool   // IDs of the users from an external directory, the rest are custom users
+	aliases     map[string]string // User IDs by their aliases
+	woltMatches map[string]string // Transport IDs by the Wolt user IDs matched to them
+	preferences map[string]*userDomain.Preferences
+}
+
+func (f *fakeUserStore) ListUsers(_ context.Context, filter userDomain.ListFilter) ([]*userDomain.User, error) {
+	all := filter.TransportID == "" && len(filter.Names) == 0 && len(filter.Emails) == 0 && len(filter.WoltUserIDs) == 0
+	users := make([]*userDomain.User, 0)
+	for _, user := range f.users {
+		if filter.CustomOnly && f.directory[user.ID] {
+			continue
+		}
+		matches := all || (filter.TransportID != "" && user.TransportID == filter.TransportID)
+		for _, name := range filter.Names {
+			matches = matches || user.FullName == name || f.aliases[name] == user.ID
+		}
+		for _, email := range filter.Emails {
+			matches = matches || (email != "" && strings.EqualFold(user.Email, email))
+		}
+		for _, woltUserID := range filter.WoltUserIDs {
+			matches = matches || (woltUserID != "" && f.woltMatches[woltUserID] == user.TransportID)
+		}
+		if matches {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUserStore) GetUser(_ context.Context, id string) (*userDomain.User, error) {
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, &userDomain.ErrNotFound{Name: id}
+}
+
+func (f *fakeUserStore) UpdateUser(_ context.Context, user *userDomain.User) error {
+	for i, current := range f.users {
+		if current.ID == user.ID {
+			f.users[i] = user
+			return nil
+		}
+	}
+	return &userDomain.ErrNotFound{Name: user.FullName}
+}
+
+func (f *fakeUserStore) DeleteUser(_ context.Context, id string) error {
+	for i, user := range f.users {
+		if user.ID == id {
+			f.users = append(f.users[:i], f.users[i+1:]...)
+			return nil
+		}
+	}
+	return &userDomain.ErrNotFound{Name: id}
+}
+
+func (f *fakeUserStore) AddAlias(_ context.Context, userID, alias string) error {
+	if _, ok := f.aliases[alias]; ok {
+		return &userDomain.ErrAlreadyExists{Name: alias}
+	}
+	if f.aliases == nil {
+		f.aliases = make(map[string]string)
+	}
+	f.aliases[alias] = userID
+	return nil
+}
+
+func (f *fakeUserStore) RemoveAlias(_ context.Context, alias string) error {
+	if _, ok := f.aliases[alias]; !ok {
+		return fmt.Errorf("alias %q not found", alias)
+	}
+	delete(f.aliases, alias)
+	return nil
+}
+
+func (f *fakeUserStore) ListAliases(_ context.Context, userID string) ([]string, error) {
+	aliases := make([]string, 0)
+	for alias, aliasUserID := range f.aliases {
+		if aliasUserID == userID {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases, nil
+}
+
+// GetPreferences returns the saved preferences of the user, or the default preferences if they were never saved
+func (f *fakeUserStore) GetPreferences(_ context.Context, transportID string) (*userDomain.Preferences, error) {
+	preferences, ok := f.preferences[transportID]
+	if !ok {
+		return userDomain.DefaultPreferences(transportID), nil
+	}
+	preferencesCopy := *preferences
+	return &preferencesCopy, nil
+}
+
+func (f *fakeUserStore) SavePreferences(_ context.Context, preferences *userDomain.Preferences) error {
+	if f.preferences == nil {
+		f.preferences = make(map[string]*userDomain.Preferences)
+	}
+	preferencesCopy := *preferences
+	f.preferences[preferences.TransportID] = &preferencesCopy
+	return nil
+}
+
+func (f *fakeUserStore) GetWoltMatch(_ context.Context, woltUserID string) (string, error) {
+	return f.woltMatches[woltUserID], nil
+}
+
+func (f *fakeUserStore) SaveWoltMatch(_ context.Context, woltUserID, transportID string) error {
+	if f.woltMatches == nil {
+		f.woltMatches = make(map[string]string)
+	}
+	f.woltMatches[woltUserID] = transportID
+	return nil
+}
+
+// fakeDebtStore keeps debts in memory, listing the ones of any of the filter's borrowers or lenders. Methods it doesn't implement panic.
+type fakeDebtStore struct {
+	debtDomain.Store
+	debts []*debtDomain.Debt
+}
+
+func (f *fakeDebtStore) AddDebt(debt *debtDomain.Debt) error {
+	f.debts = append(f.debts, debt)
+	return nil
+}
+
+func (f *fakeDebtStore) ListDebts(filter debtDomain.ListFilter) ([]*debtDomain.Debt, error) {
+	isIn := func(ids []string, id string) bool {
+		for _, current := range ids {
+			if current == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	debts := make([]*debtDomain.Debt, 0)
+	for _, debt := range f.debts {
+		matches := len(filter.BorrowerIDs) == 0 && len(filter.LenderIDs) == 0
+		matches = matches || isIn(filter.BorrowerIDs, debt.BorrowerID) || isIn(filter.LenderIDs, debt.LenderID)
+		if matches && !debt.CreatedAt.Before(filter.Since) {
+			debts = append(debts, debt)
+		}
+	}
+	return debts, nil
+}
+
+// fakeChannelStore keeps channels configuration in memory, returning copies of it like a real store would
+type fakeChannelStore struct {
+	configs map[string]*channel.Config
+}
+
+func (f *fakeChannelStore) GetChannelConfig(_ context.Context, channelID string) (*channel.Config, error) {
+	config, ok := f.configs[channelID]
+	if !ok {
+		return &channel.Config{ChannelID: channelID}, nil
+	}
+	configCopy := *config
+	return &configCopy, nil
+}
+
+func (f *fakeChannelStore) SaveChannelConfig(_ context.Context, config *channel.Config) error {
+	if f.configs == nil {
+		f.configs = make(map[string]*channel.Config)
+	}
+	configCopy := *config
+	f.configs[config.ChannelID] = &configCopy
+	return nil
+}
+
+// fakeOrderStore keeps orders in memory, listing the ones since the filter's time. Methods it doesn't implement panic.
+type fakeOrderStore struct {
+	orderDomain.Store
+	orders []*orderDomain.Order
+}
+
+func (f *fakeOrderStore) ListOrders(_ context.Context, filter orderDomain.ListFilter) ([]*orderDomain.Order, error) {
+	orders := make([]*orderDomain.Order, 0)
+	for _, order := range f.orders {
+		if (filter.Receiver == "" || order.Receiver == filter.Receiver) && !order.CreatedAt.Before(filter.Since) {
+			orders = append(orders, order)
+		}
+	}
+	return orders, nil
+}
+
+type sentMessage struct {
+	receiver  string
+	text      string
+	messageID string // The message it replied to
+}
+
+type sentPrompt struct {
+	receiver  string
+	recipient string
+	messageID string // The message it's in the thread of
+	prompt    *ChoicePrompt
+}
+
+// fakeNotification records the messages and prompts sent. Methods it doesn't implement panic.
+type fakeNotification struct {
+	EventNotification
+
+	lock        sync.Mutex
+	messages    []sentMessage
+	prompts     []sentPrompt
+	reactionErr error // Returned when adding reactions
+	homes       map[string]*HomeView
+	channels    map[string][]string // Channels by their members
+}
+
+func (f *fakeNotification) AddReaction(_, _, _ string) error {
+	return f.reactionErr
+}
+
+func (f *fakeNotification) SendMessage(receiver, event, messageID string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.messages = append(f.messages, sentMessage{receiver: receiver, text: event, messageID: messageID})
+	return fmt.Sprintf("sent-%d", len(f.messages)), nil
+}
+
+func (f *fakeNotification) SendPrompt(receiver, recipient, messageID string, prompt *ChoicePrompt) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.prompts = append(f.prompts, sentPrompt{receiver: receiver, recipient: recipient, messageID: messageID, prompt: prompt})
+	return nil
+}
+
+func (f *fakeNotification) PublishHome(receiver string, view *HomeView) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.homes == nil {
+		f.homes = make(map[string]*HomeView)
+	}
+	f.homes[receiver] = view
+	return nil
+}
+
+func (f *fakeNotification) UserChannels(user string) ([]string, error) {
+	return f.channels[user], nil
+}
+
+// takeHome returns the home view published to the receiver since the last call, nil if none
+func (f *fakeNotification) takeHome(receiver string) *HomeView {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	view := f.homes[receiver]
+	delete(f.homes, receiver)
+	return view
+}
+
+// takePrompts returns the prompts sent since the last call
+func (f *fakeNotification) takePrompts() []sentPrompt {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	prompts := f.prompts
+	f.prompts = nil
+	return prompts
+}
+
+// takeMessages returns the messages sent since the last call
+func (f *fakeNotification) takeMessages() []sentMessage {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	messages := f.messages
+	f.messages = nil
+	return messages
+}
@@ -3,48 +3,64 @@ package service
 import (
 	"context"
 	"fmt"
-	"time"
+	"log"
 
 	"github.com/oriser/bolt/order"
 	"github.com/oriser/bolt/wolt"
 )
 
-func (h *Service) joinGroupOrder(groupID string) (*groupOrder, error) {
-	g, err := wolt.NewGroupWithExistingID(wolt.WoltAddr{
-		BaseAddr:    h.cfg.WoltBaseAddr,
-		APIBaseAddr: h.cfg.WoltApiBaseAddr,
-	}, wolt.RetryConfig{
-		HTTPMaxRetries:       h.cfg.WoltHTTPMaxRetryCount,
-		HTTPMinRetryDuration: h.cfg.WoltHTTPMinRetryDuration,
-		HTTPMaxRetryDuration: h.cfg.WoltHTTPMaxRetryDuration,
-	}, groupID)
+func (h *Service) joinGroupOrder(ctx context.Context, receiver, groupID, messageID string, settings *channelSettings) (*groupOrder, error) {
+	if created, ok := h.createdGroups.LoadAndDelete(groupID); ok {
+		// Bolt is the host of the groups it created, there's no need to join them
+		return &groupOrder{
+			deliveryPrice:   -1,
+			id:              groupID,
+			receiver:        receiver,
+			messageID:       messageID,
+			settings:        settings,
+			woltGroup:       created.(*createdGroup).group,
+			nudgeRequests:   make(chan string, 1),
+			hostTransportID: created.(*createdGroup).hostTransportID,
+		}, nil
+	}
+
+	g, err := h.woltClient.GroupWithExistingID(groupID)
 	if err != nil {
 		return nil, fmt.Errorf("new existing group: %w", err)
 	}
 
-	if err := g.Join(); err != nil {
+	if err := g.Join(ctx); err != nil {
 		return nil, fmt.Errorf("join group: %w", err)
 	}
 
 	return &groupOrder{
 		deliveryPrice: -1,
 		id:            groupID,
+		receiver:      receiver,
+		messageID:     messageID,
+		settings:      settings,
 		woltGroup:     g,
+		nudgeRequests: make(chan string, 1),
 	}, nil
 }
 
 type groupOrder struct {
 	id               string
+	receiver         string
+	settings         *channelSettings
 	deliveryPrice    int
 	woltGroup        *wolt.Group
 	markedAsReady    bool
 	details          *wolt.OrderDetails
 	venue            *wolt.Venue
 	detailsMessageId string
+	messageID        string      // The message with the group link
+	nudgeRequests    chan string // Transport IDs of users who asked to nudge the participants who aren't ready
+	hostTransportID  string      // The user who checks out a group Bolt created, empty for groups Bolt joined
 }
 
-func (g *groupOrder) fetchDetails() (*wolt.OrderDetails, error) {
-	details, err := g.woltGroup.Details()
+func (g *groupOrder) fetchDetails(ctx context.Context) (*wolt.OrderDetails, error) {
+	details, err := g.woltGroup.Details(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get order details: %w", err)
 	}
@@ -52,13 +68,13 @@ func (g *groupOrder) fetchDetails() (*wolt.OrderDetails, error) {
 	return details, nil
 }
 
-func (g *groupOrder) fetchVenue() (*wolt.Venue, error) {
-	details, err := g.fetchDetails()
+func (g *groupOrder) fetchVenue(ctx context.Context) (*wolt.Venue, error) {
+	details, err := g.fetchDetails(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get group details: %w", err)
 	}
 
-	venue, err := g.woltGroup.VenueDetails(details)
+	venue, err := g.woltGroup.VenueDetails(ctx, details)
 	if err != nil {
 		return nil, fmt.Errorf("get venue details: %w", err)
 	}
@@ -66,32 +82,68 @@ func (g *groupOrder) fetchVenue() (*wolt.Venue, error) {
 	return venue, nil
 }
 
-func (g *groupOrder) MarkAsReady() error {
-	if err := g.woltGroup.MarkAsReady(); err != nil {
+func (g *groupOrder) MarkAsReady(ctx context.Context) error {
+	if g.hostTransportID != "" {
+		// Bolt is the host, the designated host checks out for it
+		g.markedAsReady = true
+		return nil
+	}
+
+	if err := g.woltGroup.MarkAsReady(ctx); err != nil {
 		return fmt.Errorf("wolt mark as ready: %w", err)
 	}
 	g.markedAsReady = true
 	return nil
 }
 
-func (h *Service) WaitUntilFinished(order *groupOrder, ctx context.Context) error {
-	details, err := order.fetchDetails()
+// WaitUntilFinished waits until the group is purchased or canceled, keeping the roster (if not nil) updated and nudging late participants meanwhile
+func (h *Service) WaitUntilFinished(order *groupOrder, ctx context.Context, roster *liveRoster) error {
+	details, err := order.fetchDetails(ctx)
 	if err != nil {
 		return fmt.Errorf("get group details: %w", err)
 	}
 
+	nudges := newParticipantNudges()
+	failures := 0
+	// The next check is kept while handling nudges, so nudging doesn't postpone it
+	var nextCheck <-chan struct{}
 	for details.Status == wolt.StatusActive {
+		if roster != nil {
+			h.updateRoster(ctx, roster, order, details)
+		}
+		if h.cfg.NudgeAfter > 0 {
+			h.nudgeLateParticipants(ctx, nudges, order, details)
+		}
+
+		if nextCheck == nil {
+			nextCheck = h.poller.after(ctx, h.poller.backoff(h.poller.groupInterval(details), failures))
+		}
 		select {
-		case <-time.After(h.cfg.WaitBetweenStatusCheck):
-			details, err = order.fetchDetails()
+		case <-nextCheck:
+			nextCheck = nil
+			latest, err := order.fetchDetails(ctx)
 			if err != nil {
-				return fmt.Errorf("get group details: %w", err)
+				if ctx.Err() != nil {
+					return fmt.Errorf("context canceled while waiting for group to progress: %w", err)
+				}
+				if failures++; failures >= maxPollFailures {
+					return fmt.Errorf("get group details: %w", err)
+				}
+				log.Printf("Error getting details of order %q, checking again later: %v\n", order.id, err)
+				continue
 			}
+			details, failures = latest, 0
+		case requestedBy := <-order.nudgeRequests:
+			h.nudgeHoldingUp(ctx, order, details, requestedBy)
 		case <-ctx.Done():
 			return fmt.Errorf("context canceled while waiting for group to progress")
 		}
 	}
 
+	if roster != nil {
+		h.updateRoster(ctx, roster, order, details)
+	}
+
 	if details.Status == wolt.StatusCanceled {
 		return fmt.Errorf("order canceled")
 	}
@@ -103,31 +155,31 @@ func (h *Service) WaitUntilFinished(order *groupOrder, ctx context.Context) erro
 	return nil
 }
 
-func (g *groupOrder) Details() (*wolt.OrderDetails, error) {
+func (g *groupOrder) Details(ctx context.Context) (*wolt.OrderDetails, error) {
 	if g.details == nil {
-		return g.fetchDetails()
+		return g.fetchDetails(ctx)
 	}
 	return g.details, nil
 }
 
-func (g *groupOrder) Venue() (*wolt.Venue, error) {
+func (g *groupOrder) Venue(ctx context.Context) (*wolt.Venue, error) {
 	if g.venue == nil {
-		return g.fetchVenue()
+		return g.fetchVenue(ctx)
 	}
 	return g.venue, nil
 }
 
-func (g *groupOrder) CalculateDeliveryRate() (int, error) {
+func (g *groupOrder) CalculateDeliveryRate(ctx context.Context) (int, error) {
 	if g.deliveryPrice >= 0 {
 		return g.deliveryPrice, nil
 	}
 
-	venue, err := g.Venue()
+	venue, err := g.Venue(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("get venue: %w", err)
 	}
 
-	details, err := g.Details()
+	details, err := g.Details(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("get details: %w", err)
 	}
@@ -141,17 +193,17 @@ func (g *groupOrder) CalculateDeliveryRate() (int, error) {
 	return deliveryPrice, nil
 }
 
-func (g *groupOrder) ToOrder(rates []Rate, receiver string) (*order.Order, error) {
-	details, err := g.Details()
+func (g *groupOrder) ToOrder(ctx context.Context, rates []Rate, receiver string) (*order.Order, error) {
+	details, err := g.Details(ctx)
 	if err != nil {
 		return nil, err
 	}
-	venue, err := g.Venue()
+	venue, err := g.Venue(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	deliveryPrice, err := g.CalculateDeliveryRate()
+	deliveryPrice, err := g.CalculateDeliveryRate(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("calculate delivery price: %w", err)
 	}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	debtDomain "github.com/oriser/bolt/debt"
+	orderDomain "github.com/oriser/bolt/order"
+	userDomain "github.com/oriser/bolt/user"
+)
+
+const (
+	homeRecentOrdersPeriod = 30 * 24 * time.Hour
+	homeRecentOrdersCount  = 5
+	// homeViewerTTL is how long the home view is kept updated after the user last opened it
+	homeViewerTTL = 7 * 24 * time.Hour
+)
+
+// HomeSection is a titled part of the user's home view, each line is a markdown text
+type HomeSection struct {
+	Title string
+	Lines []string
+}
+
+// HomeView is the personal view of a user, rendered by the transport (for example Slack's App Home tab)
+type HomeView struct {
+	Sections []HomeSection
+}
+
+// HandleAppHomeOpened publishes the home view for the user who opened it and keeps updating it on every debt change,
+// until the user doesn't open it for homeViewerTTL
+func (h *Service) HandleAppHomeOpened(transportID string) error {
+	h.expireHomeViewers()
+	h.homeViewers.Store(transportID, time.Now())
+	return h.publishHome(transportID)
+}
+
+// isHomeViewer returns whether the user opened the home view in the last homeViewerTTL
+func (h *Service) isHomeViewer(transportID string) bool {
+	openedAt, ok := h.homeViewers.Load(transportID)
+	if !ok {
+		return false
+	}
+	if time.Since(openedAt.(time.Time)) > homeViewerTTL {
+		h.homeViewers.Delete(transportID)
+		return false
+	}
+	return true
+}
+
+// expireHomeViewers stops keeping the home view updated for the users who didn't open it for homeViewerTTL
+func (h *Service) expireHomeViewers() {
+	h.homeViewers.Range(func(key, _ any) bool {
+		h.isHomeViewer(key.(string))
+		return true
+	})
+}
+
+func (h *Service) publishHome(transportID string) error {
+	view, err := h.buildHomeView(context.Background(), transportID)
+	if err != nil {
+		return fmt.Errorf("build home view: %w", err)
+	}
+
+	if err = h.eventNotification.PublishHome(transportID, view); err != nil {
+		return fmt.Errorf("publish home: %w", err)
+	}
+	return nil
+}
+
+// refreshHomes republishes the home view of the given users (by their user IDs), just for those who already opened it
+func (h *Service) refreshHomes(userIDs ...string) {
+	refreshed := make(map[string]bool)
+	for _, userID := range userIDs {
+		user, err := h.userStore.GetUser(context.Background(), userID)
+		if err != nil {
+			log.Printf("Error getting user %s for refreshing home: %v\n", userID, err)
+			continue
+		}
+		if refreshed[user.TransportID] {
+			continue
+		}
+		refreshed[user.TransportID] = true
+
+		if !h.isHomeViewer(user.TransportID) {
+			continue
+		}
+		if err = h.publishHome(user.TransportID); err != nil {
+			log.Printf("Error refreshing home of %s: %v\n", user.TransportID, err)
+		}
+	}
+}
+
+// userIDsForTransport returns all the user IDs (from all the user stores) belongs to the given transport ID
+func (h *Service) userIDsForTransport(ctx context.Context, transportID string) ([]string, error) {
+	users, err := h.userStore.ListUsers(ctx, userDomain.ListFilter{TransportID: transportID})
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+
+	ids := make([]string, 0, len(users)+1)
+	ids = append(ids, transportID)
+	for _, user := range users {
+		if user.ID != transportID {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (h *Service) buildDebtsSections(ctx context.Context, userIDs []string) ([]HomeSection, error) {
+	if h.debtStore == nil {
+		return nil, nil
+	}
+
+	debts, err := h.debtStore.ListDebts(debtDomain.ListFilter{BorrowerIDs: userIDs, LenderIDs: userIDs})
+	if err != nil {
+		return nil, fmt.Errorf("list debts: %w", err)
+	}
+
+	isUser := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		isUser[id] = true
+	}
+
+	users := &usersByID{ctx: ctx, store: h.userStore, users: make(map[string]*userDomain.User)}
+	owing := HomeSection{Title: ":money_with_wings: You owe"}
+	owed := HomeSection{Title: ":moneybag: Owed to you"}
+	totalOwing, totalOwed := 0.0, 0.0
+	for _, debt := range debts {
+		if isUser[debt.BorrowerID] {
+			owing.Lines = append(owing.Lines, fmt.Sprintf("%.2f nis to %s for Wolt order ID %s", debt.Amount, users.mention(debt.LenderID), debt.OrderID))
+			totalOwing += debt.Amount
+		}
+		if isUser[debt.LenderID] {
+			owed.Lines = append(owed.Lines, fmt.Sprintf("%s owes you %.2f nis for Wolt order ID %s", users.mention(debt.BorrowerID), debt.Amount, debt.OrderID))
+			totalOwed += debt.Amount
+		}
+	}
+
+	if len(owing.Lines) == 0 {
+		owing.Lines = []string{"Nothing, you're all clear :tada:"}
+	} else {
+		owing.Lines = append(owing.Lines, fmt.Sprintf("*Total: %.2f nis*", totalOwing))
+		owing.Lines = append(owing.Lines, fmt.Sprintf("If you paid, react with :%s: to the rates message of the order", MarkAsPaidReaction))
+	}
+	if len(owed.Lines) == 0 {
+		owed.Lines = []string{"No one owes you anything"}
+	} else {
+		owed.Lines = append(owed.Lines, fmt.Sprintf("*Total: %.2f nis*", totalOwed))
+	}
+
+	return []HomeSection{owing, owed}, nil
+}
+
+func (h *Service) buildRecentOrdersSection(ctx context.Context, userIDs []string) (HomeSection, error) {
+	section := HomeSection{Title: ":shallow_pan_of_food: Your recent orders"}
+
+	orders, err := h.orderStore.ListOrders(ctx, orderDomain.ListFilter{Since: time.Now().Add(-homeRecentOrdersPeriod)})
+	if err != nil {
+		return section, fmt.Errorf("list orders: %w", err)
+	}
+
+	isUser := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		isUser[id] = true
+	}
+
+	// Newest first
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].CreatedAt.After(orders[j].CreatedAt)
+	})
+
+	for _, order := range orders {
+		if len(section.Lines) == homeRecentOrdersCount {
+			break
+		}
+		for _, participant := range order.Participants {
+			if participant.ID == "" || !isUser[participant.ID] {
+				continue
+			}
+			section.Lines = append(section.Lines, fmt.Sprintf("<!date^%d^{date_short}|%s> %s in <#%s>: %.2f nis (Wolt order ID %s)",
+				order.CreatedAt.Unix(), order.CreatedAt.Format("2006-01-02"), order.VenueName, order.Receiver, participant.Amount, order.OriginalID))
+			break
+		}
+	}
+
+	if len(section.Lines) == 0 {
+		section.Lines = []string{"You haven't joined any order lately"}
+	}
+	return section, nil
+}
+
+// buildActiveOrdersSection lists the monitored group orders the user can see, the ones in the user's channels or hosted by the user
+func (h *Service) buildActiveOrdersSection(transportID string) HomeSection {
+	section := HomeSection{Title: ":eyes: Group orders I'm monitoring"}
+
+	channels, err := h.eventNotification.UserChannels(transportID)
+	if err != nil {
+		log.Printf("Error getting channels of %s, not showing their orders: %v\n", transportID, err)
+	}
+	isMember := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		isMember[channel] = true
+	}
+
+	h.currentlyWorkingOrders.Range(func(_, value any) bool {
+		order, ok := value.(*groupOrder)
+		if !ok || order == nil {
+			// Still joining, so its channel isn't known yet
+			return true
+		}
+		if isMember[order.receiver] || (order.hostTransportID != "" && order.hostTransportID == transportID) {
+			section.Lines = append(section.Lines, fmt.Sprintf("Wolt order ID %s in <#%s>", order.id, order.receiver))
+		}
+		return true
+	})
+	sort.Strings(section.Lines)
+
+	if len(section.Lines) == 0 {
+		section.Lines = []string{"No group orders in your channels at the moment"}
+	}
+	return section
+}
+
+func (h *Service) buildSettingsSection(transportID string) HomeSection {
+	lines := strings.Split(strings.TrimSpace(h.buildPreferencesMessage(h.userPreferences(transportID))), "\n")
+	// Replacing the message title with a hint about changing the settings
+	lines[0] = "Change them using the `/bolt-settings` command"
+	return HomeSection{Title: ":gear: Settings", Lines: lines}
+}
+
+func (h *Service) buildHomeView(ctx context.Context, transportID string) (*HomeView, error) {
+	userIDs, err := h.userIDsForTransport(ctx, transportID)
+	if err != nil {
+		return nil, fmt.Errorf("user IDs for transport: %w", err)
+	}
+
+	view := &HomeView{}
+	debtsSections, err := h.buildDebtsSections(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("build debts sections: %w", err)
+	}
+	view.Sections = append(view.Sections, debtsSections...)
+
+	recentOrders, err := h.buildRecentOrdersSection(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("build recent orders section: %w", err)
+	}
+	view.Sections = append(view.Sections, recentOrders, h.buildActiveOrdersSection(transportID), h.buildSettingsSection(transportID))
+
+	return view, nil
+}
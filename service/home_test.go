@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	debtDomain "github.com/oriser/bolt/debt"
+	orderDomain "github.com/oriser/bolt/order"
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHomeTestService() (*Service, *fakeNotification) {
+	notification := &fakeNotification{channels: map[string][]string{"U1": {"C1"}}}
+	return &Service{
+		cfg: Config{DebtReminderInterval: 3 * time.Hour},
+		userStore: &fakeUserStore{users: []*userDomain.User{
+			{ID: "1", FullName: "Dana Levi", TransportID: "U1"},
+			// Dana from another user store
+			{ID: "dana", FullName: "Dana Levi", TransportID: "U1"},
+			{ID: "2", FullName: "Noa Cohen", TransportID: "U2"},
+		}},
+		debtStore: &fakeDebtStore{debts: []*debtDomain.Debt{
+			{BorrowerID: "1", LenderID: "2", OrderID: "order-1", Amount: 20},
+			{BorrowerID: "2", LenderID: "dana", OrderID: "order-2", Amount: 15.5},
+		}},
+		orderStore:        &fakeOrderStore{},
+		eventNotification: notification,
+	}, notification
+}
+
+func TestBuildHomeView(t *testing.T) {
+	h, _ := newHomeTestService()
+	recent := time.Now().Add(-time.Hour)
+	h.orderStore = &fakeOrderStore{orders: []*orderDomain.Order{
+		{OriginalID: "order-1", VenueName: "Pizza Place", Receiver: "C1", CreatedAt: recent, Participants: []orderDomain.Participant{{Name: "Dana Levi", ID: "1", Amount: 40}}},
+		{OriginalID: "order-2", VenueName: "Burger Place", Receiver: "C1", CreatedAt: recent, Participants: []orderDomain.Participant{{Name: "Noa Cohen", ID: "2", Amount: 30}}},
+		{OriginalID: "order-3", VenueName: "Old Place", Receiver: "C1", CreatedAt: time.Now().Add(-2 * homeRecentOrdersPeriod), Participants: []orderDomain.Participant{{Name: "Dana Levi", ID: "1", Amount: 10}}},
+	}}
+	h.currentlyWorkingOrders.Store("group-1", &groupOrder{id: "group-1", receiver: "C1"})
+	h.currentlyWorkingOrders.Store("group-2", &groupOrder{id: "group-2", receiver: "C2"})
+	h.currentlyWorkingOrders.Store("group-3", &groupOrder{id: "group-3", receiver: "C3", hostTransportID: "U1"})
+	h.currentlyWorkingOrders.Store("group-4", nil)
+
+	view, err := h.buildHomeView(context.Background(), "U1")
+	require.NoError(t, err)
+	require.Len(t, view.Sections, 5)
+
+	assert.Equal(t, HomeSection{Title: ":money_with_wings: You owe", Lines: []string{
+		"20.00 nis to <@U2> for Wolt order ID order-1",
+		"*Total: 20.00 nis*",
+		"If you paid, react with :" + MarkAsPaidReaction + ": to the rates message of the order",
+	}}, view.Sections[0])
+	assert.Equal(t, HomeSection{Title: ":moneybag: Owed to you", Lines: []string{
+		"<@U2> owes you 15.50 nis for Wolt order ID order-2",
+		"*Total: 15.50 nis*",
+	}}, view.Sections[1])
+	assert.Equal(t, HomeSection{Title: ":shallow_pan_of_food: Your recent orders", Lines: []string{
+		fmt.Sprintf("<!date^%d^{date_short}|%s> Pizza Place in <#C1>: 40.00 nis (Wolt order ID order-1)", recent.Unix(), recent.Format("2006-01-02")),
+	}}, view.Sections[2])
+	assert.Equal(t, HomeSection{Title: ":eyes: Group orders I'm monitoring", Lines: []string{
+		"Wolt order ID group-1 in <#C1>",
+		"Wolt order ID group-3 in <#C3>",
+	}}, view.Sections[3])
+	assert.Equal(t, ":gear: Settings", view.Sections[4].Title)
+	assert.Equal(t, "Change them using the `/bolt-settings` command", view.Sections[4].Lines[0])
+	assert.Contains(t, view.Sections[4].Lines, "• Debt reminders (`reminders`): every 3h0m0s (default)")
+}
+
+func TestBuildHomeViewEmpty(t *testing.T) {
+	h, _ := newHomeTestService()
+	h.debtStore = &fakeDebtStore{}
+
+	view, err := h.buildHomeView(context.Background(), "U2")
+	require.NoError(t, err)
+	require.Len(t, view.Sections, 5)
+	assert.Equal(t, []string{"Nothing, you're all clear :tada:"}, view.Sections[0].Lines)
+	assert.Equal(t, []string{"No one owes you anything"}, view.Sections[1].Lines)
+	assert.Equal(t, []string{"You haven't joined any order lately"}, view.Sections[2].Lines)
+	assert.Equal(t, []string{"No group orders in your channels at the moment"}, view.Sections[3].Lines)
+}
+
+func TestRefreshHomes(t *testing.T) {
+	h, notification := newHomeTestService()
+
+	require.NoError(t, h.HandleAppHomeOpened("U1"))
+	view := notification.takeHome("U1")
+	require.NotNil(t, view)
+	assert.Equal(t, "20.00 nis to <@U2> for Wolt order ID order-1", view.Sections[0].Lines[0])
+
+	// Just the users who opened their home are refreshed, once for all their user IDs
+	h.debtStore = &fakeDebtStore{}
+	h.refreshHomes("1", "dana", "2", "unknown")
+	view = notification.takeHome("U1")
+	require.NotNil(t, view)
+	assert.Equal(t, []string{"Nothing, you're all clear :tada:"}, view.Sections[0].Lines)
+	assert.Nil(t, notification.takeHome("U2"))
+
+	// Homes not opened for longer than the TTL aren't refreshed anymore
+	h.homeViewers.Store("U1", time.Now().Add(-homeViewerTTL-time.Minute))
+	h.refreshHomes("1")
+	assert.Nil(t, notification.takeHome("U1"))
+	_, ok := h.homeViewers.Load("U1")
+	assert.False(t, ok)
+}
+
+func TestHandleAppHomeOpenedExpiresViewers(t *testing.T) {
+	h, notification := newHomeTestService()
+	h.homeViewers.Store("U1", time.Now().Add(-homeViewerTTL-time.Minute))
+
+	require.NoError(t, h.HandleAppHomeOpened("U2"))
+	assert.NotNil(t, notification.takeHome("U2"))
+
+	_, ok := h.homeViewers.Load("U1")
+	assert.False(t, ok, "viewers who didn't open their home for longer than the TTL should be dropped")
+	_, ok = h.homeViewers.Load("U2")
+	assert.True(t, ok)
+}
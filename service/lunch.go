@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/oriser/bolt/wolt"
+)
+
+// createdGroup is a group order Bolt created, waiting to be tracked
+type createdGroup struct {
+	group           *wolt.Group
+	hostTransportID string // The user who checks out the group
+}
+
+// HandleLunch creates a group order on the venue (by its link or ID) with Bolt's Wolt account, posts its link and tracks it like a shared group link.
+// The host is the user who checks out the group and the participants pay to.
+func (h *Service) HandleLunch(receiver, requestedBy, venueRef, hostTransportID string) (string, error) {
+	if h.woltSession == nil {
+		return "Creating group orders isn't supported, there's no secret for Bolt's Wolt session (WOLT_SESSION_SECRET)", nil
+	}
+	ctx := context.Background()
+	signedIn, err := h.woltSession.SignedIn(ctx)
+	if err != nil {
+		return "", fmt.Errorf("check wolt session: %w", err)
+	}
+	if !signedIn {
+		return "Creating group orders isn't supported, Bolt isn't signed in to Wolt (see the wolt-login command)", nil
+	}
+	if h.officeLocation == nil {
+		return "Creating group orders isn't supported, there's no location to deliver to (OFFICE_LOCATION)", nil
+	}
+	if hostTransportID == "" {
+		hostTransportID = requestedBy
+	}
+
+	v, err := h.fetchVenueByRef(ctx, venueRef)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVenue) {
+			return "", err
+		}
+		log.Printf("Error getting venue %q: %v\n", venueRef, err)
+		return fmt.Sprintf("I couldn't find venue %q on Wolt", venueRef), nil
+	}
+	if !v.IsDelivering() && !v.IsOpenForPreorderDelivery() {
+		return fmt.Sprintf("%s – I can't start a group order from <%s|%s> now", h.buildClosedVenueStatus(v), v.Link, v.Name), nil
+	}
+
+	g, err := h.woltClient.CreateGroup(ctx, h.woltSession, v.ID, fmt.Sprintf("Lunch from %s", v.Name), *h.officeLocation)
+	if err != nil {
+		return "", fmt.Errorf("create group: %w", err)
+	}
+	h.createdGroups.Store(g.ShortID(), &createdGroup{group: g, hostTransportID: hostTransportID})
+
+	messageID, err := h.informEvent(receiver, fmt.Sprintf(":knife_fork_plate: <@%s> is hosting a group order from <%s|%s>, join here: %s\n"+
+		"<@%s> will check out once everyone is ready", hostTransportID, v.Link, v.Name, g.URL(), hostTransportID), "", "")
+	if err != nil {
+		h.createdGroups.Delete(g.ShortID())
+		return "", fmt.Errorf("post group link: %w", err)
+	}
+
+	go h.trackCreatedGroup(receiver, messageID, hostTransportID, g.ShortID(), g.URL())
+
+	return fmt.Sprintf("OK, I started a group order from %s", v.Name), nil
+}
+
+// trackCreatedGroup tracks a group Bolt created like a shared group link.
+// Tracking takes the created group once it joins it, so if tracking fails before that, the created group is dropped here instead of kept forever.
+func (h *Service) trackCreatedGroup(receiver, messageID, hostTransportID, shortID, url string) {
+	defer h.createdGroups.Delete(shortID)
+
+	_, err := h.HandleLinkMessage(LinksRequest{
+		Links:     []Link{{Domain: "wolt.com", URL: url}},
+		MessageID: messageID,
+		Channel:   receiver,
+		User:      hostTransportID,
+	})
+	if err != nil {
+		log.Printf("Error tracking created group %s: %v\n", shortID, err)
+	}
+}
+
+// designatedHost returns the user who checks out a group Bolt created
+func (h *Service) designatedHost(hostTransportID string) (*userDomain.User, error) {
+	users, err := h.userStore.ListUsers(context.Background(), userDomain.ListFilter{TransportID: hostTransportID})
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no user with transport ID %s", hostTransportID)
+	}
+	return users[0], nil
+}
+
+// designatedHostWoltName returns the Wolt name the designated host of a group Bolt created is rated by:
+// the name of the participant matched to the host, or the host's full name if the host didn't join the group
+func (h *Service) designatedHostWoltName(ctx context.Context, details *wolt.OrderDetails, host *userDomain.User) string {
+	for _, participant := range details.Participants {
+		if participant.Name() == details.Host {
+			// Bolt's own account
+			continue
+		}
+		user := h.lookupUser(ctx, participant.Name(), participant.UserID, h.participantEmail(participant.Name(), participant.Email))
+		if user != nil && user.TransportID == host.TransportID {
+			return participant.Name()
+		}
+	}
+	return host.FullName
+}
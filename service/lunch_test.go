@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/oriser/bolt/channel"
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/oriser/bolt/wolt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDesignatedHostWoltName(t *testing.T) {
+	ctx := context.Background()
+	h, _ := newNudgeTestService()
+	_, details := newNudgeTestOrder()
+	details.Host, details.HostID = "Bolt Account", "wolt-bolt"
+	details.Participants = append(details.Participants, wolt.Participant{FirstName: "Bolt", LastName: "Account", UserID: "wolt-bolt"})
+
+	assert.Equal(t, "Dana Levi", h.designatedHostWoltName(ctx, details, &userDomain.User{ID: "U1", FullName: "Dana Levi", TransportID: "U1"}))
+
+	// Hosts who didn't join the group are rated by their name, so the delivery they pay isn't added to Bolt's account
+	host := &userDomain.User{ID: "U4", FullName: "Avi Mizrahi", TransportID: "U4"}
+	assert.Equal(t, "Avi Mizrahi", h.designatedHostWoltName(ctx, details, host))
+
+	rates := map[string]float64{"Dana Levi": 40}
+	splitDeliveryRate(rates, h.designatedHostWoltName(ctx, details, host), 15, channel.SplitHost)
+	assert.Equal(t, map[string]float64{"Dana Levi": 40, "Avi Mizrahi": 15}, rates)
+}
+
+func TestTrackCreatedGroupFailure(t *testing.T) {
+	h, notification := newNudgeTestService()
+	notification.reactionErr = errors.New("no reactions")
+	h.createdGroups.Store("ABCD1234", &createdGroup{hostTransportID: "U1"})
+
+	h.trackCreatedGroup("C1", "M1", "U1", "ABCD1234", "https://wolt.com/group/ABCD1234")
+
+	_, ok := h.createdGroups.Load("ABCD1234")
+	assert.False(t, ok, "a created group which wasn't tracked shouldn't be kept")
+	_, ok = h.currentlyWorkingOrders.Load("ABCD1234")
+	assert.False(t, ok)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+)
+
+const (
+	// maxPromptCandidates is the maximum number of candidates offered when asking who a participant is
+	maxPromptCandidates = 5
+	noneOfTheseChoice   = "none"
+)
+
+// participantEmail returns the email of a Wolt participant, preferring the configured email over the one from Wolt
+func (h *Service) participantEmail(woltName, woltEmail string) string {
+	if email, ok := h.cfg.ParticipantEmails[woltName]; ok {
+		return email
+	}
+	return woltEmail
+}
+
+// matchSource is how the user of a Wolt participant was found
+type matchSource int
+
+const (
+	notMatched     matchSource = iota
+	matchedBefore              // By the user the Wolt user ID was matched to before
+	matchedByEmail             // By the participant's exact email
+	matchedByName              // By the participant's exact name
+)
+
+// matchUser finds the user of a Wolt participant without saving the match, preferring the user the Wolt user ID was matched to before, then the participant's email and then the participant's name.
+// When more than one user has the participant's name, it returns them instead.
+func (h *Service) matchUser(ctx context.Context, woltName, woltUserID, email string) (*userDomain.User, []*userDomain.User, matchSource) {
+	if user := h.findMatchedUser(ctx, woltUserID); user != nil {
+		return user, nil, matchedBefore
+	}
+
+	if email != "" {
+		users, err := h.userStore.ListUsers(ctx, userDomain.ListFilter{Emails: []string{email}})
+		if err != nil {
+			log.Printf("Error getting user with email %s from storage: %v\n", email, err)
+		} else if len(users) > 0 {
+			// The same user may be found in more than one storage, the first one is preferred
+			return users[0], nil, matchedByEmail
+		}
+	}
+
+	users, err := h.userStore.ListUsers(ctx, userDomain.ListFilter{Names: []string{woltName}})
+	if err != nil {
+		log.Printf("Error getting user %s from storage: %v\n", woltName, err)
+		return nil, nil, notMatched
+	}
+	if len(users) == 1 {
+		return users[0], nil, matchedByName
+	}
+	return nil, users, notMatched
+}
+
+// lookupUser finds the user of a Wolt participant like findUser, without saving the match or looking for candidates.
+// It's for checking the participants while polling, which shouldn't write on every poll.
+func (h *Service) lookupUser(ctx context.Context, woltName, woltUserID, email string) *userDomain.User {
+	user, _, _ := h.matchUser(ctx, woltName, woltUserID, email)
+	return user
+}
+
+// findUser finds the user of a Wolt participant like matchUser, and saves the match so the next orders find the user by the Wolt user ID.
+// When there's no certain match, it returns the users which might be the participant.
+func (h *Service) findUser(ctx context.Context, woltName, woltUserID, email string) (*userDomain.User, []*userDomain.Candidate) {
+	user, sameName, source := h.matchUser(ctx, woltName, woltUserID, email)
+	switch source {
+	case matchedByEmail:
+		// An exact email is a certain match, so it's saved as confirmed
+		h.saveMatch(ctx, woltName, woltUserID, user, true)
+	case matchedByName:
+		h.saveMatch(ctx, woltName, woltUserID, user, false)
+	}
+	if user != nil {
+		return user, nil
+	}
+
+	if len(sameName) > 0 {
+		log.Printf("More than one user for %s: %#v\n", woltName, sameName)
+		candidates := make([]*userDomain.Candidate, len(sameName))
+		for i, user := range sameName {
+			candidates[i] = &userDomain.Candidate{User: user, Score: 100}
+		}
+		return nil, candidates
+	}
+
+	candidates, err := h.userStore.FindCandidates(ctx, woltName)
+	if err != nil {
+		log.Printf("Error finding candidates for %s: %v\n", woltName, err)
+		return nil, nil
+	}
+	if len(candidates) == 0 {
+		log.Printf("User not found %s\n", woltName)
+	}
+	return nil, candidates
+}
+
+// findMatchedUser returns the user the Wolt user was matched to before, or nil if the Wolt user was never matched
+func (h *Service) findMatchedUser(ctx context.Context, woltUserID string) *userDomain.User {
+	if woltUserID == "" {
+		return nil
+	}
+
+	users, err := h.userStore.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{woltUserID}})
+	if err != nil {
+		log.Printf("Error getting user matched to Wolt user ID %s from storage: %v\n", woltUserID, err)
+		return nil
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	// The same user may be found in more than one storage, the first one is preferred
+	return users[0]
+}
+
+// saveMatch saves the user as the Wolt participant, so the next orders will find the user by the Wolt user ID.
+// Confirmed matches of custom users are saved as an alias too, so they will be found by the participant's name as well.
+// Unless the match is confirmed, just certain matches are saved: custom users (matched by their name or alias) and users from external directories with the exact same name.
+func (h *Service) saveMatch(ctx context.Context, woltName, woltUserID string, user *userDomain.User, confirmed bool) {
+	customUsers, err := h.userStore.ListUsers(ctx, userDomain.ListFilter{TransportID: user.TransportID, CustomOnly: true})
+	if err != nil {
+		log.Printf("Error listing custom users for %s: %v\n", woltName, err)
+		return
+	}
+	var customUser *userDomain.User
+	for _, u := range customUsers {
+		if u.ID == user.ID {
+			customUser = u
+		}
+	}
+
+	sameName := strings.EqualFold(strings.TrimSpace(user.FullName), strings.TrimSpace(woltName))
+	if !confirmed && customUser == nil && !sameName {
+		// A fuzzy match, it might be the wrong user
+		return
+	}
+
+	if woltUserID != "" && user.TransportID != "" && h.woltMatchStore != nil {
+		if err = h.woltMatchStore.SaveWoltMatch(ctx, woltUserID, user.TransportID); err != nil {
+			log.Printf("Error saving Wolt user ID of %s: %v\n", woltName, err)
+		}
+	}
+
+	if confirmed && customUser != nil && !sameName {
+		if err = h.userStore.AddAlias(ctx, customUser.ID, woltName); err != nil {
+			log.Printf("Error saving %s as an alias of %s: %v\n", woltName, customUser.FullName, err)
+		}
+	}
+}
+
+// askAboutUncertainParticipants asks the host (or whoever shared the link, if the host is unknown) who are the participants Bolt isn't sure about
+func (h *Service) askAboutUncertainParticipants(req LinksRequest, orderID string, rates GroupRate, settings *channelSettings) {
+	recipient := req.User
+	if rates.HostUser != nil {
+		recipient = rates.HostUser.TransportID
+	}
+	if recipient == "" {
+		return
+	}
+
+	// Debts can be added only while the debts of the order are tracked
+	debtsDeadline := time.Now().Add(settings.debtMaximumDuration)
+	for _, rate := range rates.Rates {
+		if rate.User != nil || len(rate.Candidates) == 0 {
+			continue
+		}
+
+		candidates := rate.Candidates
+		if len(candidates) > maxPromptCandidates {
+			candidates = candidates[:maxPromptCandidates]
+		}
+
+		prompt := &ChoicePrompt{
+			Text:    fmt.Sprintf("Is Wolt participant %q one of these?", rate.WoltName),
+			Choices: make([]Choice, 0, len(candidates)+1),
+		}
+		for i, candidate := range candidates {
+			prompt.Choices = append(prompt.Choices, Choice{ID: strconv.Itoa(i), Text: candidate.User.FullName})
+		}
+		prompt.Choices = append(prompt.Choices, Choice{ID: noneOfTheseChoice, Text: "None of these"})
+
+		rate := rate
+		err := h.sendPrompt(req.Channel, recipient, req.MessageID, prompt, settings.debtMaximumDuration, func(choiceID, fromTransportID string) (string, error) {
+			if choiceID == noneOfTheseChoice {
+				return fmt.Sprintf("OK, I won't track %q payment.", rate.WoltName), nil
+			}
+			index, err := strconv.Atoi(choiceID)
+			if err != nil || index < 0 || index >= len(candidates) {
+				return "", fmt.Errorf("unknown choice %q", choiceID)
+			}
+			return h.handleParticipantMatched(req, orderID, rates, rate, candidates[index].User, debtsDeadline, settings)
+		})
+		if err != nil {
+			log.Printf("Error asking about participant %q of order %s: %v\n", rate.WoltName, orderID, err)
+		}
+	}
+}
+
+// handleParticipantMatched saves the answer about who the participant is, and creates the debt which wasn't created without knowing the user
+func (h *Service) handleParticipantMatched(req LinksRequest, orderID string, rates GroupRate, rate Rate, user *userDomain.User, debtsDeadline time.Time, settings *channelSettings) (string, error) {
+	h.saveMatch(context.Background(), rate.WoltName, rate.WoltUserID, user, true)
+
+	response := fmt.Sprintf("Thanks! I'll remember that Wolt participant %q is <@%s>.", rate.WoltName, user.TransportID)
+	if h.debtStore == nil || !settings.debtTracking || rates.HostUser == nil || rate.WoltName == rates.HostWoltUser || user.ID == rates.HostUser.ID {
+		return response, nil
+	}
+	if time.Now().After(debtsDeadline) {
+		return response + " It's too late to track the payment for this order.", nil
+	}
+
+	if err := h.createDebt(rate.Amount, req.Channel, orderID, req.MessageID, user, rates.HostUser); err != nil {
+		return "", fmt.Errorf("create debt: %w", err)
+	}
+	h.startDebtWorker(orderID, debtsDeadline, settings.debtReminderInterval)
+	go h.refreshHomes(user.ID, rates.HostUser.ID)
+
+	_, _ = h.informEvent(req.Channel, fmt.Sprintf("<@%s>, you should pay %.2f nis to <@%s> for Wolt order ID %s. When you pay, react with :%s: to the rates message.",
+		user.TransportID, rate.Amount, rates.HostUser.TransportID, orderID, MarkAsPaidReaction), "", req.MessageID)
+	return response + " I'll track the payment as well.", nil
+}
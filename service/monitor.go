@@ -6,33 +6,67 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"github.com/oriser/bolt/wolt"
 )
 
-func (h *Service) buildClosedVenueMessage(offlinePeriodEnd time.Time, timezone *time.Location, preorderEnabled bool) string {
+func (h *Service) buildClosedVenueMessage(venue *wolt.Venue) string {
+	return h.buildClosedVenueStatus(venue) + " – I'll let you know when it comes back"
+}
+
+// buildClosedVenueStatus describes why a venue isn't delivering, until when, and when pre-orders can be delivered
+func (h *Service) buildClosedVenueStatus(venue *wolt.Venue) string {
 	var sb strings.Builder
+	now := time.Now()
 
+	preorderEnabled := venue.IsOpenForPreorderDelivery()
 	if preorderEnabled {
 		sb.WriteString(":large_yellow_circle: Venue is accepting only pre-order deliveries")
 	} else {
 		sb.WriteString(":red_circle: Venue is closed for delivery")
 	}
 
+	offlinePeriodEnd := venue.OfflinePeriodEnd
 	if !IsUnixZero(offlinePeriodEnd) {
-		timeFormatString := "{time}"
-		if !IsToday(offlinePeriodEnd, timezone) {
-			timeFormatString = "{date_num} {time}"
-		}
-		offlinePeriodEndString := fmt.Sprintf("<!date^%d^%s|%s>", offlinePeriodEnd.Unix(), timeFormatString, offlinePeriodEnd.In(timezone).Format("2006-01-02 15:04"))
-		sb.WriteString(fmt.Sprintf(" (allegedly until %s)", offlinePeriodEndString))
+		sb.WriteString(fmt.Sprintf(" (allegedly until %s)", formatVenueTime(offlinePeriodEnd, venue.TimezoneLocation)))
 	}
 
-	sb.WriteString(" – I'll let you know when it comes back")
+	if opening, ok := venue.NextOpening(now); ok && (IsUnixZero(offlinePeriodEnd) || !opening.Before(offlinePeriodEnd)) {
+		sb.WriteString(fmt.Sprintf(", opens at %s (in %s)", formatVenueTime(opening, venue.TimezoneLocation), formatTimeUntil(opening.Sub(now))))
+	}
+
+	if slot, ok := venue.NextPreorderSlot(now); ok && slot.After(now) {
+		sb.WriteString(fmt.Sprintf(", pre-order slots from %s", formatVenueTime(slot, venue.TimezoneLocation)))
+	}
 
 	return sb.String()
 }
 
+// formatVenueTime formats t as a Slack date, showing the date too if it's not today in the venue's timezone
+func formatVenueTime(t time.Time, timezone *time.Location) string {
+	timeFormatString := "{time}"
+	if !IsToday(t, timezone) {
+		timeFormatString = "{date_num} {time}"
+	}
+	return fmt.Sprintf("<!date^%d^%s|%s>", t.Unix(), timeFormatString, t.In(timezone).Format("2006-01-02 15:04"))
+}
+
+// formatTimeUntil formats a duration in whole minutes, like "40 min" or "2h 5 min"
+func formatTimeUntil(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	hours, minutes := minutes/60, minutes%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d min", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh %d min", hours, minutes)
+	}
+}
+
 func (h *Service) monitorVenue(ctx context.Context, order *groupOrder, receiver, initialMessageID string) {
-	details, err := order.Details()
+	details, err := order.Details(ctx)
 	if err != nil {
 		log.Printf("Error getting details for order %q: %v\n", order.id, err)
 		return
@@ -42,29 +76,34 @@ func (h *Service) monitorVenue(ctx context.Context, order *groupOrder, receiver,
 	var lastOfflinePeriodEnd time.Time
 	var venueClosedMessageId string
 
-	ticker := time.NewTicker(h.cfg.WaitBetweenStatusCheck)
+	var venue *wolt.Venue
+	failures := 0
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			venue, err := order.woltGroup.VenueDetails(details)
+		case <-h.poller.after(ctx, h.poller.backoff(h.poller.venueInterval(venue), failures)):
+			latest, err := order.woltGroup.VenueStatus(ctx, details)
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				failures++
 				log.Printf("Error getting venue for order %q: %v\n", order.id, err)
 				continue
 			}
+			venue, failures = latest, 0
 
-			isOpenForPreorderDelivery := venue.IsOpenForPreorderDelivery()
 			if waitingToOpenDeliveries && venue.IsDelivering() {
 				_, _ = h.informEvent(receiver, ":large_green_circle: Venue is now open for delivery", "", initialMessageID)
 				waitingToOpenDeliveries = false
 			} else if !waitingToOpenDeliveries && !venue.IsDelivering() {
-				venueClosedMessageId, _ = h.informEvent(receiver, h.buildClosedVenueMessage(venue.OfflinePeriodEnd, venue.TimezoneLocation, isOpenForPreorderDelivery), "", initialMessageID)
+				venueClosedMessageId, _ = h.informEvent(receiver, h.buildClosedVenueMessage(venue), "", initialMessageID)
 				waitingToOpenDeliveries = true
 				lastOfflinePeriodEnd = venue.OfflinePeriodEnd
 			} else if waitingToOpenDeliveries && lastOfflinePeriodEnd != venue.OfflinePeriodEnd {
-				_ = h.eventNotification.EditMessage(receiver, h.buildClosedVenueMessage(venue.OfflinePeriodEnd, venue.TimezoneLocation, isOpenForPreorderDelivery), venueClosedMessageId)
+				_ = h.eventNotification.EditMessage(receiver, h.buildClosedVenueMessage(venue), venueClosedMessageId)
 				lastOfflinePeriodEnd = venue.OfflinePeriodEnd
 			}
 		}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/oriser/bolt/wolt"
+)
+
+// HostNudgeReaction is the reaction the host adds to the message with the group link to nudge whoever isn't ready yet
+const HostNudgeReaction = "bell"
+
+// requestNudge asks the waiting loop of every group order linked in the message to nudge its participants who aren't ready
+func (h *Service) requestNudge(req ReactionAddRequest) {
+	orders := h.ordersOfMessage(req.Channel, req.MessageID)
+	if len(orders) == 0 {
+		log.Println("Got nudge reaction for a message without an open group order, ignoring")
+		return
+	}
+	for _, order := range orders {
+		select {
+		case order.nudgeRequests <- req.FromUserID:
+		default:
+			// A nudge is already pending
+		}
+	}
+}
+
+// participantNudges tracks since when participants of an open group order aren't ready, and who was nudged
+type participantNudges struct {
+	notReadySince map[string]time.Time // By the Wolt user ID
+	nudged        map[string]bool
+}
+
+func newParticipantNudges() *participantNudges {
+	return &participantNudges{
+		notReadySince: make(map[string]time.Time),
+		nudged:        make(map[string]bool),
+	}
+}
+
+// holdingUp returns the participants who aren't ready, other than the host and Bolt
+func holdingUp(details *wolt.OrderDetails) []wolt.Participant {
+	participants := make([]wolt.Participant, 0)
+	for _, participant := range details.Participants {
+		if participant.Status == wolt.ParticipantStatusReady || participant.UserID == details.HostID || participant.Name() == wolt.BotName {
+			continue
+		}
+		participants = append(participants, participant)
+	}
+	return participants
+}
+
+// nudgeLateParticipants nudges the participants who aren't ready for longer than NUDGE_AFTER, once per participant
+func (h *Service) nudgeLateParticipants(ctx context.Context, nudges *participantNudges, order *groupOrder, details *wolt.OrderDetails) {
+	now := time.Now()
+	late := make([]wolt.Participant, 0)
+	stillNotReady := make(map[string]time.Time)
+	for _, participant := range holdingUp(details) {
+		since, ok := nudges.notReadySince[participant.UserID]
+		if !ok {
+			since = now
+		}
+		stillNotReady[participant.UserID] = since
+		if !nudges.nudged[participant.UserID] && now.Sub(since) >= h.cfg.NudgeAfter {
+			nudges.nudged[participant.UserID] = true
+			late = append(late, participant)
+		}
+	}
+	nudges.notReadySince = stillNotReady
+
+	if len(late) > 0 {
+		h.nudgeParticipants(ctx, order, details, late)
+	}
+}
+
+// nudgeHoldingUp nudges all the participants who aren't ready, when the host asked to
+func (h *Service) nudgeHoldingUp(ctx context.Context, order *groupOrder, details *wolt.OrderDetails, requestedBy string) {
+	if !h.isGroupHost(ctx, order, details, requestedBy) {
+		host := details.Host
+		if order.hostTransportID != "" {
+			host = fmt.Sprintf("<@%s>", order.hostTransportID)
+		}
+		_, _ = h.informEvent(requestedBy, fmt.Sprintf("Only the host (%s) can nudge the participants of this group :no_good:", host), "", "")
+		return
+	}
+
+	participants := holdingUp(details)
+	if len(participants) == 0 {
+		_, _ = h.informEvent(order.receiver, "Everyone is ready, there's no one to nudge :tada:", "", order.messageID)
+		return
+	}
+	h.nudgeParticipants(ctx, order, details, participants)
+}
+
+// isGroupHost returns whether the user (by the transport ID) is the host of the group
+func (h *Service) isGroupHost(ctx context.Context, order *groupOrder, details *wolt.OrderDetails, transportID string) bool {
+	if order.hostTransportID != "" {
+		// The Wolt host of groups Bolt created is Bolt's account, their host is whoever checks out
+		return order.hostTransportID == transportID
+	}
+	hostUser := h.lookupUser(ctx, details.Host, details.HostID, "")
+	return hostUser != nil && hostUser.TransportID == transportID
+}
+
+// nudgeParticipants lets the participants know the group is waiting for them, and tells the channel who was nudged
+func (h *Service) nudgeParticipants(ctx context.Context, order *groupOrder, details *wolt.OrderDetails, participants []wolt.Participant) {
+	groupName := fmt.Sprintf("the group of %s", details.Host)
+	if venue, err := order.Venue(ctx); err != nil {
+		log.Printf("Error getting venue of order %q for nudging: %v\n", order.id, err)
+	} else {
+		groupName = fmt.Sprintf("%s (%s)", groupName, venue.Name)
+	}
+
+	nudged := make([]string, 0, len(participants))
+	notFound := make([]string, 0)
+	for _, participant := range participants {
+		user := h.lookupUser(ctx, participant.Name(), participant.UserID, h.participantEmail(participant.Name(), participant.Email))
+		if user == nil {
+			notFound = append(notFound, participant.Name())
+			continue
+		}
+
+		if _, err := h.informEvent(user.TransportID, fmt.Sprintf(":hourglass_flowing_sand: The group for %s is waiting for you", groupName), "", ""); err != nil {
+			log.Printf("Error nudging %s in order %q: %v\n", participant.Name(), order.id, err)
+			notFound = append(notFound, participant.Name())
+			continue
+		}
+		nudged = append(nudged, fmt.Sprintf("<@%s>", user.TransportID))
+	}
+
+	var sb strings.Builder
+	if len(nudged) > 0 {
+		sb.WriteString(fmt.Sprintf(":bell: I nudged %s", strings.Join(nudged, ", ")))
+	}
+	if len(notFound) > 0 {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("I couldn't nudge %s, they are still not ready", strings.Join(notFound, ", ")))
+	}
+	_, _ = h.informEvent(order.receiver, sb.String(), "", order.messageID)
+}
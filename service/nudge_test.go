@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/oriser/bolt/wolt"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNudgeTestService() (*Service, *fakeNotification) {
+	notification := &fakeNotification{}
+	return &Service{
+		cfg: Config{NudgeAfter: time.Hour},
+		userStore: &fakeUserStore{users: []*userDomain.User{
+			{ID: "U1", FullName: "Dana Levi", TransportID: "U1"},
+			{ID: "U2", FullName: "Noa Cohen", TransportID: "U2"},
+			{ID: "U3", FullName: "Yossi Host", TransportID: "U3"},
+		}},
+		eventNotification: notification,
+	}, notification
+}
+
+func newNudgeTestOrder() (*groupOrder, *wolt.OrderDetails) {
+	order := &groupOrder{id: "order", receiver: "C1", messageID: "M1", venue: &wolt.Venue{Name: "Tasty Venue"}}
+	details := &wolt.OrderDetails{
+		Host:   "Yossi Host",
+		HostID: "wolt-host",
+		Participants: []wolt.Participant{
+			{FirstName: "Yossi", LastName: "Host", UserID: "wolt-host", Status: wolt.ParticipantStatusJoined},
+			{FirstName: "Dana", LastName: "Levi", UserID: "wolt-dana", Status: wolt.ParticipantStatusJoined},
+			{FirstName: "Noa", LastName: "Cohen", UserID: "wolt-noa", Status: wolt.ParticipantStatusReady},
+			{FirstName: "Tom", UserID: "wolt-tom", Status: wolt.ParticipantStatusJoined},
+			{FirstName: wolt.BotName, UserID: "wolt-bot", Status: wolt.ParticipantStatusJoined},
+		},
+	}
+	return order, details
+}
+
+func TestNudgeLateParticipants(t *testing.T) {
+	ctx := context.Background()
+	h, notification := newNudgeTestService()
+	order, details := newNudgeTestOrder()
+	nudges := newParticipantNudges()
+
+	// Participants who just aren't ready aren't late yet
+	h.nudgeLateParticipants(ctx, nudges, order, details)
+	assert.Empty(t, notification.takeMessages())
+	assert.Contains(t, nudges.notReadySince, "wolt-dana")
+	assert.Contains(t, nudges.notReadySince, "wolt-tom")
+	assert.NotContains(t, nudges.notReadySince, "wolt-host", "the host isn't holding up the group")
+	assert.NotContains(t, nudges.notReadySince, "wolt-noa", "ready participants aren't holding up the group")
+	assert.NotContains(t, nudges.notReadySince, "wolt-bot")
+
+	// Dana isn't ready for longer than NUDGE_AFTER
+	nudges.notReadySince["wolt-dana"] = time.Now().Add(-2 * time.Hour)
+	h.nudgeLateParticipants(ctx, nudges, order, details)
+	assert.Equal(t, []sentMessage{
+		{receiver: "U1", text: ":hourglass_flowing_sand: The group for the group of Yossi Host (Tasty Venue) is waiting for you"},
+		{receiver: "C1", text: ":bell: I nudged <@U1>", messageID: "M1"},
+	}, notification.takeMessages())
+
+	// Every participant is nudged once
+	h.nudgeLateParticipants(ctx, nudges, order, details)
+	assert.Empty(t, notification.takeMessages())
+
+	// Participants without a user are mentioned in the channel
+	nudges.notReadySince["wolt-tom"] = time.Now().Add(-2 * time.Hour)
+	h.nudgeLateParticipants(ctx, nudges, order, details)
+	assert.Equal(t, []sentMessage{
+		{receiver: "C1", text: "I couldn't nudge Tom, they are still not ready", messageID: "M1"},
+	}, notification.takeMessages())
+
+	// Participants who got ready aren't tracked anymore
+	details.Participants[1].Status = wolt.ParticipantStatusReady
+	h.nudgeLateParticipants(ctx, nudges, order, details)
+	assert.NotContains(t, nudges.notReadySince, "wolt-dana")
+	assert.Empty(t, notification.takeMessages())
+}
+
+func TestNudgeHoldingUp(t *testing.T) {
+	ctx := context.Background()
+	h, notification := newNudgeTestService()
+	order, details := newNudgeTestOrder()
+
+	// Just the host can nudge
+	h.nudgeHoldingUp(ctx, order, details, "U2")
+	assert.Equal(t, []sentMessage{
+		{receiver: "U2", text: "Only the host (Yossi Host) can nudge the participants of this group :no_good:"},
+	}, notification.takeMessages())
+
+	h.nudgeHoldingUp(ctx, order, details, "U3")
+	assert.Equal(t, []sentMessage{
+		{receiver: "U1", text: ":hourglass_flowing_sand: The group for the group of Yossi Host (Tasty Venue) is waiting for you"},
+		{receiver: "C1", text: ":bell: I nudged <@U1>\nI couldn't nudge Tom, they are still not ready", messageID: "M1"},
+	}, notification.takeMessages())
+
+	// Nothing to nudge once everyone is ready
+	for i := range details.Participants {
+		details.Participants[i].Status = wolt.ParticipantStatusReady
+	}
+	h.nudgeHoldingUp(ctx, order, details, "U3")
+	assert.Equal(t, []sentMessage{
+		{receiver: "C1", text: "Everyone is ready, there's no one to nudge :tada:", messageID: "M1"},
+	}, notification.takeMessages())
+}
+
+func TestNudgeHoldingUpCreatedGroup(t *testing.T) {
+	ctx := context.Background()
+	h, notification := newNudgeTestService()
+	order, details := newNudgeTestOrder()
+	// Groups Bolt created are hosted in Wolt by Bolt's account, and checked out by the host from /lunch
+	order.hostTransportID = "U2"
+	details.Host, details.HostID = "Bolt Account", "wolt-bolt"
+
+	h.nudgeHoldingUp(ctx, order, details, "U3")
+	assert.Equal(t, []sentMessage{
+		{receiver: "U3", text: "Only the host (<@U2>) can nudge the participants of this group :no_good:"},
+	}, notification.takeMessages())
+
+	h.nudgeHoldingUp(ctx, order, details, "U2")
+	messages := notification.takeMessages()
+	assert.NotEmpty(t, messages)
+	assert.Equal(t, sentMessage{receiver: "C1", text: ":bell: I nudged <@U3>, <@U1>\nI couldn't nudge Tom, they are still not ready", messageID: "M1"}, messages[len(messages)-1])
+}
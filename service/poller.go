@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/oriser/bolt/wolt"
+	"golang.org/x/time/rate"
+)
+
+// maxPollFailures is how many status checks in a row may fail before giving up on what's polled
+const maxPollFailures = 5
+
+// pollJitter is the fraction of the interval the status checks are randomly moved by, so checks scheduled together don't stay together
+const pollJitter = 0.1
+
+// poller schedules the status checks of all the tracked orders and watched venues.
+// Every check is polled as often as its state needs (between the min and max intervals),
+// and all the checks are spread so they are sent at most at STATUS_CHECKS_PER_SECOND together.
+type poller struct {
+	base    time.Duration
+	min     time.Duration
+	max     time.Duration
+	limiter *rate.Limiter
+}
+
+func newPoller(base, min, max time.Duration, checksPerSecond float64) *poller {
+	if min <= 0 || min > base {
+		min = base
+	}
+	if max < base {
+		max = base
+	}
+	limit := rate.Inf
+	if checksPerSecond > 0 {
+		limit = rate.Limit(checksPerSecond)
+	}
+	burst := int(checksPerSecond)
+	if burst < 1 {
+		burst = 1
+	}
+	return &poller{
+		base:    base,
+		min:     min,
+		max:     max,
+		limiter: rate.NewLimiter(limit, burst),
+	}
+}
+
+// after returns a channel which is closed once the next status check should be done: about interval from now,
+// and then once the rate limit allows it, so checks which are due together are spread.
+// The channel is never closed if the context is done first.
+func (p *poller) after(ctx context.Context, interval time.Duration) <-chan struct{} {
+	if interval > 0 {
+		interval += time.Duration((rand.Float64()*2 - 1) * pollJitter * float64(interval))
+	}
+
+	due := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// Waiting at the time of the check, as reserving ahead would reserve the checks out of order
+		if err := p.limiter.Wait(ctx); err != nil {
+			return
+		}
+		close(due)
+	}()
+	return due
+}
+
+// backoff returns the interval after the given number of failed checks in a row
+func (p *poller) backoff(interval time.Duration, failures int) time.Duration {
+	for i := 0; i < failures && interval < p.max; i++ {
+		interval *= 2
+	}
+	return p.clamp(interval)
+}
+
+func (p *poller) clamp(interval time.Duration) time.Duration {
+	if interval < p.min {
+		return p.min
+	}
+	if interval > p.max {
+		return p.max
+	}
+	return interval
+}
+
+// groupInterval returns the interval for checking an open group: slow while no one started ordering,
+// faster as participants become ready, and the fastest once everyone is ready and the group is about to be purchased
+func (p *poller) groupInterval(details *wolt.OrderDetails) time.Duration {
+	participants, ordering, ready := 0, 0, 0
+	for _, participant := range details.Participants {
+		if participant.Name() == wolt.BotName {
+			continue
+		}
+		participants++
+		if participant.Status == wolt.ParticipantStatusReady {
+			ready++
+		} else if participant.Subtotal() > 0 {
+			ordering++
+		}
+	}
+
+	switch {
+	case participants == 0 || ready+ordering == 0:
+		return p.max
+	case ready == participants:
+		return p.min
+	default:
+		readyPart := float64(ready) / float64(participants)
+		return p.clamp(p.base - time.Duration(readyPart*float64(p.base-p.min)))
+	}
+}
+
+// deliveryInterval returns the interval for checking a purchased group: more often as the delivery gets closer,
+// and not later than when the "get ready" message should be sent
+func (p *poller) deliveryInterval(details *wolt.OrderDetails, getReadyBefore time.Duration) time.Duration {
+	if IsUnixZero(details.DeliveryEta) {
+		return p.base
+	}
+
+	untilDelivery := time.Until(details.DeliveryEta)
+	if untilDelivery <= 0 {
+		return p.min
+	}
+	interval := untilDelivery / 10
+	if untilGetReady := untilDelivery - getReadyBefore; untilGetReady > 0 && untilGetReady < interval {
+		interval = untilGetReady
+	}
+	return p.clamp(interval)
+}
+
+// venueInterval returns the interval for checking a venue: slow while it's delivering,
+// and around the time it's expected to reopen while it's closed
+func (p *poller) venueInterval(venue *wolt.Venue) time.Duration {
+	if venue == nil {
+		return p.base
+	}
+	if venue.IsDelivering() {
+		return p.max
+	}
+
+	reopening := venue.OfflinePeriodEnd
+	// Like in the closed venue status, the venue reopens at the later of the end of its offline period and its next opening
+	if opening, ok := venue.NextOpening(time.Now()); ok && (IsUnixZero(reopening) || opening.After(reopening)) {
+		reopening = opening
+	}
+	if IsUnixZero(reopening) || !reopening.After(time.Now()) {
+		return p.base
+	}
+	return p.clamp(time.Until(reopening))
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/wolt"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testBaseInterval = time.Second
+	testMinInterval  = 100 * time.Millisecond
+	testMaxInterval  = 10 * time.Second
+	// intervalDelta allows for the time passing between building the test case and computing the interval
+	intervalDelta = float64(50 * time.Millisecond)
+)
+
+func newTestPoller() *poller {
+	return newPoller(testBaseInterval, testMinInterval, testMaxInterval, 0)
+}
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		interval time.Duration
+		failures int
+		expected time.Duration
+	}{
+		{name: "No failures", interval: testBaseInterval, expected: testBaseInterval},
+		{name: "Doubled on every failure", interval: testBaseInterval, failures: 2, expected: 4 * testBaseInterval},
+		{name: "Capped at max", interval: testBaseInterval, failures: 10, expected: testMaxInterval},
+		{name: "Clamped to min", interval: testMinInterval / 2, expected: testMinInterval},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, newTestPoller().backoff(tc.interval, tc.failures))
+		})
+	}
+}
+
+func TestGroupInterval(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		participants []wolt.Participant
+		expected     time.Duration
+	}{
+		{name: "No participants", expected: testMaxInterval},
+		{
+			name:         "Just the bot",
+			participants: []wolt.Participant{rosterParticipant(wolt.BotName, wolt.ParticipantStatusReady)},
+			expected:     testMaxInterval,
+		},
+		{
+			name:         "No one started ordering",
+			participants: []wolt.Participant{rosterParticipant("Noa", wolt.ParticipantStatusJoined)},
+			expected:     testMaxInterval,
+		},
+		{
+			name: "Ordering",
+			participants: []wolt.Participant{
+				rosterParticipant("Noa", wolt.ParticipantStatusJoined),
+				rosterParticipant("Dana", wolt.ParticipantStatusJoined, 2500),
+			},
+			expected: testBaseInterval,
+		},
+		{
+			name: "Half ready",
+			participants: []wolt.Participant{
+				rosterParticipant(wolt.BotName, wolt.ParticipantStatusJoined),
+				rosterParticipant("Dana", wolt.ParticipantStatusJoined, 2500),
+				rosterParticipant("Yossi", wolt.ParticipantStatusReady, 3000),
+			},
+			expected: testBaseInterval - (testBaseInterval-testMinInterval)/2,
+		},
+		{
+			name: "Everyone ready",
+			participants: []wolt.Participant{
+				rosterParticipant(wolt.BotName, wolt.ParticipantStatusJoined),
+				rosterParticipant("Yossi", wolt.ParticipantStatusReady, 3000),
+				rosterParticipant("Avi", wolt.ParticipantStatusReady),
+			},
+			expected: testMinInterval,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, newTestPoller().groupInterval(&wolt.OrderDetails{Participants: tc.participants}))
+		})
+	}
+}
+
+func TestDeliveryInterval(t *testing.T) {
+	const getReadyBefore = 5 * time.Minute
+	for _, tc := range []struct {
+		name        string
+		deliveryEta time.Time
+		expected    time.Duration
+	}{
+		{name: "No ETA", deliveryEta: time.Unix(0, 0), expected: testBaseInterval},
+		{name: "ETA passed", deliveryEta: time.Now().Add(-time.Minute), expected: testMinInterval},
+		{name: "Tenth of the time until delivery", deliveryEta: time.Now().Add(50 * time.Second), expected: 5 * time.Second},
+		{name: "Not later than the get ready message", deliveryEta: time.Now().Add(getReadyBefore + 2*time.Second), expected: 2 * time.Second},
+		{name: "Far delivery", deliveryEta: time.Now().Add(2 * time.Hour), expected: testMaxInterval},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			details := &wolt.OrderDetails{DeliveryEta: tc.deliveryEta}
+			assert.InDelta(t, float64(tc.expected), float64(newTestPoller().deliveryInterval(details, getReadyBefore)), intervalDelta)
+		})
+	}
+}
+
+func TestVenueInterval(t *testing.T) {
+	closedVenue := func(offlinePeriodEnd time.Time) *wolt.Venue {
+		return &wolt.Venue{TimezoneLocation: time.UTC, OfflinePeriodEnd: offlinePeriodEnd}
+	}
+	deliveringVenue := closedVenue(time.Unix(0, 0))
+	deliveringVenue.Alive = 1
+	deliveringVenue.Online = true
+	deliveringVenue.DeliverySpecs.DeliveryEnabled = true
+
+	for _, tc := range []struct {
+		name     string
+		venue    *wolt.Venue
+		expected time.Duration
+	}{
+		{name: "Not checked yet", expected: testBaseInterval},
+		{name: "Delivering", venue: deliveringVenue, expected: testMaxInterval},
+		{name: "Closed until unknown", venue: closedVenue(time.Unix(0, 0)), expected: testBaseInterval},
+		{name: "Closed until passed", venue: closedVenue(time.Now().Add(-time.Minute)), expected: testBaseInterval},
+		{name: "Reopening soon", venue: closedVenue(time.Now().Add(3 * time.Second)), expected: 3 * time.Second},
+		{name: "Reopening later", venue: closedVenue(time.Now().Add(time.Hour)), expected: testMaxInterval},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, float64(tc.expected), float64(newTestPoller().venueInterval(tc.venue)), intervalDelta)
+		})
+	}
+}
+
+func TestAfterSpreadsChecks(t *testing.T) {
+	const checksPerSecond = 2
+	p := newPoller(testBaseInterval, testMinInterval, testMaxInterval, checksPerSecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Checks due together are sent at most at the rate limit, beyond the burst
+	start := time.Now()
+	checks := make([]<-chan struct{}, 2*checksPerSecond)
+	for i := range checks {
+		checks[i] = p.after(ctx, 0)
+	}
+	for _, check := range checks {
+		select {
+		case <-check:
+		case <-ctx.Done():
+			t.Fatal("Check wasn't due before the timeout")
+		}
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+}
+
+func TestAfterCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	check := newTestPoller().after(ctx, testMinInterval)
+	cancel()
+
+	select {
+	case <-check:
+		t.Fatal("Check was due after the context was canceled")
+	case <-time.After(2 * testMinInterval):
+	}
+}
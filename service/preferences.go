@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+)
+
+const (
+	SettingReminders         = "reminders"
+	SettingQuietHours        = "quiet-hours"
+	SettingPaidNotifications = "paid-notifications"
+	SettingNotificationMode  = "mode"
+)
+
+// ErrInvalidSetting is returned when the user asked to change a setting with an invalid value
+var ErrInvalidSetting = errors.New("invalid setting")
+
+// userPreferences returns the preferences for the given transport ID, falling back to the default preferences on error
+func (h *Service) userPreferences(transportID string) *userDomain.Preferences {
+	preferences, err := h.userStore.GetPreferences(context.Background(), transportID)
+	if err != nil || preferences == nil {
+		if err != nil {
+			log.Printf("Error getting preferences for %q, using defaults: %v\n", transportID, err)
+		}
+		return userDomain.DefaultPreferences(transportID)
+	}
+	return preferences
+}
+
+// digestMode returns whether the user gets notifications in the debts digest instead of immediately.
+// Without a scheduled debts digest the digest mode isn't available, and notifications are sent immediately.
+func (h *Service) digestMode(preferences *userDomain.Preferences) bool {
+	return h.debtsDigestSchedule != nil && preferences.NotificationMode == userDomain.NotificationModeDigest
+}
+
+func (h *Service) buildPreferencesMessage(preferences *userDomain.Preferences) string {
+	var sb strings.Builder
+	sb.WriteString("Your Bolt settings:\n")
+
+	reminders := fmt.Sprintf("every %s (default)", h.cfg.DebtReminderInterval)
+	if preferences.ReminderInterval > 0 {
+		reminders = fmt.Sprintf("every %s", preferences.ReminderInterval)
+	}
+	sb.WriteString(fmt.Sprintf("• Debt reminders (`%s`): %s\n", SettingReminders, reminders))
+
+	quietHours := "off"
+	if preferences.QuietHoursStart != preferences.QuietHoursEnd {
+		quietHours = fmt.Sprintf("%02d:00-%02d:00", preferences.QuietHoursStart, preferences.QuietHoursEnd)
+	}
+	sb.WriteString(fmt.Sprintf("• Quiet hours (`%s`): %s\n", SettingQuietHours, quietHours))
+
+	paidNotifications := "off"
+	if preferences.HostPaidNotifications {
+		paidNotifications = "on"
+	}
+	sb.WriteString(fmt.Sprintf("• Notify me as a host when someone marks themselves as paid (`%s`): %s\n", SettingPaidNotifications, paidNotifications))
+	mode := preferences.NotificationMode.String()
+	if preferences.NotificationMode == userDomain.NotificationModeDigest && !h.digestMode(preferences) {
+		mode += " (no digest is scheduled, so notifications are immediate)"
+	}
+	sb.WriteString(fmt.Sprintf("• Notifications mode (`%s`): %s\n", SettingNotificationMode, mode))
+
+	return sb.String()
+}
+
+func (h *Service) HandleShowPreferences(transportID string) (string, error) {
+	preferences, err := h.userStore.GetPreferences(context.Background(), transportID)
+	if err != nil {
+		return "", fmt.Errorf("get preferences: %w", err)
+	}
+	return h.buildPreferencesMessage(preferences), nil
+}
+
+func parseQuietHours(value string) (start int, end int, err error) {
+	switch value {
+	case "off":
+		return 0, 0, nil
+	case "default":
+		return userDomain.DefaultQuietHoursStart, userDomain.DefaultQuietHoursEnd, nil
+	}
+
+	splitted := strings.Split(value, "-")
+	if len(splitted) != 2 {
+		return 0, 0, fmt.Errorf("%w: quiet hours should be in <from>-<to> format (for example 21-9)", ErrInvalidSetting)
+	}
+	start, err = strconv.Atoi(splitted[0])
+	if err != nil || start < 0 || start > 23 {
+		return 0, 0, fmt.Errorf("%w: %q is not a valid hour", ErrInvalidSetting, splitted[0])
+	}
+	end, err = strconv.Atoi(splitted[1])
+	if err != nil || end < 0 || end > 23 {
+		return 0, 0, fmt.Errorf("%w: %q is not a valid hour", ErrInvalidSetting, splitted[1])
+	}
+	return start, end, nil
+}
+
+func parseOnOff(value string) (bool, error) {
+	switch value {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: expected on or off but got %q", ErrInvalidSetting, value)
+}
+
+func (h *Service) HandleSetPreference(transportID, setting, value string) (string, error) {
+	preferences, err := h.userStore.GetPreferences(context.Background(), transportID)
+	if err != nil {
+		return "", fmt.Errorf("get preferences: %w", err)
+	}
+
+	switch setting {
+	case SettingReminders:
+		if value == "default" {
+			preferences.ReminderInterval = 0
+			break
+		}
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return "", fmt.Errorf("%w: %q is not a valid duration (for example 6h)", ErrInvalidSetting, value)
+		}
+		if interval < h.cfg.DebtReminderInterval {
+			return "", fmt.Errorf("%w: reminders can't be sent more often than every %s", ErrInvalidSetting, h.cfg.DebtReminderInterval)
+		}
+		preferences.ReminderInterval = interval
+	case SettingQuietHours:
+		preferences.QuietHoursStart, preferences.QuietHoursEnd, err = parseQuietHours(value)
+		if err != nil {
+			return "", err
+		}
+	case SettingPaidNotifications:
+		preferences.HostPaidNotifications, err = parseOnOff(value)
+		if err != nil {
+			return "", err
+		}
+	case SettingNotificationMode:
+		preferences.NotificationMode, err = userDomain.ParseNotificationMode(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidSetting, err)
+		}
+		if preferences.NotificationMode == userDomain.NotificationModeDigest && h.debtsDigestSchedule == nil {
+			return "", fmt.Errorf("%w: the digest mode isn't available, no debts digest is scheduled", ErrInvalidSetting)
+		}
+	default:
+		return "", fmt.Errorf("%w: unknown setting %q", ErrInvalidSetting, setting)
+	}
+
+	if err = h.userStore.SavePreferences(context.Background(), preferences); err != nil {
+		return "", fmt.Errorf("save preferences: %w", err)
+	}
+	return "OK, saved.\n" + h.buildPreferencesMessage(preferences), nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrPromptExpired is returned when answering a prompt which was already answered, timed out, or was sent before Bolt restarted (pending prompts are kept in memory)
+	ErrPromptExpired = errors.New("prompt expired")
+	// ErrPromptNotForUser is returned when a prompt is answered by a user other than its recipient
+	ErrPromptNotForUser = errors.New("prompt isn't for the user")
+)
+
+// Choice is a single possible answer of a prompt
+type Choice struct {
+	ID   string
+	Text string
+}
+
+// ChoicePrompt is a question with predefined answers, shown just to a single user
+type ChoicePrompt struct {
+	ID      string
+	Text    string
+	Choices []Choice
+}
+
+// promptHandler handles the answer of a prompt, returning the text which should replace the prompt
+type promptHandler func(choiceID, fromTransportID string) (string, error)
+
+type pendingPrompt struct {
+	recipient string
+	handler   promptHandler
+}
+
+// sendPrompt sends the prompt to the recipient in the receiver (in the thread of messageID), the handler is called once it's answered
+func (h *Service) sendPrompt(receiver, recipient, messageID string, prompt *ChoicePrompt, ttl time.Duration, handler promptHandler) error {
+	prompt.ID = uuid.NewString()
+	h.pendingPrompts.Store(prompt.ID, &pendingPrompt{recipient: recipient, handler: handler})
+	time.AfterFunc(ttl, func() {
+		h.pendingPrompts.Delete(prompt.ID)
+	})
+
+	if err := h.eventNotification.SendPrompt(receiver, recipient, messageID, prompt); err != nil {
+		h.pendingPrompts.Delete(prompt.ID)
+		return fmt.Errorf("send prompt: %w", err)
+	}
+	return nil
+}
+
+// HandlePromptAnswer handles a choice made in a prompt, returning the text which should replace the prompt.
+// Only the recipient of the prompt can answer it.
+func (h *Service) HandlePromptAnswer(promptID, choiceID, fromTransportID string) (string, error) {
+	value, ok := h.pendingPrompts.Load(promptID)
+	if !ok {
+		return "", ErrPromptExpired
+	}
+	if value.(*pendingPrompt).recipient != fromTransportID {
+		return "", ErrPromptNotForUser
+	}
+
+	// Deleting it before handling, so answering twice at the same time won't be handled twice
+	if _, ok = h.pendingPrompts.LoadAndDelete(promptID); !ok {
+		return "", ErrPromptExpired
+	}
+	return value.(*pendingPrompt).handler(choiceID, fromTransportID)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	debtDomain "github.com/oriser/bolt/debt"
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const promptTestOrderID = "order-1"
+
+var (
+	promptTestHost      = &userDomain.User{ID: "3", FullName: "Yossi Host", TransportID: "U3"}
+	promptTestDanaLevi  = &userDomain.User{ID: "1", FullName: "Dana Levi", TransportID: "U1"}
+	promptTestDanaCohen = &userDomain.User{ID: "U4", FullName: "Dana Cohen", TransportID: "U4"}
+)
+
+func newPromptTestService(ttl time.Duration) (*Service, *fakeUserStore, *fakeDebtStore, *fakeNotification) {
+	userStore := &fakeUserStore{
+		users:     []*userDomain.User{promptTestHost, promptTestDanaLevi, promptTestDanaCohen},
+		directory: map[string]bool{"U4": true},
+	}
+	debtStore := &fakeDebtStore{}
+	notification := &fakeNotification{}
+	h := &Service{userStore: userStore, woltMatchStore: userStore, debtStore: debtStore, eventNotification: notification}
+	// The order's debts are already tracked, so answering won't start reminding about them
+	h.debtWorkers.Store(promptTestOrderID, nil)
+
+	rates := GroupRate{
+		HostWoltUser: "Yossi Host",
+		HostUser:     promptTestHost,
+		Rates: []Rate{
+			{WoltName: "Yossi Host", User: promptTestHost, Amount: 30},
+			{
+				WoltName:   "Dana",
+				WoltUserID: "wolt-dana",
+				Amount:     42,
+				Candidates: []*userDomain.Candidate{{User: promptTestDanaLevi, Score: 80}, {User: promptTestDanaCohen, Score: 70}},
+			},
+			// Participants without any candidate aren't asked about
+			{WoltName: "Tom", WoltUserID: "wolt-tom", Amount: 20},
+		},
+	}
+	settings := &channelSettings{debtTracking: true, debtMaximumDuration: ttl, debtReminderInterval: time.Hour}
+	h.askAboutUncertainParticipants(LinksRequest{Channel: "C1", MessageID: "M1", User: "U2"}, promptTestOrderID, rates, settings)
+
+	return h, userStore, debtStore, notification
+}
+
+// takePromptID returns the ID of the single prompt sent, after checking who it was sent to
+func takePromptID(t *testing.T, notification *fakeNotification) string {
+	prompts := notification.takePrompts()
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "C1", prompts[0].receiver)
+	assert.Equal(t, "U3", prompts[0].recipient, "the host should be asked")
+	assert.Equal(t, "M1", prompts[0].messageID)
+	assert.Equal(t, "Is Wolt participant \"Dana\" one of these?", prompts[0].prompt.Text)
+	assert.Equal(t, []Choice{{ID: "0", Text: "Dana Levi"}, {ID: "1", Text: "Dana Cohen"}, {ID: noneOfTheseChoice, Text: "None of these"}}, prompts[0].prompt.Choices)
+	return prompts[0].prompt.ID
+}
+
+func TestPromptConfirm(t *testing.T) {
+	h, userStore, debtStore, notification := newPromptTestService(time.Hour)
+	promptID := takePromptID(t, notification)
+
+	response, err := h.HandlePromptAnswer(promptID, "1", "U3")
+	require.NoError(t, err)
+	assert.Equal(t, "Thanks! I'll remember that Wolt participant \"Dana\" is <@U4>. I'll track the payment as well.", response)
+	assert.Equal(t, "U4", userStore.woltMatches["wolt-dana"])
+	assert.Empty(t, userStore.aliases, "aliases are saved just for custom users")
+
+	require.Len(t, debtStore.debts, 1)
+	assert.Equal(t, &debtDomain.Debt{
+		ID:                   debtStore.debts[0].ID,
+		BorrowerID:           "U4",
+		LenderID:             "3",
+		OrderID:              promptTestOrderID,
+		Amount:               42,
+		InitiatedTransportID: "C1",
+		MessageID:            "M1",
+		CreatedAt:            debtStore.debts[0].CreatedAt,
+	}, debtStore.debts[0])
+	assert.Equal(t, []sentMessage{{
+		receiver:  "C1",
+		text:      "<@U4>, you should pay 42.00 nis to <@U3> for Wolt order ID order-1. When you pay, react with :" + MarkAsPaidReaction + ": to the rates message.",
+		messageID: "M1",
+	}}, notification.takeMessages())
+
+	// Every prompt is answered once
+	_, err = h.HandlePromptAnswer(promptID, "0", "U3")
+	assert.True(t, errors.Is(err, ErrPromptExpired), "expected prompt expired but got %v", err)
+	assert.Len(t, debtStore.debts, 1)
+}
+
+func TestPromptConfirmCustomUser(t *testing.T) {
+	h, userStore, _, notification := newPromptTestService(time.Hour)
+	h.debtStore = nil
+
+	response, err := h.HandlePromptAnswer(takePromptID(t, notification), "0", "U3")
+	require.NoError(t, err)
+	assert.Equal(t, "Thanks! I'll remember that Wolt participant \"Dana\" is <@U1>.", response)
+	assert.Equal(t, "U1", userStore.woltMatches["wolt-dana"])
+	assert.Equal(t, map[string]string{"Dana": "1"}, userStore.aliases, "the Wolt name should find the custom user from now on")
+	assert.Empty(t, notification.takeMessages())
+}
+
+func TestPromptReject(t *testing.T) {
+	h, userStore, debtStore, notification := newPromptTestService(time.Hour)
+
+	response, err := h.HandlePromptAnswer(takePromptID(t, notification), noneOfTheseChoice, "U3")
+	require.NoError(t, err)
+	assert.Equal(t, "OK, I won't track \"Dana\" payment.", response)
+	assert.Empty(t, userStore.woltMatches)
+	assert.Empty(t, userStore.aliases)
+	assert.Empty(t, debtStore.debts)
+	assert.Empty(t, notification.takeMessages())
+}
+
+func TestPromptWrongRecipient(t *testing.T) {
+	h, userStore, debtStore, notification := newPromptTestService(time.Hour)
+	promptID := takePromptID(t, notification)
+
+	_, err := h.HandlePromptAnswer(promptID, "0", "U1")
+	assert.True(t, errors.Is(err, ErrPromptNotForUser), "expected prompt not for user but got %v", err)
+	assert.Empty(t, userStore.woltMatches)
+	assert.Empty(t, debtStore.debts)
+
+	// The recipient can still answer it
+	_, err = h.HandlePromptAnswer(promptID, noneOfTheseChoice, "U3")
+	assert.NoError(t, err)
+}
+
+func TestPromptUnknownChoice(t *testing.T) {
+	h, userStore, _, notification := newPromptTestService(time.Hour)
+
+	_, err := h.HandlePromptAnswer(takePromptID(t, notification), "7", "U3")
+	assert.EqualError(t, err, "unknown choice \"7\"")
+	assert.Empty(t, userStore.woltMatches)
+}
+
+func TestPromptTimeout(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	h, userStore, debtStore, notification := newPromptTestService(ttl)
+	promptID := takePromptID(t, notification)
+
+	time.Sleep(2 * ttl)
+	_, err := h.HandlePromptAnswer(promptID, "1", "U3")
+	assert.True(t, errors.Is(err, ErrPromptExpired), "expected prompt expired but got %v", err)
+	assert.Empty(t, userStore.woltMatches)
+	assert.Empty(t, debtStore.debts)
+
+	_, err = h.HandlePromptAnswer("unknown-prompt", "1", "U3")
+	assert.True(t, errors.Is(err, ErrPromptExpired), "expected prompt expired but got %v", err)
+}
+
+func TestPromptRecipientWithoutHost(t *testing.T) {
+	notification := &fakeNotification{}
+	h := &Service{eventNotification: notification}
+	rates := GroupRate{
+		HostWoltUser: "Someone",
+		Rates:        []Rate{{WoltName: "Dana", Candidates: []*userDomain.Candidate{{User: promptTestDanaLevi, Score: 80}}}},
+	}
+	h.askAboutUncertainParticipants(LinksRequest{Channel: "C1", MessageID: "M1", User: "U2"}, promptTestOrderID, rates, &channelSettings{debtMaximumDuration: time.Hour})
+
+	prompts := notification.takePrompts()
+	require.Len(t, prompts, 1)
+	assert.Equal(t, "U2", prompts[0].recipient, "whoever shared the link should be asked when the host is unknown")
+}
@@ -7,8 +7,10 @@ import (
 	"log"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/oriser/bolt/channel"
 	userDomain "github.com/oriser/bolt/user"
 	"github.com/oriser/regroup"
 )
@@ -28,9 +30,11 @@ type ParsedWoltGroupID struct {
 }
 
 type Rate struct {
-	WoltName string
-	User     *userDomain.User
-	Amount   float64
+	WoltName   string
+	WoltUserID string
+	User       *userDomain.User
+	Amount     float64
+	Candidates []*userDomain.Candidate // Possible users when it's not certain which one is the participant
 }
 
 type GroupRate struct {
@@ -40,7 +44,7 @@ type GroupRate struct {
 	DeliveryRate int
 }
 
-func getSortedKeys(m map[string]float64) []string {
+func getSortedKeys[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for key := range m {
 		keys = append(keys, key)
@@ -50,73 +54,112 @@ func getSortedKeys(m map[string]float64) []string {
 }
 
 func (h *Service) HandleLinkMessage(req LinksRequest) (string, error) {
-	// handle just one link in a message
-	groupID := h.getWoltGroupID(req.Links)
-	if groupID == nil {
+	groupIDs := h.getWoltGroupIDs(req.Links)
+	venueSlugs := h.getWoltVenueSlugs(req.Links)
+	if len(groupIDs) == 0 && len(venueSlugs) == 0 {
 		log.Printf("No wolt links found (%+v)", req.Links)
 		return "", nil
 	}
 
-	if _, ok := h.currentlyWorkingOrders.Load(groupID.ID); ok {
-		log.Println("Already working on order", groupID.ID)
+	for _, slug := range venueSlugs {
+		h.handleVenueLink(req, slug)
+	}
+
+	newGroupIDs := make([]string, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		if _, loaded := h.currentlyWorkingOrders.LoadOrStore(groupID, nil); loaded {
+			log.Println("Already working on order", groupID)
+			continue
+		}
+		newGroupIDs = append(newGroupIDs, groupID)
+	}
+	if len(newGroupIDs) == 0 {
 		return "", nil
 	}
-	h.currentlyWorkingOrders.Store(groupID.ID, nil)
-	defer h.currentlyWorkingOrders.Delete(groupID.ID)
 
 	err := h.eventNotification.AddReaction(req.Channel, req.MessageID, h.cfg.JoinedOrderEmoji)
 	if err != nil {
+		for _, groupID := range newGroupIDs {
+			h.currentlyWorkingOrders.Delete(groupID)
+		}
 		return "", errWontJoin
 	}
 
-	groupRate, err := h.getRateForGroup(req.Channel, groupID.ID, req.MessageID)
+	// Every group order in the message is tracked independently
+	errs := make([]error, len(newGroupIDs))
+	var wg sync.WaitGroup
+	for i, groupID := range newGroupIDs {
+		wg.Add(1)
+		go func(i int, groupID string) {
+			defer wg.Done()
+			defer h.currentlyWorkingOrders.Delete(groupID)
+			errs[i] = h.handleGroupOrder(req, groupID)
+		}(i, groupID)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return "", fmt.Errorf("group %s: %w", newGroupIDs[i], err)
+		}
+	}
+	return "", nil
+}
+
+func (h *Service) handleGroupOrder(req LinksRequest, groupID string) error {
+	settings := h.channelSettings(req.Channel)
+	groupRate, err := h.getRateForGroup(req.Channel, groupID, req.MessageID, settings)
 	if err != nil {
 		if errors.Is(err, errNotInTime) {
-			return "", nil
+			return nil
 		}
 		if strings.Contains(err.Error(), "order canceled") {
-			_, _ = h.informEvent(req.Channel, fmt.Sprintf("Order for group ID %s was canceled", groupID.ID), "", req.MessageID)
-			return "", nil
+			_, _ = h.informEvent(req.Channel, fmt.Sprintf("Order for group ID %s was canceled", groupID), "", req.MessageID)
+			return nil
 		}
 		if strings.Contains(err.Error(), "context canceled while waiting") {
 			_, _ = h.informEvent(req.Channel, "Timed out waiting for order to be ready", "", req.MessageID)
-			return "", nil
+			return nil
 		}
-		log.Printf("Error getting rate for group %s: %v\n", groupID.ID, err)
-		_, _ = h.informEvent(req.Channel, fmt.Sprintf("I had an error getting rate for group ID %s", groupID.ID), "", req.MessageID)
-		return "", nil
+		log.Printf("Error getting rate for group %s: %v\n", groupID, err)
+		_, _ = h.informEvent(req.Channel, fmt.Sprintf("I had an error getting rate for group ID %s", groupID), "", req.MessageID)
+		return nil
 	}
 
-	order, _ := h.currentlyWorkingOrders.Load(groupID.ID)
+	order, _ := h.currentlyWorkingOrders.Load(groupID)
 	if order == nil {
-		return "", fmt.Errorf("order %s not initialized in map", groupID.ID)
+		return fmt.Errorf("order %s not initialized in map", groupID)
 	}
 
-	ratesMessage := h.buildRatesMessage(groupRate, groupID.ID)
+	ratesMessage := h.buildRatesMessage(groupRate, groupID)
 	order.(*groupOrder).detailsMessageId, err = h.informEvent(req.Channel, ratesMessage, MarkAsPaidReaction, req.MessageID)
 	if err != nil {
-		return "", fmt.Errorf("failed sending details message: %w", err)
+		return fmt.Errorf("failed sending details message: %w", err)
 	}
 
-	if err := h.addDebts(req.Channel, groupID.ID, groupRate, req.MessageID); err != nil {
+	if err := h.addDebts(req.Channel, groupID, groupRate, req.MessageID, settings); err != nil {
 		log.Println(fmt.Sprintf("Error adding debts: %s", err.Error()))
 		_, _ = h.informEvent(req.Channel, "I had an error adding debts, I won't track this order", "", req.MessageID)
 	}
+	h.askAboutUncertainParticipants(req, groupID, groupRate, settings)
 
 	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.OrderDoneTimeout)
 	defer cancel()
-	if err = h.monitorDelivery(req.Channel, order.(*groupOrder), ctx, h.cfg.WaitBetweenStatusCheck, req.MessageID, ratesMessage); err != nil {
+	if err = h.monitorDelivery(req.Channel, order.(*groupOrder), ctx, req.MessageID, ratesMessage); err != nil {
 		if strings.Contains(err.Error(), "context canceled while waiting") {
 			_, _ = h.informEvent(req.Channel, "Timed out waiting for order to be done", "", req.MessageID)
-			return "", nil
+			return nil
 		}
-		return "", fmt.Errorf("error in waiting for order to finish: %w", err)
+		return fmt.Errorf("error in waiting for order to finish: %w", err)
 	}
 
-	return "", nil
+	return nil
 }
 
-func (h *Service) getWoltGroupID(links []Link) *ParsedWoltGroupID {
+// getWoltGroupIDs returns the IDs of all the group orders linked, without duplicates
+func (h *Service) getWoltGroupIDs(links []Link) []string {
+	groupIDs := make([]string, 0)
+	seen := make(map[string]bool)
 	for _, link := range links {
 		if link.Domain != "wolt.com" {
 			continue
@@ -130,12 +173,15 @@ func (h *Service) getWoltGroupID(links []Link) *ParsedWoltGroupID {
 			continue
 		}
 
-		return parsedWoltLink
+		if !seen[parsedWoltLink.ID] {
+			seen[parsedWoltLink.ID] = true
+			groupIDs = append(groupIDs, parsedWoltLink.ID)
+		}
 	}
-	return nil
+	return groupIDs
 }
 
-func (h *Service) buildGroupRates(woltRates map[string]float64, host string, deliveryRate int) GroupRate {
+func (h *Service) buildGroupRates(woltRates map[string]float64, host string, deliveryRate int, woltUserIDs, emails map[string]string) GroupRate {
 	if _, ok := woltRates[host]; !ok {
 		// The host didn't take anything, so he won't be included in the rates, add it here just to fetch his user
 		woltRates[host] = 0.0
@@ -149,28 +195,21 @@ func (h *Service) buildGroupRates(woltRates map[string]float64, host string, del
 
 	for i, person := range sortedKeys {
 		groupRate.Rates[i] = Rate{
-			WoltName: person,
-			User:     nil,
-			Amount:   woltRates[person],
+			WoltName:   person,
+			WoltUserID: woltUserIDs[person],
+			User:       nil,
+			Amount:     woltRates[person],
 		}
-		users, err := h.userStore.ListUsers(context.Background(), userDomain.ListFilter{Names: []string{person}})
-		if err != nil {
-			log.Printf("Error getting user %s from storage: %v\n", person, err)
-			continue
-		}
-		if len(users) == 0 {
-			log.Printf("User not found %s\n", person)
-			continue
-		}
-		if len(users) != 1 {
-			log.Printf("More than one user for %s. Taking first: %#v\n", person, users)
+		user, candidates := h.findUser(context.Background(), person, woltUserIDs[person], h.participantEmail(person, emails[person]))
+		if user == nil {
+			groupRate.Rates[i].Candidates = candidates
 			continue
 		}
 
 		if person == host {
-			groupRate.HostUser = users[0]
+			groupRate.HostUser = user
 		}
-		groupRate.Rates[i].User = users[0]
+		groupRate.Rates[i].User = user
 	}
 
 	return groupRate
@@ -208,8 +247,8 @@ func (h *Service) buildRatesMessage(groupRate GroupRate, groupID string) string
 	return sb.String()
 }
 
-func (h *Service) shouldHandleOrder() bool {
-	if h.dontJoinAfter.IsZero() {
+func (h *Service) shouldHandleOrder(dontJoinAfter time.Time) bool {
+	if dontJoinAfter.IsZero() {
 		return true
 	}
 
@@ -218,8 +257,8 @@ func (h *Service) shouldHandleOrder() bool {
 		currentTime = currentTime.In(h.dontJoinAfterTZ)
 	}
 
-	if (currentTime.Hour() > h.dontJoinAfter.Hour()) ||
-		(currentTime.Hour() == h.dontJoinAfter.Hour() && currentTime.Minute() >= h.dontJoinAfter.Minute()) {
+	if (currentTime.Hour() > dontJoinAfter.Hour()) ||
+		(currentTime.Hour() == dontJoinAfter.Hour() && currentTime.Minute() >= dontJoinAfter.Minute()) {
 		return false
 	}
 
@@ -227,7 +266,7 @@ func (h *Service) shouldHandleOrder() bool {
 }
 
 func (h *Service) saveOrderAsync(order *groupOrder, groupRate GroupRate, receiver string) {
-	domainOrder, err := order.ToOrder(groupRate.Rates, receiver)
+	domainOrder, err := order.ToOrder(context.Background(), groupRate.Rates, receiver)
 	if err != nil {
 		log.Printf("Error converting order %q: %v\n", order.id, err)
 		return
@@ -239,8 +278,8 @@ func (h *Service) saveOrderAsync(order *groupOrder, groupRate GroupRate, receive
 
 }
 
-func (h *Service) getRateForGroup(receiver, groupID, messageID string) (groupRate GroupRate, err error) {
-	shouldHandleOrder := h.shouldHandleOrder()
+func (h *Service) getRateForGroup(receiver, groupID, messageID string, settings *channelSettings) (groupRate GroupRate, err error) {
+	shouldHandleOrder := h.shouldHandleOrder(settings.dontJoinAfter)
 
 	if !shouldHandleOrder {
 		_, err := h.informEvent(receiver, "It's too late for me... I won't track prices for this order :sleeping:", "", messageID)
@@ -249,7 +288,10 @@ func (h *Service) getRateForGroup(receiver, groupID, messageID string) (groupRat
 		}
 	}
 
-	order, err := h.joinGroupOrder(groupID)
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.TimeoutForReady)
+	defer cancel()
+
+	order, err := h.joinGroupOrder(ctx, receiver, groupID, messageID, settings)
 	if err != nil {
 		_, _ = h.informEvent(receiver, "I had an error joining the order", "", messageID)
 		return GroupRate{}, fmt.Errorf("join group order: %w", err)
@@ -260,16 +302,17 @@ func (h *Service) getRateForGroup(receiver, groupID, messageID string) (groupRat
 		go h.saveOrderAsync(order, groupRate, receiver)
 	}()
 
-	if err = order.MarkAsReady(); err != nil {
+	if err = order.MarkAsReady(ctx); err != nil {
 		return GroupRate{}, fmt.Errorf("mark as ready in group: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.TimeoutForReady)
-	defer cancel()
-
 	monitorCtx, monitorCancel := context.WithCancel(ctx)
 	go h.monitorVenue(monitorCtx, order, receiver, messageID)
-	if err = h.WaitUntilFinished(order, ctx); err != nil {
+	var roster *liveRoster
+	if settings.liveRoster {
+		roster = newLiveRoster(receiver, messageID)
+	}
+	if err = h.WaitUntilFinished(order, ctx, roster); err != nil {
 		monitorCancel()
 		return GroupRate{}, fmt.Errorf("wait for group to finish: %w", err)
 	}
@@ -279,7 +322,7 @@ func (h *Service) getRateForGroup(receiver, groupID, messageID string) (groupRat
 		return GroupRate{}, errNotInTime
 	}
 
-	details, err := order.Details()
+	details, err := order.Details(ctx)
 	if err != nil {
 		return GroupRate{}, fmt.Errorf("get group details for calculating rates: %w", err)
 	}
@@ -289,16 +332,55 @@ func (h *Service) getRateForGroup(receiver, groupID, messageID string) (groupRat
 		return GroupRate{}, fmt.Errorf("rate by person: %w", err)
 	}
 
-	deliveryRate, err := order.CalculateDeliveryRate()
+	host := details.Host
+	var designatedHost *userDomain.User
+	if order.hostTransportID != "" {
+		// The Wolt host of groups Bolt created is Bolt's account, the participants pay to whoever checks out (and so does the delivery, if the host pays it)
+		if designatedHost, err = h.designatedHost(order.hostTransportID); err != nil {
+			log.Printf("Error getting the host of order %q: %v\n", groupID, err)
+		} else {
+			host = h.designatedHostWoltName(ctx, details, designatedHost)
+		}
+	}
+
+	deliveryRate, err := order.CalculateDeliveryRate(ctx)
 	if err != nil {
 		_, _ = h.informEvent(receiver, "I can't find the delivery rate, I'll publish the rates without including the delivery rate", "", messageID)
 		log.Println("Error getting delivery rate:", err)
-		return h.buildGroupRates(rates, details.Host, 0), nil
+		deliveryRate = 0
+	} else {
+		splitDeliveryRate(rates, host, deliveryRate, settings.splitStrategy)
+	}
+
+	groupRate = h.buildGroupRates(rates, host, deliveryRate, details.UserIDByName(), details.EmailByName())
+	if designatedHost != nil {
+		groupRate.HostUser = designatedHost
 	}
+	return groupRate, nil
+}
 
-	pricePerPerson := float64(deliveryRate) / float64(len(rates))
-	for person, rate := range rates {
-		rates[person] = rate + pricePerPerson
+// splitDeliveryRate adds to each participant's rate their part of the delivery rate according to the split strategy
+func splitDeliveryRate(rates map[string]float64, host string, deliveryRate int, strategy channel.SplitStrategy) {
+	switch strategy {
+	case channel.SplitHost:
+		rates[host] += float64(deliveryRate)
+	case channel.SplitProportional:
+		total := 0.0
+		for _, rate := range rates {
+			total += rate
+		}
+		if total > 0 {
+			for person, rate := range rates {
+				rates[person] = rate + float64(deliveryRate)*rate/total
+			}
+			return
+		}
+		// Nothing to be proportional to, falling back to an equal split
+		fallthrough
+	default:
+		pricePerPerson := float64(deliveryRate) / float64(len(rates))
+		for person, rate := range rates {
+			rates[person] = rate + pricePerPerson
+		}
 	}
-	return h.buildGroupRates(rates, details.Host, deliveryRate), nil
 }
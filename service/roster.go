@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/oriser/bolt/wolt"
+)
+
+// liveRoster is a message in the thread of an open group order, which is edited as participants join and fill their baskets
+type liveRoster struct {
+	receiver  string
+	threadID  string
+	messageID string
+	lastText  string
+}
+
+func newLiveRoster(receiver, threadID string) *liveRoster {
+	return &liveRoster{receiver: receiver, threadID: threadID}
+}
+
+// updateRoster sends the roster on the first update, and then edits it only when something changed
+func (h *Service) updateRoster(ctx context.Context, roster *liveRoster, order *groupOrder, details *wolt.OrderDetails) {
+	minimumOrder := 0
+	venue, err := order.Venue(ctx)
+	if err != nil {
+		log.Printf("Error getting venue of order %q for its roster: %v\n", order.id, err)
+	} else {
+		minimumOrder = venue.MinimumOrder()
+	}
+
+	text := buildRosterMessage(details, minimumOrder)
+	if text == roster.lastText {
+		return
+	}
+
+	if roster.messageID == "" {
+		roster.messageID, err = h.informEvent(roster.receiver, text, "", roster.threadID)
+		if err != nil {
+			log.Printf("Error sending roster of order %q: %v\n", order.id, err)
+			return
+		}
+	} else if err = h.eventNotification.EditMessage(roster.receiver, text, roster.messageID); err != nil {
+		log.Printf("Error editing roster of order %q: %v\n", order.id, err)
+		return
+	}
+	roster.lastText = text
+}
+
+func buildRosterMessage(details *wolt.OrderDetails, minimumOrder int) string {
+	participants := make([]wolt.Participant, 0, len(details.Participants))
+	for _, participant := range details.Participants {
+		if participant.Name() != wolt.BotName {
+			participants = append(participants, participant)
+		}
+	}
+	// Ready participants first, then the ones who are still choosing, then participants in other statuses, by name
+	rank := func(p *wolt.Participant) int {
+		switch p.Status {
+		case wolt.ParticipantStatusReady:
+			return 0
+		case wolt.ParticipantStatusJoined:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(participants, func(i, j int) bool {
+		if rank(&participants[i]) != rank(&participants[j]) {
+			return rank(&participants[i]) < rank(&participants[j])
+		}
+		return participants[i].Name() < participants[j].Name()
+	})
+
+	var sb strings.Builder
+	if details.Status == wolt.StatusActive {
+		sb.WriteString(fmt.Sprintf("*Who's in so far (%d):*\n", len(participants)))
+	} else {
+		sb.WriteString(fmt.Sprintf("*Who was in (%d):*\n", len(participants)))
+	}
+
+	total := 0.0
+	for i := range participants {
+		participant := &participants[i]
+		subtotal := participant.Subtotal()
+		total += subtotal
+		switch participant.Status {
+		case wolt.ParticipantStatusReady:
+			sb.WriteString(fmt.Sprintf(":white_check_mark: %s – ready, %.2f\n", participant.Name(), subtotal))
+		case wolt.ParticipantStatusJoined:
+			sb.WriteString(fmt.Sprintf(":hourglass_flowing_sand: %s – choosing, %.2f so far\n", participant.Name(), subtotal))
+		default:
+			sb.WriteString(fmt.Sprintf(":grey_question: %s – %s, %.2f\n", participant.Name(), participant.Status, subtotal))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\nSubtotal: %.2f", total))
+	if minimumOrder > 0 {
+		if total >= float64(minimumOrder) {
+			sb.WriteString(fmt.Sprintf(" (minimum order of %d reached)", minimumOrder))
+		} else {
+			sb.WriteString(fmt.Sprintf(" (%.2f more for the minimum order of %d)", float64(minimumOrder)-total, minimumOrder))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/oriser/bolt/wolt"
+	"github.com/stretchr/testify/assert"
+)
+
+func rosterParticipant(name, status string, itemAmounts ...float64) wolt.Participant {
+	participant := wolt.Participant{FirstName: name, Status: status}
+	for _, amount := range itemAmounts {
+		participant.Basket.Items = append(participant.Basket.Items, wolt.Item{BasePrice: amount, EndAmount: amount})
+	}
+	return participant
+}
+
+func TestBuildRosterMessage(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		status       wolt.Status
+		participants []wolt.Participant
+		minimumOrder int
+		expected     string
+	}{
+		{
+			name:   "Statuses",
+			status: wolt.StatusActive,
+			participants: []wolt.Participant{
+				rosterParticipant("Noa", wolt.ParticipantStatusJoined),
+				rosterParticipant(wolt.BotName, wolt.ParticipantStatusJoined),
+				rosterParticipant("Dana", wolt.ParticipantStatusJoined, 2500),
+				rosterParticipant("Tom", "pending"),
+				rosterParticipant("Yossi", wolt.ParticipantStatusReady, 3000, 1000),
+				rosterParticipant("Avi", wolt.ParticipantStatusReady),
+			},
+			expected: "*Who's in so far (5):*\n" +
+				":white_check_mark: Avi – ready, 0.00\n" +
+				":white_check_mark: Yossi – ready, 40.00\n" +
+				":hourglass_flowing_sand: Dana – choosing, 25.00 so far\n" +
+				":hourglass_flowing_sand: Noa – choosing, 0.00 so far\n" +
+				":grey_question: Tom – pending, 0.00\n" +
+				"\nSubtotal: 65.00",
+		},
+		{
+			name:         "Minimum order missing",
+			status:       wolt.StatusActive,
+			participants: []wolt.Participant{rosterParticipant("Dana", wolt.ParticipantStatusReady, 2500)},
+			minimumOrder: 50,
+			expected:     "*Who's in so far (1):*\n:white_check_mark: Dana – ready, 25.00\n\nSubtotal: 25.00 (25.00 more for the minimum order of 50)",
+		},
+		{
+			name:         "Minimum order reached",
+			status:       wolt.StatusActive,
+			participants: []wolt.Participant{rosterParticipant("Dana", wolt.ParticipantStatusReady, 5000)},
+			minimumOrder: 50,
+			expected:     "*Who's in so far (1):*\n:white_check_mark: Dana – ready, 50.00\n\nSubtotal: 50.00 (minimum order of 50 reached)",
+		},
+		{
+			name:         "Not active",
+			status:       wolt.StatusPurchased,
+			participants: []wolt.Participant{rosterParticipant("Dana", wolt.ParticipantStatusJoined, 1000)},
+			expected:     "*Who was in (1):*\n:hourglass_flowing_sand: Dana – choosing, 10.00 so far\n\nSubtotal: 10.00",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			details := &wolt.OrderDetails{Status: tc.status, Participants: tc.participants}
+			assert.Equal(t, tc.expected, buildRosterMessage(details, tc.minimumOrder))
+		})
+	}
+}
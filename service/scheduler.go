@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var weekdaysByName = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Schedule is a cron-like schedule, running at a specific time of the day at some (or all) weekdays.
+// It's defined as "[<weekday>[,<weekday>...]] HH:MM", for example "09:30" (every day) or "sun,thu 17:00".
+type Schedule struct {
+	weekdays map[time.Weekday]bool // Empty means every day
+	hour     int
+	minute   int
+	location *time.Location
+}
+
+func ParseSchedule(spec string, location *time.Location) (*Schedule, error) {
+	if location == nil {
+		location = time.Local
+	}
+
+	fields := strings.Fields(strings.ToLower(spec))
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("expected schedule in \"[<weekday>[,<weekday>...]] HH:MM\" format but got %q", spec)
+	}
+
+	timeOfDay, err := time.Parse("15:04", fields[len(fields)-1])
+	if err != nil {
+		return nil, fmt.Errorf("parsing time of day (HH:MM format): %w", err)
+	}
+
+	schedule := &Schedule{
+		weekdays: make(map[time.Weekday]bool),
+		hour:     timeOfDay.Hour(),
+		minute:   timeOfDay.Minute(),
+		location: location,
+	}
+
+	if len(fields) == 2 {
+		for _, name := range strings.Split(fields[0], ",") {
+			if len(name) < 3 {
+				return nil, fmt.Errorf("unknown weekday %q", name)
+			}
+			weekday, ok := weekdaysByName[name[:3]]
+			if !ok {
+				return nil, fmt.Errorf("unknown weekday %q", name)
+			}
+			schedule.weekdays[weekday] = true
+		}
+	}
+
+	return schedule, nil
+}
+
+// Next returns the first time the schedule should run after the given time
+func (s *Schedule) Next(after time.Time) time.Time {
+	after = after.In(s.location)
+	// Going over one week and one more day to cover today's time already passed
+	for days := 0; days <= 7; days++ {
+		candidate := time.Date(after.Year(), after.Month(), after.Day()+days, s.hour, s.minute, 0, 0, s.location)
+		if !candidate.After(after) {
+			continue
+		}
+		if len(s.weekdays) > 0 && !s.weekdays[candidate.Weekday()] {
+			continue
+		}
+		return candidate
+	}
+
+	// Can't really happen as every weekday is checked
+	return after.Add(24 * time.Hour)
+}
+
+type scheduledJob struct {
+	name     string
+	schedule *Schedule
+	run      func(ctx context.Context)
+}
+
+// Scheduler runs jobs according to their schedules until its context is done
+type Scheduler struct {
+	jobs []scheduledJob
+}
+
+func (s *Scheduler) Add(name string, schedule *Schedule, run func(ctx context.Context)) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, schedule: schedule, run: run})
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.runJob(ctx, job)
+	}
+	<-ctx.Done()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job scheduledJob) {
+	for {
+		next := job.schedule.Next(time.Now())
+		log.Printf("Next run of scheduled job %q is at %s\n", job.name, next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			job.run(ctx)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
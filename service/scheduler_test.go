@@ -0,0 +1,156 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		spec          string
+		expected      *Schedule
+		expectedError string
+	}{
+		{name: "Every day", spec: "09:30", expected: &Schedule{weekdays: map[time.Weekday]bool{}, hour: 9, minute: 30}},
+		{
+			name:     "Weekdays list",
+			spec:     "sun,thu 17:00",
+			expected: &Schedule{weekdays: map[time.Weekday]bool{time.Sunday: true, time.Thursday: true}, hour: 17},
+		},
+		{
+			name:     "Full weekday names in any case",
+			spec:     "  Monday,WEDNESDAY   8:05 ",
+			expected: &Schedule{weekdays: map[time.Weekday]bool{time.Monday: true, time.Wednesday: true}, hour: 8, minute: 5},
+		},
+		{name: "Empty", spec: " ", expectedError: "expected schedule"},
+		{name: "Too many fields", spec: "sun 10:00 extra", expectedError: "expected schedule"},
+		{name: "Bad time", spec: "25:00", expectedError: "parsing time of day"},
+		{name: "Time without minutes", spec: "sun 10", expectedError: "parsing time of day"},
+		{name: "Unknown weekday", spec: "sun,funday 10:00", expectedError: `unknown weekday "funday"`},
+		{name: "Short weekday", spec: "su 10:00", expectedError: `unknown weekday "su"`},
+		{name: "Empty weekday", spec: "sun,,mon 10:00", expectedError: `unknown weekday ""`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.spec, time.UTC)
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			tc.expected.location = time.UTC
+			assert.Equal(t, tc.expected, schedule)
+		})
+	}
+}
+
+func TestParseScheduleDefaultLocation(t *testing.T) {
+	schedule, err := ParseSchedule("10:00", nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, schedule.location)
+}
+
+func TestScheduleNext(t *testing.T) {
+	jerusalem, err := time.LoadLocation("Asia/Jerusalem")
+	require.NoError(t, err)
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		spec     string
+		location *time.Location
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Later today",
+			spec:     "10:00",
+			location: time.UTC,
+			after:    time.Date(2023, time.March, 1, 9, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Exactly at the time runs the next day",
+			spec:     "10:00",
+			location: time.UTC,
+			after:    time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.March, 2, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Passed today",
+			spec:     "10:00",
+			location: time.UTC,
+			after:    time.Date(2023, time.March, 31, 11, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.April, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Next weekday in the list",
+			spec:     "sun,thu 17:00",
+			location: time.UTC,
+			// Monday
+			after:    time.Date(2023, time.March, 6, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.March, 9, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekday rolls over to next week",
+			spec:     "mon 09:00",
+			location: time.UTC,
+			// Monday, after the time
+			after:    time.Date(2023, time.March, 6, 9, 30, 0, 0, time.UTC),
+			expected: time.Date(2023, time.March, 13, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekday rolls over the year",
+			spec:     "sun 10:00",
+			location: time.UTC,
+			// Saturday
+			after:    time.Date(2022, time.December, 31, 12, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.January, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "In the schedule's location",
+			spec:     "10:00",
+			location: jerusalem,
+			// 09:00 in Jerusalem (UTC+2)
+			after:    time.Date(2023, time.January, 10, 7, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.January, 10, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Weekday of the schedule's location",
+			spec:     "sun 01:00",
+			location: jerusalem,
+			// Saturday 23:30 UTC is already Sunday 01:30 in Jerusalem, so the next run is a week later
+			after:    time.Date(2023, time.January, 7, 23, 30, 0, 0, time.UTC),
+			expected: time.Date(2023, time.January, 14, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Across DST start",
+			spec:     "10:00",
+			location: london,
+			// Clocks go forward on March 26, 2023, so 10:00 moves from 10:00 UTC to 09:00 UTC
+			after:    time.Date(2023, time.March, 25, 11, 0, 0, 0, time.UTC),
+			expected: time.Date(2023, time.March, 26, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Across DST end",
+			spec:     "10:00",
+			location: london,
+			// Clocks go back on October 29, 2023, so 10:00 moves from 09:00 UTC to 10:00 UTC
+			after:    time.Date(2023, time.October, 28, 9, 30, 0, 0, time.UTC),
+			expected: time.Date(2023, time.October, 29, 10, 0, 0, 0, time.UTC),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.spec, tc.location)
+			require.NoError(t, err)
+			next := schedule.Next(tc.after)
+			assert.True(t, tc.expected.Equal(next), "expected %s but got %s", tc.expected, next)
+			assert.Equal(t, tc.location, next.Location())
+		})
+	}
+}
@@ -2,37 +2,77 @@ package service
 
 import (
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
+	"github.com/oriser/bolt/channel"
 	"github.com/oriser/bolt/debt"
 	"github.com/oriser/bolt/order"
+	"github.com/oriser/bolt/session"
 	"github.com/oriser/bolt/user"
+	"github.com/oriser/bolt/venue"
+	"github.com/oriser/bolt/wolt"
 )
 
 type EventNotification interface {
 	SendMessage(receiver, event, messageID string) (string, error)
 	EditMessage(receiver, event, messageID string) error
 	AddReaction(receiver, messageID, reaction string) error
+	PublishHome(receiver string, view *HomeView) error
+	// SendPrompt shows the prompt just to the recipient, in the thread of messageID in the receiver
+	SendPrompt(receiver, recipient, messageID string, prompt *ChoicePrompt) error
+	// UserChannels returns the channels the user is a member of
+	UserChannels(user string) ([]string, error)
 }
 
 type Config struct {
-	TimeoutForReady          time.Duration `env:"ORDER_READY_TIMEOUT" envDefault:"1h"`
-	OrderDoneTimeout         time.Duration `env:"ORDER_DONE_TIMEOUT" envDefault:"3h"`
-	TimeTillGetReadyMessage  time.Duration `env:"TIME_TILL_GET_READY_MESSAGE" envDefault:"7m"`
-	OrderDestinationEmoji    string        `env:"ORDER_DESTINATION_EMOJI" envDefault:"house"`
-	JoinedOrderEmoji         string        `env:"JOINED_ORDER_EMOJI" envDefault:"eyes"`
-	TimeoutForDeliveryRate   time.Duration `env:"GET_DELIVERY_RATE_TIMEOUT" envDefault:"10m"`
-	WaitBetweenStatusCheck   time.Duration `env:"WAIT_BETWEEN_STATUS_CHECK" envDefault:"20s"`
-	DebtReminderInterval     time.Duration `env:"DEBT_REMINDER_INTERVAL" envDefault:"3h"`
-	DebtMaximumDuration      time.Duration `env:"DEBT_MAXIMUM_DURATION" envDefault:"24h"`
-	DontJoinAfter            string        `env:"DONT_JOIN_AFTER"`
-	DontJoinAfterTZ          string        `env:"DONT_JOIN_AFTER_TZ"`
-	WoltBaseAddr             string        `env:"WOLT_BASE_ADDR" envDefault:"https://wolt.com"`
-	WoltApiBaseAddr          string        `env:"WOLT_API_BASE_ADDR" envDefault:"https://restaurant-api.wolt.com"`
-	WoltHTTPMaxRetryCount    int           `env:"WOLT_HTTP_MAX_RETRY_COUNT" envDefault:"5"`
-	WoltHTTPMinRetryDuration time.Duration `env:"WOLT_HTTP_MIN_RETRY_DURATION" envDefault:"1s"`
-	WoltHTTPMaxRetryDuration time.Duration `env:"WOLT_HTTP_MAX_RETRY_DURATION" envDefault:"30s"`
+	TimeoutForReady           time.Duration `env:"ORDER_READY_TIMEOUT" envDefault:"1h"`
+	OrderDoneTimeout          time.Duration `env:"ORDER_DONE_TIMEOUT" envDefault:"3h"`
+	TimeTillGetReadyMessage   time.Duration `env:"TIME_TILL_GET_READY_MESSAGE" envDefault:"7m"`
+	OrderDestinationEmoji     string        `env:"ORDER_DESTINATION_EMOJI" envDefault:"house"`
+	JoinedOrderEmoji          string        `env:"JOINED_ORDER_EMOJI" envDefault:"eyes"`
+	TimeoutForDeliveryRate    time.Duration `env:"GET_DELIVERY_RATE_TIMEOUT" envDefault:"10m"`
+	WaitBetweenStatusCheck    time.Duration `env:"WAIT_BETWEEN_STATUS_CHECK" envDefault:"20s"`
+	MinWaitBetweenStatusCheck time.Duration `env:"MIN_WAIT_BETWEEN_STATUS_CHECK" envDefault:"5s"`
+	MaxWaitBetweenStatusCheck time.Duration `env:"MAX_WAIT_BETWEEN_STATUS_CHECK" envDefault:"1m"`
+	StatusChecksPerSecond     float64       `env:"STATUS_CHECKS_PER_SECOND" envDefault:"5"` // Of all the orders and venues together, zero means no limit
+	DebtReminderInterval      time.Duration `env:"DEBT_REMINDER_INTERVAL" envDefault:"3h"`
+	DebtMaximumDuration       time.Duration `env:"DEBT_MAXIMUM_DURATION" envDefault:"24h"`
+	DontJoinAfter             string        `env:"DONT_JOIN_AFTER"`
+	DontJoinAfterTZ           string        `env:"DONT_JOIN_AFTER_TZ"`
+	WoltBaseAddr              string        `env:"WOLT_BASE_ADDR" envDefault:"https://wolt.com"`
+	WoltApiBaseAddr           string        `env:"WOLT_API_BASE_ADDR" envDefault:"https://restaurant-api.wolt.com"`
+	WoltHTTPMaxRetryCount     int           `env:"WOLT_HTTP_MAX_RETRY_COUNT" envDefault:"5"`
+	WoltHTTPMinRetryDuration  time.Duration `env:"WOLT_HTTP_MIN_RETRY_DURATION" envDefault:"1s"`
+	WoltHTTPMaxRetryDuration  time.Duration `env:"WOLT_HTTP_MAX_RETRY_DURATION" envDefault:"30s"`
+	WoltHTTPTimeout           time.Duration `env:"WOLT_HTTP_TIMEOUT" envDefault:"30s"`
+	WoltRateLimit             float64       `env:"WOLT_RATE_LIMIT" envDefault:"10"` // Requests per second, zero means no limit
+	WoltRateBurst             int           `env:"WOLT_RATE_BURST" envDefault:"20"`
+	WoltUserAgent             string        `env:"WOLT_USER_AGENT"` // Default is of a browser
+	WoltRecordDir             string        `env:"WOLT_RECORD_DIR"` // Saves sanitized requests to Wolt and their responses there, for replaying in tests
+	VenueCacheTTL             time.Duration `env:"VENUE_CACHE_TTL" envDefault:"1h"`
+	VenueStatusCacheTTL       time.Duration `env:"VENUE_STATUS_CACHE_TTL" envDefault:"15s"`
+	WoltAuthBaseAddr          string        `env:"WOLT_AUTH_BASE_ADDR" envDefault:"https://authentication.wolt.com"`
+	WoltSessionSecret         string        `env:"WOLT_SESSION_SECRET" json:"-"` // Encrypts the stored session of the Wolt account Bolt creates group orders with
+	DebtsDigestSchedule       string        `env:"DEBTS_DIGEST_SCHEDULE"`
+	ChannelDigestSchedule     string        `env:"CHANNEL_DIGEST_SCHEDULE"`
+	ChannelDigestPeriod       time.Duration `env:"CHANNEL_DIGEST_PERIOD" envDefault:"168h"`
+	DigestTZ                  string        `env:"DIGEST_TZ"`
+	OfficeLocation            string        `env:"OFFICE_LOCATION"` // <latitude>,<longitude> for estimating deliveries of linked venues
+	VenueWatchTimeout         time.Duration `env:"VENUE_WATCH_TIMEOUT" envDefault:"12h"`
+	LiveRoster                bool          `env:"LIVE_ROSTER" envDefault:"false"`
+	NudgeAfter                time.Duration `env:"NUDGE_AFTER"` // Zero means not nudging automatically
+	ChannelConfigPolicy       string        `env:"CHANNEL_CONFIG_POLICY" envDefault:"admins"`
+
+	// DeadlineReminders are how long before order deadlines to remind about them
+	DeadlineReminders []time.Duration `env:"DEADLINE_REMINDERS" envDefault:"15m,5m"`
+
+	// WoltHTTPHeaders are sent with every request to Wolt, overriding the default headers
+	WoltHTTPHeaders map[string]string `env:"WOLT_HTTP_HEADERS"`
+
+	// ParticipantEmails maps Wolt participant names to their emails, for matching users by email
+	ParticipantEmails map[string]string `env:"PARTICIPANT_EMAILS"`
 }
 
 type Service struct {
@@ -40,11 +80,28 @@ type Service struct {
 	eventNotification      EventNotification
 	currentlyWorkingOrders sync.Map
 	userStore              user.Store
+	woltMatchStore         user.WoltMatchStore
 	debtStore              debt.Store
 	orderStore             order.Store
+	channelStore           channel.Store
+	venueStore             venue.Store
 	selfID                 string
 	dontJoinAfter          time.Time
 	dontJoinAfterTZ        *time.Location
+	debtsDigestSchedule    *Schedule
+	channelDigestSchedule  *Schedule
+	officeLocation         *wolt.Coordinate
+	woltClient             *wolt.Client
+	poller                 *poller
+	woltSession            *wolt.SessionManager // Nil if there's no WOLT_SESSION_SECRET
+	homeViewers            sync.Map             // When users last opened their home view, by their transport IDs
+	pendingPrompts         sync.Map             // Prompt ID to the pending prompt, kept just in memory so prompts expire on restart
+	debtWorkers            sync.Map             // Order IDs with a running debt worker
+	venueWatches           sync.Map             // Watched venues, by the receiver and the venue's slug
+	createdGroups          sync.Map             // Group orders Bolt created and didn't start tracking yet, by their short ID
+
+	orderDeadlinesLock sync.Mutex
+	orderDeadlines     map[string]*orderDeadline // Deadlines of group orders, by the receiver and the message with the group link
 }
 
 type ReactionAddRequest struct {
@@ -53,6 +110,7 @@ type ReactionAddRequest struct {
 	Channel       string
 	MessageUserID string
 	MessageText   string
+	MessageID     string
 }
 
 type Link struct {
@@ -63,9 +121,10 @@ type LinksRequest struct {
 	Links     []Link
 	MessageID string
 	Channel   string
+	User      string // The user who shared the links
 }
 
-func New(cfg Config, userStore user.Store, debtStore debt.Store, orderStore order.Store, selfID string, eventNotification EventNotification) (*Service, error) {
+func New(cfg Config, userStore user.Store, woltMatchStore user.WoltMatchStore, debtStore debt.Store, orderStore order.Store, channelStore channel.Store, venueStore venue.Store, sessionStore session.Store, selfID string, eventNotification EventNotification) (*Service, error) {
 	var dontJoinAfter time.Time
 	var err error
 	if cfg.DontJoinAfter != "" {
@@ -82,18 +141,107 @@ func New(cfg Config, userStore user.Store, debtStore debt.Store, orderStore orde
 			return nil, fmt.Errorf("parsing DONT_JOIN_AFTER_TZ: %w", err)
 		}
 	}
+
+	var digestTZ *time.Location
+	if cfg.DigestTZ != "" {
+		digestTZ, err = time.LoadLocation(cfg.DigestTZ)
+		if err != nil {
+			return nil, fmt.Errorf("parsing DIGEST_TZ: %w", err)
+		}
+	}
+
+	var debtsDigestSchedule *Schedule
+	if cfg.DebtsDigestSchedule != "" {
+		debtsDigestSchedule, err = ParseSchedule(cfg.DebtsDigestSchedule, digestTZ)
+		if err != nil {
+			return nil, fmt.Errorf("parsing DEBTS_DIGEST_SCHEDULE: %w", err)
+		}
+	}
+
+	var channelDigestSchedule *Schedule
+	if cfg.ChannelDigestSchedule != "" {
+		channelDigestSchedule, err = ParseSchedule(cfg.ChannelDigestSchedule, digestTZ)
+		if err != nil {
+			return nil, fmt.Errorf("parsing CHANNEL_DIGEST_SCHEDULE: %w", err)
+		}
+	}
+
+	var officeLocation *wolt.Coordinate
+	if cfg.OfficeLocation != "" {
+		location, err := wolt.ParseCoordinate(cfg.OfficeLocation)
+		if err != nil {
+			return nil, fmt.Errorf("parsing OFFICE_LOCATION: %w", err)
+		}
+		officeLocation = &location
+	}
+
+	woltClient, err := NewWoltClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("new wolt client: %w", err)
+	}
+
+	var woltSession *wolt.SessionManager
+	if cfg.WoltSessionSecret != "" && sessionStore != nil {
+		woltSession, err = woltClient.NewSessionManager(sessionStore, cfg.WoltSessionSecret)
+		if err != nil {
+			return nil, fmt.Errorf("new wolt session manager: %w", err)
+		}
+	}
+
 	return &Service{
-		cfg:               cfg,
-		eventNotification: eventNotification,
-		userStore:         userStore,
-		debtStore:         debtStore,
-		orderStore:        orderStore,
-		selfID:            selfID,
-		dontJoinAfter:     dontJoinAfter,
-		dontJoinAfterTZ:   dontJoinAfterTZ,
+		cfg:                   cfg,
+		eventNotification:     eventNotification,
+		userStore:             userStore,
+		woltMatchStore:        woltMatchStore,
+		debtStore:             debtStore,
+		orderStore:            orderStore,
+		channelStore:          channelStore,
+		venueStore:            venueStore,
+		selfID:                selfID,
+		dontJoinAfter:         dontJoinAfter,
+		dontJoinAfterTZ:       dontJoinAfterTZ,
+		debtsDigestSchedule:   debtsDigestSchedule,
+		channelDigestSchedule: channelDigestSchedule,
+		officeLocation:        officeLocation,
+		woltClient:            woltClient,
+		poller:                newPoller(cfg.WaitBetweenStatusCheck, cfg.MinWaitBetweenStatusCheck, cfg.MaxWaitBetweenStatusCheck, cfg.StatusChecksPerSecond),
+		woltSession:           woltSession,
+		orderDeadlines:        make(map[string]*orderDeadline),
 	}, nil
 }
 
+// NewWoltClient creates the client all the requests to Wolt are sent with
+func NewWoltClient(cfg Config) (*wolt.Client, error) {
+	var recorder *wolt.Recorder
+	if cfg.WoltRecordDir != "" {
+		var err error
+		recorder, err = wolt.NewRecorder(cfg.WoltRecordDir)
+		if err != nil {
+			return nil, fmt.Errorf("new wolt recorder: %w", err)
+		}
+		log.Printf("Recording requests to Wolt in %s\n", recorder.Dir())
+	}
+
+	return wolt.NewClient(wolt.WoltAddr{
+		BaseAddr:     cfg.WoltBaseAddr,
+		APIBaseAddr:  cfg.WoltApiBaseAddr,
+		AuthBaseAddr: cfg.WoltAuthBaseAddr,
+	}, wolt.RetryConfig{
+		HTTPMaxRetries:       cfg.WoltHTTPMaxRetryCount,
+		HTTPMinRetryDuration: cfg.WoltHTTPMinRetryDuration,
+		HTTPMaxRetryDuration: cfg.WoltHTTPMaxRetryDuration,
+	}, wolt.ClientConfig{
+		UserAgent:      cfg.WoltUserAgent,
+		Headers:        cfg.WoltHTTPHeaders,
+		RequestTimeout: cfg.WoltHTTPTimeout,
+		RateLimit:      cfg.WoltRateLimit,
+		RateBurst:      cfg.WoltRateBurst,
+		VenueTTL:       cfg.VenueCacheTTL,
+		VenueStatusTTL: cfg.VenueStatusCacheTTL,
+		Recorder:       recorder,
+	})
+}
+
 func (h *Service) informEvent(receiver, event, reactionEmoji, initialMessageID string) (string, error) {
 	if h.eventNotification == nil {
 		return "", fmt.Errorf("nil eventNotification")
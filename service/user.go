@@ -2,12 +2,48 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
+	debtDomain "github.com/oriser/bolt/debt"
 	userDomain "github.com/oriser/bolt/user"
 	"github.com/slack-go/slack"
 )
 
+var (
+	// ErrBadUsersUsage is returned when the users command arguments don't match any of its usages
+	ErrBadUsersUsage = errors.New("bad users usage")
+	// ErrUsersNotAllowed is returned when someone who isn't Bolt's admin tries to manage the users
+	ErrUsersNotAllowed = errors.New("only admins can manage users")
+)
+
+// HandleUsersCommand lists, shows, removes and renames custom users and manages their aliases, by the arguments of the users command.
+// Just Bolt's admins can manage the users.
+func (h *Service) HandleUsersCommand(isAdmin bool, args []string) (string, error) {
+	if !isAdmin {
+		return "", ErrUsersNotAllowed
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		return h.HandleListUsers()
+	case len(args) == 2 && args[0] == "show":
+		return h.HandleShowUser(args[1])
+	case len(args) == 2 && args[0] == "remove":
+		return h.HandleRemoveUser(args[1])
+	case len(args) == 3 && args[0] == "rename":
+		return h.HandleRenameUser(args[1], args[2])
+	case len(args) == 4 && args[0] == "alias" && args[1] == "add":
+		return h.HandleAddAlias(args[2], args[3])
+	case len(args) == 3 && args[0] == "alias" && args[1] == "remove":
+		return h.HandleRemoveAlias(args[2])
+	default:
+		return "", ErrBadUsersUsage
+	}
+}
+
 func (h *Service) HandleAddUser(name string, user slack.User) error {
 	if err := h.userStore.AddUser(context.Background(), &userDomain.User{
 		FullName:           name,
@@ -21,3 +57,142 @@ func (h *Service) HandleAddUser(name string, user slack.User) error {
 	}
 	return nil
 }
+
+// customUser returns the custom user (added manually, not from an external directory) with exactly the given name
+func (h *Service) customUser(ctx context.Context, name string) (*userDomain.User, error) {
+	users, err := h.userStore.ListUsers(ctx, userDomain.ListFilter{Names: []string{name}, CustomOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+	for _, user := range users {
+		if user.FullName == name {
+			return user, nil
+		}
+	}
+	return nil, &userDomain.ErrNotFound{Name: name}
+}
+
+func (h *Service) HandleListUsers() (string, error) {
+	users, err := h.userStore.ListUsers(context.Background(), userDomain.ListFilter{CustomOnly: true})
+	if err != nil {
+		return "", fmt.Errorf("list users: %w", err)
+	}
+	if len(users) == 0 {
+		return "There are no custom users, add one using `/add-user`", nil
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].FullName < users[j].FullName
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Custom users (%d):\n", len(users)))
+	for _, user := range users {
+		sb.WriteString(fmt.Sprintf("• %q → <@%s>\n", user.FullName, user.TransportID))
+	}
+	return sb.String(), nil
+}
+
+func (h *Service) openDebtsOfUser(userID string) ([]*debtDomain.Debt, error) {
+	if h.debtStore == nil {
+		return nil, nil
+	}
+	return h.debtStore.ListDebts(debtDomain.ListFilter{BorrowerIDs: []string{userID}, LenderIDs: []string{userID}})
+}
+
+func (h *Service) HandleShowUser(name string) (string, error) {
+	user, err := h.customUser(context.Background(), name)
+	if err != nil {
+		return "", err
+	}
+
+	debts, err := h.openDebtsOfUser(user.ID)
+	if err != nil {
+		return "", fmt.Errorf("list debts: %w", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%q:\n", user.FullName))
+	sb.WriteString(fmt.Sprintf("• Slack user: <@%s>\n", user.TransportID))
+	if user.Email != "" {
+		sb.WriteString(fmt.Sprintf("• Email: %s\n", user.Email))
+	}
+	if user.Timezone != "" {
+		sb.WriteString(fmt.Sprintf("• Timezone: %s\n", user.Timezone))
+	}
+	aliases, err := h.userStore.ListAliases(context.Background(), user.ID)
+	if err != nil {
+		return "", fmt.Errorf("list aliases: %w", err)
+	}
+	if len(aliases) > 0 {
+		sb.WriteString(fmt.Sprintf("• Aliases: %s\n", strings.Join(aliases, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("• Open debts: %d\n", len(debts)))
+	sb.WriteString(fmt.Sprintf("• ID: %s\n", user.ID))
+	return sb.String(), nil
+}
+
+func (h *Service) HandleRemoveUser(name string) (string, error) {
+	ctx := context.Background()
+	user, err := h.customUser(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	// Debts are referencing the user ID, removing the user would leave them without a borrower or a lender
+	debts, err := h.openDebtsOfUser(user.ID)
+	if err != nil {
+		return "", fmt.Errorf("list debts: %w", err)
+	}
+	if len(debts) > 0 {
+		return fmt.Sprintf("I can't remove %q, there are still %d open debts of that user", user.FullName, len(debts)), nil
+	}
+
+	if err = h.userStore.DeleteUser(ctx, user.ID); err != nil {
+		return "", fmt.Errorf("delete user: %w", err)
+	}
+	return fmt.Sprintf("OK, I removed %q (<@%s>)", user.FullName, user.TransportID), nil
+}
+
+func (h *Service) HandleRenameUser(name, newName string) (string, error) {
+	if strings.TrimSpace(newName) == "" {
+		return "", fmt.Errorf("empty new name")
+	}
+
+	ctx := context.Background()
+	user, err := h.customUser(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	user.FullName = newName
+	if err = h.userStore.UpdateUser(ctx, user); err != nil {
+		return "", fmt.Errorf("update user: %w", err)
+	}
+	return fmt.Sprintf("OK, %q is now %q (<@%s>)", name, newName, user.TransportID), nil
+}
+
+// HandleAddAlias links another Wolt name to the custom user with the given name
+func (h *Service) HandleAddAlias(name, alias string) (string, error) {
+	if strings.TrimSpace(alias) == "" {
+		return "", fmt.Errorf("empty alias")
+	}
+
+	ctx := context.Background()
+	user, err := h.customUser(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	if err = h.userStore.AddAlias(ctx, user.ID, alias); err != nil {
+		return "", fmt.Errorf("add alias: %w", err)
+	}
+	return fmt.Sprintf("OK, Wolt user %q is now also matched to %q (<@%s>)", alias, user.FullName, user.TransportID), nil
+}
+
+func (h *Service) HandleRemoveAlias(alias string) (string, error) {
+	if err := h.userStore.RemoveAlias(context.Background(), alias); err != nil {
+		return "", fmt.Errorf("remove alias: %w", err)
+	}
+	return fmt.Sprintf("OK, I removed the alias %q", alias), nil
+}
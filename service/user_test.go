@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	debtDomain "github.com/oriser/bolt/debt"
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUsersTestService() (*Service, *fakeUserStore) {
+	userStore := &fakeUserStore{
+		users: []*userDomain.User{
+			{ID: "1", FullName: "Dana Levi", TransportID: "U1", Email: "dana@example.com"},
+			{ID: "2", FullName: "Noa Cohen", TransportID: "U2"},
+			{ID: "U3", FullName: "Yossi Host", TransportID: "U3"},
+		},
+		directory: map[string]bool{"U3": true},
+		aliases:   map[string]string{"Dana L": "1"},
+	}
+	return &Service{
+		cfg:       Config{DebtReminderInterval: 3 * time.Hour},
+		userStore: userStore,
+		debtStore: &fakeDebtStore{debts: []*debtDomain.Debt{{BorrowerID: "1", LenderID: "U3", OrderID: "order-1", Amount: 20}}},
+	}, userStore
+}
+
+func TestHandleUsersCommand(t *testing.T) {
+	var notFound *userDomain.ErrNotFound
+
+	for _, tc := range []struct {
+		name          string
+		isAdmin       bool
+		args          []string
+		expected      string
+		expectedError error
+		expectedAs    any
+	}{
+		{name: "Not admin", args: []string{"list"}, expectedError: ErrUsersNotAllowed},
+		{name: "Not admin removing", args: []string{"remove", "Noa Cohen"}, expectedError: ErrUsersNotAllowed},
+		{name: "Not admin with bad usage", args: []string{"list", "all"}, expectedError: ErrUsersNotAllowed},
+		{name: "No arguments", isAdmin: true, expectedError: ErrBadUsersUsage},
+		{name: "Too many arguments", isAdmin: true, args: []string{"list", "all"}, expectedError: ErrBadUsersUsage},
+		{name: "Rename without new name", isAdmin: true, args: []string{"rename", "Noa Cohen"}, expectedError: ErrBadUsersUsage},
+		{name: "Unknown alias command", isAdmin: true, args: []string{"alias", "rename", "Dana L", "Dana"}, expectedError: ErrBadUsersUsage},
+		{
+			name:     "List",
+			isAdmin:  true,
+			args:     []string{"list"},
+			expected: "Custom users (2):\n• \"Dana Levi\" → <@U1>\n• \"Noa Cohen\" → <@U2>\n",
+		},
+		{
+			name:    "Show",
+			isAdmin: true,
+			args:    []string{"show", "Dana Levi"},
+			expected: "\"Dana Levi\":\n• Slack user: <@U1>\n• Email: dana@example.com\n• Aliases: Dana L\n" +
+				"• Open debts: 1\n• ID: 1\n",
+		},
+		{name: "Show unknown user", isAdmin: true, args: []string{"show", "Nobody"}, expectedAs: &notFound},
+		{name: "Show user which isn't custom", isAdmin: true, args: []string{"show", "Yossi Host"}, expectedAs: &notFound},
+		{name: "Remove unknown user", isAdmin: true, args: []string{"remove", "Nobody"}, expectedAs: &notFound},
+		{name: "Rename unknown user", isAdmin: true, args: []string{"rename", "Nobody", "Somebody"}, expectedAs: &notFound},
+		{name: "Add alias to unknown user", isAdmin: true, args: []string{"alias", "add", "Nobody", "No one"}, expectedAs: &notFound},
+		{name: "Add alias to user which isn't custom", isAdmin: true, args: []string{"alias", "add", "Yossi Host", "Yossi"}, expectedAs: &notFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, userStore := newUsersTestService()
+
+			response, err := h.HandleUsersCommand(tc.isAdmin, tc.args)
+			switch {
+			case tc.expectedError != nil:
+				assert.True(t, errors.Is(err, tc.expectedError), "expected %v but got %v", tc.expectedError, err)
+			case tc.expectedAs != nil:
+				assert.True(t, errors.As(err, tc.expectedAs), "expected %T but got %v", tc.expectedAs, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, response)
+				return
+			}
+			assert.Len(t, userStore.users, 3, "users shouldn't change on errors")
+			assert.Equal(t, map[string]string{"Dana L": "1"}, userStore.aliases, "aliases shouldn't change on errors")
+		})
+	}
+}
+
+func TestHandleRemoveUser(t *testing.T) {
+	h, userStore := newUsersTestService()
+
+	// Users with open debts are kept, the debts are referencing them
+	response, err := h.HandleUsersCommand(true, []string{"remove", "Dana Levi"})
+	require.NoError(t, err)
+	assert.Equal(t, "I can't remove \"Dana Levi\", there are still 1 open debts of that user", response)
+	_, err = userStore.GetUser(context.Background(), "1")
+	assert.NoError(t, err)
+
+	response, err = h.HandleUsersCommand(true, []string{"remove", "Noa Cohen"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK, I removed \"Noa Cohen\" (<@U2>)", response)
+	_, err = userStore.GetUser(context.Background(), "2")
+	assert.Error(t, err)
+}
+
+func TestHandleRenameUser(t *testing.T) {
+	h, userStore := newUsersTestService()
+
+	response, err := h.HandleUsersCommand(true, []string{"rename", "Noa Cohen", "Noa Levi"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK, \"Noa Cohen\" is now \"Noa Levi\" (<@U2>)", response)
+	user, err := userStore.GetUser(context.Background(), "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Noa Levi", user.FullName)
+
+	_, err = h.HandleUsersCommand(true, []string{"rename", "Noa Levi", " "})
+	assert.EqualError(t, err, "empty new name")
+}
+
+func TestHandleAliases(t *testing.T) {
+	h, userStore := newUsersTestService()
+
+	response, err := h.HandleUsersCommand(true, []string{"alias", "add", "Noa Cohen", "Noa C"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK, Wolt user \"Noa C\" is now also matched to \"Noa Cohen\" (<@U2>)", response)
+	assert.Equal(t, "2", userStore.aliases["Noa C"])
+
+	// Aliases are unique
+	_, err = h.HandleUsersCommand(true, []string{"alias", "add", "Noa Cohen", "Dana L"})
+	var alreadyExists *userDomain.ErrAlreadyExists
+	assert.True(t, errors.As(err, &alreadyExists), "expected already exists error but got %v", err)
+	assert.Equal(t, "1", userStore.aliases["Dana L"])
+
+	_, err = h.HandleUsersCommand(true, []string{"alias", "add", "Noa Cohen", ""})
+	assert.EqualError(t, err, "empty alias")
+
+	response, err = h.HandleUsersCommand(true, []string{"alias", "remove", "Dana L"})
+	require.NoError(t, err)
+	assert.Equal(t, "OK, I removed the alias \"Dana L\"", response)
+	assert.NotContains(t, userStore.aliases, "Dana L")
+
+	_, err = h.HandleUsersCommand(true, []string{"alias", "remove", "Dana L"})
+	assert.Error(t, err)
+}
+
+func TestHandleSetPreference(t *testing.T) {
+	defaults := userDomain.DefaultPreferences("U1")
+	withPreferences := func(change func(preferences *userDomain.Preferences)) *userDomain.Preferences {
+		preferences := *defaults
+		change(&preferences)
+		return &preferences
+	}
+
+	for _, tc := range []struct {
+		name          string
+		setting       string
+		value         string
+		digest        bool
+		expected      *userDomain.Preferences
+		expectedError bool
+	}{
+		{
+			name:     "Reminders",
+			setting:  SettingReminders,
+			value:    "6h",
+			expected: withPreferences(func(p *userDomain.Preferences) { p.ReminderInterval = 6 * time.Hour }),
+		},
+		{name: "Reminders more often than the global interval", setting: SettingReminders, value: "1h", expectedError: true},
+		{name: "Invalid reminders", setting: SettingReminders, value: "often", expectedError: true},
+		{name: "Default reminders", setting: SettingReminders, value: "default", expected: defaults},
+		{
+			name:     "Quiet hours",
+			setting:  SettingQuietHours,
+			value:    "22-7",
+			expected: withPreferences(func(p *userDomain.Preferences) { p.QuietHoursStart, p.QuietHoursEnd = 22, 7 }),
+		},
+		{
+			name:     "No quiet hours",
+			setting:  SettingQuietHours,
+			value:    "off",
+			expected: withPreferences(func(p *userDomain.Preferences) { p.QuietHoursStart, p.QuietHoursEnd = 0, 0 }),
+		},
+		{name: "Invalid quiet hours", setting: SettingQuietHours, value: "22-25", expectedError: true},
+		{
+			name:     "Paid notifications",
+			setting:  SettingPaidNotifications,
+			value:    "off",
+			expected: withPreferences(func(p *userDomain.Preferences) { p.HostPaidNotifications = false }),
+		},
+		{name: "Digest mode without a digest", setting: SettingNotificationMode, value: "digest", expectedError: true},
+		{
+			name:     "Digest mode",
+			setting:  SettingNotificationMode,
+			value:    "digest",
+			digest:   true,
+			expected: withPreferences(func(p *userDomain.Preferences) { p.NotificationMode = userDomain.NotificationModeDigest }),
+		},
+		{name: "Unknown mode", setting: SettingNotificationMode, value: "loud", expectedError: true},
+		{name: "Unknown setting", setting: "color", value: "blue", expectedError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, userStore := newUsersTestService()
+			if tc.digest {
+				h.debtsDigestSchedule = &Schedule{hour: 10, location: time.UTC}
+			}
+
+			response, err := h.HandleSetPreference("U1", tc.setting, tc.value)
+			if tc.expectedError {
+				assert.True(t, errors.Is(err, ErrInvalidSetting), "expected invalid setting but got %v", err)
+				assert.Empty(t, userStore.preferences)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, response, "OK, saved.\nYour Bolt settings:\n")
+			assert.Equal(t, tc.expected, userStore.preferences["U1"])
+			// Users change just their own preferences
+			assert.Len(t, userStore.preferences, 1)
+		})
+	}
+}
+
+func TestHandleShowPreferences(t *testing.T) {
+	h, _ := newUsersTestService()
+	_, err := h.HandleSetPreference("U1", SettingReminders, "6h")
+	require.NoError(t, err)
+
+	response, err := h.HandleShowPreferences("U1")
+	require.NoError(t, err)
+	assert.Contains(t, response, "• Debt reminders (`reminders`): every 6h0m0s\n")
+
+	response, err = h.HandleShowPreferences("U2")
+	require.NoError(t, err)
+	assert.Contains(t, response, "• Debt reminders (`reminders`): every 3h0m0s (default)\n")
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	orderDomain "github.com/oriser/bolt/order"
+	"github.com/oriser/bolt/venue"
+	"github.com/oriser/bolt/wolt"
+	"github.com/oriser/regroup"
+)
+
+var venueLinkRe = regroup.MustCompile(`\/(restaurant|venue)\/(?P<slug>[a-z0-9-]+?)\/?(\?.*)?$`)
+var venueIDRe = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
+// ErrInvalidVenue is returned when a venue isn't a link, ID or known name of a Wolt venue
+var ErrInvalidVenue = errors.New("invalid venue")
+
+const (
+	watchVenueChoice     = "watch"
+	dontWatchVenueChoice = "dont-watch"
+)
+
+type ParsedWoltVenueSlug struct {
+	Slug string `regroup:"slug,required"`
+}
+
+// getWoltVenueSlugs returns the slugs of all the venues linked, without duplicates
+func (h *Service) getWoltVenueSlugs(links []Link) []string {
+	slugs := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, link := range links {
+		if link.Domain != "wolt.com" {
+			continue
+		}
+
+		parsedVenueLink := &ParsedWoltVenueSlug{}
+		if err := venueLinkRe.MatchToTarget(link.URL, parsedVenueLink); err != nil {
+			if !errors.Is(err, &regroup.NoMatchFoundError{}) {
+				log.Println("Error matching wolt venue URL regex:", err)
+			}
+			continue
+		}
+
+		if !seen[parsedVenueLink.Slug] {
+			seen[parsedVenueLink.Slug] = true
+			slugs = append(slugs, parsedVenueLink.Slug)
+		}
+	}
+	return slugs
+}
+
+// handleVenueLink replies with the venue's card, and offers whoever shared the link to watch the venue if it's closed
+func (h *Service) handleVenueLink(req LinksRequest, slug string) {
+	v, err := h.woltClient.VenueBySlug(context.Background(), slug)
+	if err != nil {
+		log.Printf("Error getting venue %q: %v\n", slug, err)
+		return
+	}
+
+	if _, err = h.informEvent(req.Channel, h.buildVenueCard(v), "", req.MessageID); err != nil {
+		log.Printf("Error sending card of venue %q: %v\n", slug, err)
+		return
+	}
+
+	if v.IsDelivering() || req.User == "" {
+		return
+	}
+
+	prompt := &ChoicePrompt{
+		Text: fmt.Sprintf("Do you want me to let you know when %s opens for delivery?", v.Name),
+		Choices: []Choice{
+			{ID: watchVenueChoice, Text: "Yes, watch it"},
+			{ID: dontWatchVenueChoice, Text: "No, thanks"},
+		},
+	}
+	err = h.sendPrompt(req.Channel, req.User, req.MessageID, prompt, h.cfg.VenueWatchTimeout, func(choiceID, fromTransportID string) (string, error) {
+		if choiceID != watchVenueChoice {
+			return "OK, I won't watch it.", nil
+		}
+		started, err := h.addVenueWatch(&venue.Watch{
+			Slug:        slug,
+			VenueName:   v.Name,
+			Receiver:    req.Channel,
+			MessageID:   req.MessageID,
+			RequestedBy: fromTransportID,
+			ExpiresAt:   time.Now().Add(h.cfg.VenueWatchTimeout),
+		})
+		if err != nil {
+			return "", fmt.Errorf("add venue watch: %w", err)
+		}
+		if !started {
+			return fmt.Sprintf("I'm already watching %s.", v.Name), nil
+		}
+		return fmt.Sprintf("OK, I'll let you know here when %s opens for delivery.", v.Name), nil
+	})
+	if err != nil {
+		log.Printf("Error offering to watch venue %q: %v\n", slug, err)
+	}
+}
+
+// buildVenueCard describes whether the venue is delivering, and the delivery estimate to the office if its location is known
+func (h *Service) buildVenueCard(v *wolt.Venue) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*<%s|%s>*", v.Link, v.Name))
+	if v.Address != "" {
+		sb.WriteString(fmt.Sprintf(" (%s)", v.Address))
+	}
+	sb.WriteString("\n")
+
+	if v.IsDelivering() {
+		sb.WriteString(":large_green_circle: Venue is open for delivery")
+	} else {
+		sb.WriteString(h.buildClosedVenueStatus(v))
+	}
+
+	var estimates []string
+	if h.officeLocation != nil {
+		deliveryRate, err := v.CalculateDeliveryRate(*h.officeLocation)
+		if err != nil {
+			log.Printf("Error calculating delivery rate of venue %q: %v\n", v.Slug, err)
+		} else {
+			distance := wolt.Distance(v.ParsedCoordinate, *h.officeLocation) / 1000
+			estimates = append(estimates, fmt.Sprintf("%.1f km away", distance), fmt.Sprintf("%d nis delivery", deliveryRate))
+		}
+	}
+	if v.Estimates.Total.Max > 0 {
+		estimates = append(estimates, fmt.Sprintf("%d-%d minutes", v.Estimates.Total.Min, v.Estimates.Total.Max))
+	}
+	if len(estimates) > 0 {
+		sb.WriteString(fmt.Sprintf("\n:%s: Delivery to the office: %s", h.cfg.OrderDestinationEmoji, strings.Join(estimates, ", ")))
+	}
+
+	return sb.String()
+}
+
+// HandleWatchVenue starts watching the venue (by its link or ID) until it opens for delivery, and then lets the receiver know
+func (h *Service) HandleWatchVenue(receiver, requestedBy, venueRef string) (string, error) {
+	v, err := h.fetchVenueByRef(context.Background(), venueRef)
+	if err != nil {
+		if errors.Is(err, ErrInvalidVenue) {
+			return "", err
+		}
+		log.Printf("Error getting venue %q: %v\n", venueRef, err)
+		return fmt.Sprintf("I couldn't find venue %q on Wolt", venueRef), nil
+	}
+
+	if v.IsDelivering() {
+		return fmt.Sprintf(":large_green_circle: <%s|%s> is open for delivery right now", v.Link, v.Name), nil
+	}
+
+	started, err := h.addVenueWatch(&venue.Watch{
+		Slug:        v.Slug,
+		VenueName:   v.Name,
+		Receiver:    receiver,
+		RequestedBy: requestedBy,
+		ExpiresAt:   time.Now().Add(h.cfg.VenueWatchTimeout),
+	})
+	if err != nil {
+		return "", fmt.Errorf("add venue watch: %w", err)
+	}
+	if !started {
+		return fmt.Sprintf("I'm already watching %s for this channel", v.Name), nil
+	}
+	return fmt.Sprintf("%s – I'll let this channel know when <%s|%s> opens", h.buildClosedVenueStatus(v), v.Link, v.Name), nil
+}
+
+// fetchVenueByRef fetches a venue by its link, ID or name.
+// Wolt can't be searched by name, so names are looked up in the venues of the orders Bolt tracked.
+func (h *Service) fetchVenueByRef(ctx context.Context, venueRef string) (*wolt.Venue, error) {
+	// Slack may send links wrapped as <url> or <url|text>
+	venueRef = strings.TrimSpace(venueRef)
+	venueRef = strings.TrimSuffix(strings.TrimPrefix(venueRef, "<"), ">")
+	if i := strings.Index(venueRef, "|"); i != -1 {
+		venueRef = venueRef[:i]
+	}
+
+	if strings.Contains(venueRef, "/") {
+		parsedVenueLink := &ParsedWoltVenueSlug{}
+		if err := venueLinkRe.MatchToTarget(venueRef, parsedVenueLink); err != nil || !strings.Contains(venueRef, "wolt.com") {
+			return nil, fmt.Errorf("%w: %q isn't a link of a Wolt venue", ErrInvalidVenue, venueRef)
+		}
+		return h.woltClient.VenueBySlug(ctx, parsedVenueLink.Slug)
+	}
+
+	if venueIDRe.MatchString(venueRef) {
+		return h.woltClient.VenueByID(ctx, venueRef)
+	}
+
+	venueID, err := h.venueIDByName(ctx, venueRef)
+	if err != nil {
+		return nil, err
+	}
+	return h.woltClient.VenueByID(ctx, venueID)
+}
+
+// venueIDByName returns the ID of the venue with the name (case insensitive) from the orders Bolt tracked.
+// Venues of the same name (like branches of a chain) are told apart by their links.
+func (h *Service) venueIDByName(ctx context.Context, name string) (string, error) {
+	orders, err := h.orderStore.ListOrders(ctx, orderDomain.ListFilter{})
+	if err != nil {
+		return "", fmt.Errorf("list orders: %w", err)
+	}
+
+	venueIDs := make([]string, 0)
+	links := make(map[string]string)
+	for _, order := range orders {
+		if order.VenueID == "" || !strings.EqualFold(strings.TrimSpace(order.VenueName), name) {
+			continue
+		}
+		if _, ok := links[order.VenueID]; !ok {
+			venueIDs = append(venueIDs, order.VenueID)
+			links[order.VenueID] = order.VenueLink
+		}
+	}
+
+	switch len(venueIDs) {
+	case 0:
+		return "", fmt.Errorf("%w: %q isn't a link or ID of a Wolt venue, or a name of a venue I tracked orders from", ErrInvalidVenue, name)
+	case 1:
+		return venueIDs[0], nil
+	default:
+		venueLinks := make([]string, len(venueIDs))
+		for i, venueID := range venueIDs {
+			venueLinks[i] = fmt.Sprintf("<%s|%s>", links[venueID], venueID)
+			if links[venueID] == "" {
+				venueLinks[i] = venueID
+			}
+		}
+		return "", fmt.Errorf("%w: there's more than one venue named %q (%s), use the link or ID of the one you meant", ErrInvalidVenue, name, strings.Join(venueLinks, ", "))
+	}
+}
+
+// addVenueWatch starts watching the venue and saves the watch so it will be resumed after a restart.
+// It returns false if the venue is already watched for the receiver.
+func (h *Service) addVenueWatch(watch *venue.Watch) (bool, error) {
+	key := watch.Receiver + "/" + watch.Slug
+	if _, loaded := h.venueWatches.LoadOrStore(key, nil); loaded {
+		return false, nil
+	}
+
+	if h.venueStore != nil {
+		if err := h.venueStore.AddVenueWatch(context.Background(), watch); err != nil {
+			h.venueWatches.Delete(key)
+			return false, fmt.Errorf("save venue watch: %w", err)
+		}
+	}
+
+	go h.runVenueWatch(key, watch)
+	return true, nil
+}
+
+// ResumeVenueWatches resumes watching the venues which were watched before the last restart
+func (h *Service) ResumeVenueWatches(ctx context.Context) {
+	if h.venueStore == nil {
+		return
+	}
+
+	watches, err := h.venueStore.ListVenueWatches(ctx)
+	if err != nil {
+		log.Println("Error listing venue watches:", err)
+		return
+	}
+	for _, watch := range watches {
+		key := watch.Receiver + "/" + watch.Slug
+		if _, loaded := h.venueWatches.LoadOrStore(key, nil); loaded {
+			continue
+		}
+		log.Printf("Resuming watch of venue %q for %s\n", watch.Slug, watch.Receiver)
+		go h.runVenueWatch(key, watch)
+	}
+}
+
+// runVenueWatch watches the venue until it opens or the watch expires, and then removes the watch
+func (h *Service) runVenueWatch(key string, watch *venue.Watch) {
+	defer h.venueWatches.Delete(key)
+
+	ctx, cancel := context.WithDeadline(context.Background(), watch.ExpiresAt)
+	defer cancel()
+	if err := h.watchVenue(ctx, watch); err != nil {
+		log.Printf("Error watching venue %q: %v\n", watch.Slug, err)
+		if strings.Contains(err.Error(), "context canceled while waiting") {
+			_, _ = h.informEvent(watch.Receiver, fmt.Sprintf("I stopped watching %s, it didn't open for delivery in time", watch.VenueName), "", watch.MessageID)
+		}
+	}
+
+	if h.venueStore != nil && watch.ID != "" {
+		if err := h.venueStore.DeleteVenueWatch(context.Background(), watch.ID); err != nil {
+			log.Printf("Error deleting watch of venue %q: %v\n", watch.Slug, err)
+		}
+	}
+}
+
+// watchVenue polls the venue until it opens for delivery, and then lets the receiver know
+func (h *Service) watchVenue(ctx context.Context, watch *venue.Watch) error {
+	var v *wolt.Venue
+	failures := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context canceled while waiting for venue to open")
+		case <-h.poller.after(ctx, h.poller.backoff(h.poller.venueInterval(v), failures)):
+			latest, err := h.woltClient.VenueBySlug(ctx, watch.Slug)
+			if err != nil {
+				if ctx.Err() != nil {
+					continue
+				}
+				failures++
+				log.Printf("Error getting venue %q: %v\n", watch.Slug, err)
+				continue
+			}
+			v, failures = latest, 0
+			if !v.IsDelivering() {
+				continue
+			}
+
+			if _, err = h.informEvent(watch.Receiver, fmt.Sprintf(":large_green_circle: <%s|%s> is now open for delivery", v.Link, v.Name), "", watch.MessageID); err != nil {
+				return fmt.Errorf("inform venue is open: %w", err)
+			}
+			return nil
+		}
+	}
+}
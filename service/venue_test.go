@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	orderDomain "github.com/oriser/bolt/order"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVenueIDByName(t *testing.T) {
+	h := &Service{orderStore: &fakeOrderStore{orders: []*orderDomain.Order{
+		{VenueName: "Pizza Place", VenueID: "venue-1", VenueLink: "https://wolt.com/venue/pizza-place"},
+		{VenueName: "Pizza Place", VenueID: "venue-1", VenueLink: "https://wolt.com/venue/pizza-place"},
+		{VenueName: "Burger Chain", VenueID: "venue-2", VenueLink: "https://wolt.com/venue/burger-chain-north"},
+		{VenueName: "Burger Chain", VenueID: "venue-3", VenueLink: "https://wolt.com/venue/burger-chain-south"},
+		{VenueName: "Unknown Venue"},
+	}}}
+	ctx := context.Background()
+
+	for _, name := range []string{"Pizza Place", "pizza place"} {
+		venueID, err := h.venueIDByName(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, "venue-1", venueID)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "Sushi Bar", expected: `"Sushi Bar" isn't a link or ID of a Wolt venue, or a name of a venue I tracked orders from`},
+		{name: "Unknown Venue", expected: `"Unknown Venue" isn't a link or ID of a Wolt venue, or a name of a venue I tracked orders from`},
+		{
+			name: "Burger Chain",
+			expected: `there's more than one venue named "Burger Chain" (<https://wolt.com/venue/burger-chain-north|venue-2>, ` +
+				`<https://wolt.com/venue/burger-chain-south|venue-3>), use the link or ID of the one you meant`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := h.venueIDByName(ctx, tc.name)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidVenue))
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
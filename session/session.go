@@ -0,0 +1,19 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+// Session is a Wolt account Bolt is signed in to
+type Session struct {
+	Account      string    `db:"account"`
+	RefreshToken []byte    `db:"refresh_token"` // Encrypted, it's enough for signing in to the account
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+type Store interface {
+	// GetSession returns the session of the account, or nil if it never signed in
+	GetSession(ctx context.Context, account string) (*Session, error)
+	SaveSession(ctx context.Context, session *Session) error
+}
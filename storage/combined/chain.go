@@ -0,0 +1,338 @@
+package combined
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+)
+
+// The UserStoreChain combines any number of user stores, queried in their order in the chain:
+// 1. For AddUser, UpdateUser and DeleteUser, using the first authoritative store, and writing through to the cache stores
+// 2. For GetUser, taking from the first store which has the user, and caching it in the cache stores before it
+// 3. For ListUsers, combining the users from all the stores, unless looking up a single user which was already found.
+//    A user found in a store hides users with the same transport ID from the next stores.
+// 4. For FindCandidates, combining the candidates from all the stores, the same way
+// 5. For GetPreferences, SavePreferences, AddAlias, RemoveAlias and ListAliases, using the first authoritative store
+
+// Policy defines the role of a store in the chain
+type Policy int
+
+const (
+	// PolicyAuthoritative stores are always queried, the first of them is the one which is written to
+	PolicyAuthoritative Policy = iota
+	// PolicyFallback stores are read only, and queried just when looking up a single user which wasn't found yet (or when listing)
+	PolicyFallback
+	// PolicyWriteThroughCache stores get all the writes, as well as the users looked up from the next stores
+	PolicyWriteThroughCache
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyAuthoritative:
+		return "authoritative"
+	case PolicyFallback:
+		return "fallback"
+	case PolicyWriteThroughCache:
+		return "write-through cache"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
+	}
+}
+
+// SlowStoreThreshold is the duration above which calls to stores of the chain are logged
+var SlowStoreThreshold = time.Second
+
+// Link is a single store in the chain
+type Link struct {
+	Name   string // For logs and stats
+	Store  userDomain.Store
+	Policy Policy
+}
+
+// StoreStats are the timing stats of calls to a single store in the chain
+type StoreStats struct {
+	Name          string
+	Calls         int
+	Errors        int
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+func (s StoreStats) String() string {
+	average := time.Duration(0)
+	if s.Calls > 0 {
+		average = s.TotalDuration / time.Duration(s.Calls)
+	}
+	return fmt.Sprintf("%d calls, %d errors, average %s, max %s", s.Calls, s.Errors, average, s.MaxDuration)
+}
+
+type UserStoreChain struct {
+	links   []Link
+	primary int // Index of the first authoritative store
+
+	statsLock sync.Mutex
+	stats     map[string]*StoreStats
+}
+
+func NewChain(links ...Link) (*UserStoreChain, error) {
+	chain := &UserStoreChain{
+		links:   links,
+		primary: -1,
+		stats:   make(map[string]*StoreStats, len(links)),
+	}
+	for i, link := range links {
+		if _, ok := chain.stats[link.Name]; ok {
+			return nil, fmt.Errorf("more than one store named %q", link.Name)
+		}
+		chain.stats[link.Name] = &StoreStats{Name: link.Name}
+		if chain.primary == -1 && link.Policy == PolicyAuthoritative {
+			chain.primary = i
+		}
+	}
+	if chain.primary == -1 {
+		return nil, fmt.Errorf("no authoritative store")
+	}
+	return chain, nil
+}
+
+// Stats returns the timing stats of every store in the chain, in the chain's order
+func (c *UserStoreChain) Stats() []StoreStats {
+	c.statsLock.Lock()
+	defer c.statsLock.Unlock()
+
+	stats := make([]StoreStats, len(c.links))
+	for i, link := range c.links {
+		stats[i] = *c.stats[link.Name]
+	}
+	return stats
+}
+
+// LogStats logs the timing stats of every store in the chain every interval, until the context is done
+func (c *UserStoreChain) LogStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, stats := range c.Stats() {
+				log.Printf("User store %q stats: %s\n", stats.Name, stats)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// timed calls f with the store of the link, recording how long it took
+func (c *UserStoreChain) timed(link Link, operation string, f func(store userDomain.Store) error) error {
+	start := time.Now()
+	err := f(link.Store)
+	duration := time.Since(start)
+
+	c.statsLock.Lock()
+	stats := c.stats[link.Name]
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.TotalDuration += duration
+	if duration > stats.MaxDuration {
+		stats.MaxDuration = duration
+	}
+	c.statsLock.Unlock()
+
+	if duration > SlowStoreThreshold {
+		log.Printf("User store %q took %s for %s\n", link.Name, duration, operation)
+	}
+	return err
+}
+
+func (c *UserStoreChain) primaryLink() Link {
+	return c.links[c.primary]
+}
+
+// writeThrough calls f on the primary store, and then on all the cache stores
+func (c *UserStoreChain) writeThrough(operation string, f func(store userDomain.Store) error) error {
+	primary := c.primaryLink()
+	if err := c.timed(primary, operation, f); err != nil {
+		return err
+	}
+
+	for _, link := range c.links {
+		if link.Policy != PolicyWriteThroughCache {
+			continue
+		}
+		if err := c.timed(link, operation, f); err != nil {
+			log.Printf("Error writing through to cache store %q for %s: %v\n", link.Name, operation, err)
+		}
+	}
+	return nil
+}
+
+// cacheUsers adds users found in the store at index found to the cache stores before it
+func (c *UserStoreChain) cacheUsers(ctx context.Context, found int, users ...*userDomain.User) {
+	for _, link := range c.links[:found] {
+		if link.Policy != PolicyWriteThroughCache {
+			continue
+		}
+		for _, user := range users {
+			user := *user
+			if err := c.timed(link, "AddUser", func(store userDomain.Store) error {
+				return store.AddUser(ctx, &user)
+			}); err != nil {
+				log.Printf("Error caching user %s in store %q: %v\n", user.ID, link.Name, err)
+			}
+		}
+	}
+}
+
+func (c *UserStoreChain) AddUser(ctx context.Context, user *userDomain.User) error {
+	return c.writeThrough("AddUser", func(store userDomain.Store) error {
+		return store.AddUser(ctx, user)
+	})
+}
+
+func (c *UserStoreChain) UpdateUser(ctx context.Context, user *userDomain.User) error {
+	return c.writeThrough("UpdateUser", func(store userDomain.Store) error {
+		return store.UpdateUser(ctx, user)
+	})
+}
+
+func (c *UserStoreChain) DeleteUser(ctx context.Context, id string) error {
+	return c.writeThrough("DeleteUser", func(store userDomain.Store) error {
+		return store.DeleteUser(ctx, id)
+	})
+}
+
+// singleLookup returns whether the filter looks up a single user (rather than listing users)
+func singleLookup(filter userDomain.ListFilter) bool {
+	return len(filter.Names)+len(filter.WoltUserIDs)+len(filter.Emails) == 1 && filter.TransportID == ""
+}
+
+func (c *UserStoreChain) ListUsers(ctx context.Context, filter userDomain.ListFilter) ([]*userDomain.User, error) {
+	users := make([]*userDomain.User, 0)
+	found := make(map[string]bool) // Transport IDs found in the previous stores
+	for i, link := range c.links {
+		if link.Policy == PolicyFallback && singleLookup(filter) && len(users) == 1 {
+			// The single user we looked for was already found
+			continue
+		}
+
+		var storeUsers []*userDomain.User
+		err := c.timed(link, "ListUsers", func(store userDomain.Store) (err error) {
+			storeUsers, err = store.ListUsers(ctx, filter)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing users from %s store: %w", link.Name, err)
+		}
+
+		newUsers := make([]*userDomain.User, 0, len(storeUsers))
+		for _, user := range storeUsers {
+			if !found[user.TransportID] {
+				newUsers = append(newUsers, user)
+			}
+		}
+		for _, user := range newUsers {
+			found[user.TransportID] = true
+		}
+		if singleLookup(filter) && len(users) == 0 && len(newUsers) == 1 {
+			c.cacheUsers(ctx, i, newUsers...)
+		}
+		users = append(users, newUsers...)
+	}
+	return users, nil
+}
+
+func (c *UserStoreChain) FindCandidates(ctx context.Context, name string) ([]*userDomain.Candidate, error) {
+	candidates := make([]*userDomain.Candidate, 0)
+	found := make(map[string]bool) // Transport IDs found in the previous stores
+	for _, link := range c.links {
+		var storeCandidates []*userDomain.Candidate
+		err := c.timed(link, "FindCandidates", func(store userDomain.Store) (err error) {
+			storeCandidates, err = store.FindCandidates(ctx, name)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("finding candidates from %s store: %w", link.Name, err)
+		}
+
+		newCandidates := make([]*userDomain.Candidate, 0, len(storeCandidates))
+		for _, candidate := range storeCandidates {
+			if !found[candidate.User.TransportID] {
+				newCandidates = append(newCandidates, candidate)
+			}
+		}
+		for _, candidate := range newCandidates {
+			found[candidate.User.TransportID] = true
+		}
+		candidates = append(candidates, newCandidates...)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
+
+func (c *UserStoreChain) GetUser(ctx context.Context, id string) (*userDomain.User, error) {
+	var errs []error
+	for i, link := range c.links {
+		var user *userDomain.User
+		err := c.timed(link, "GetUser", func(store userDomain.Store) (err error) {
+			user, err = store.GetUser(ctx, id)
+			return err
+		})
+		if err == nil && user != nil {
+			c.cacheUsers(ctx, i, user)
+			return user, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s store: %w", link.Name, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	return nil, errs[len(errs)-1]
+}
+
+func (c *UserStoreChain) GetPreferences(ctx context.Context, transportID string) (preferences *userDomain.Preferences, err error) {
+	err = c.timed(c.primaryLink(), "GetPreferences", func(store userDomain.Store) error {
+		preferences, err = store.GetPreferences(ctx, transportID)
+		return err
+	})
+	return preferences, err
+}
+
+func (c *UserStoreChain) SavePreferences(ctx context.Context, preferences *userDomain.Preferences) error {
+	return c.timed(c.primaryLink(), "SavePreferences", func(store userDomain.Store) error {
+		return store.SavePreferences(ctx, preferences)
+	})
+}
+
+func (c *UserStoreChain) AddAlias(ctx context.Context, userID, alias string) error {
+	return c.timed(c.primaryLink(), "AddAlias", func(store userDomain.Store) error {
+		return store.AddAlias(ctx, userID, alias)
+	})
+}
+
+func (c *UserStoreChain) RemoveAlias(ctx context.Context, alias string) error {
+	return c.timed(c.primaryLink(), "RemoveAlias", func(store userDomain.Store) error {
+		return store.RemoveAlias(ctx, alias)
+	})
+}
+
+func (c *UserStoreChain) ListAliases(ctx context.Context, userID string) (aliases []string, err error) {
+	err = c.timed(c.primaryLink(), "ListAliases", func(store userDomain.Store) error {
+		aliases, err = store.ListAliases(ctx, userID)
+		return err
+	})
+	return aliases, err
+}
@@ -0,0 +1,240 @@
+package combined
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore keeps users in memory, counting the lookups. Methods it doesn't implement panic.
+type fakeStore struct {
+	userDomain.Store
+	users       []*userDomain.User
+	woltMatches map[string]string // Transport IDs by the Wolt user IDs matched to them
+	lookups     int
+}
+
+func (f *fakeStore) AddUser(_ context.Context, user *userDomain.User) error {
+	f.users = append(f.users, user)
+	return nil
+}
+
+func (f *fakeStore) GetUser(_ context.Context, id string) (*userDomain.User, error) {
+	f.lookups++
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListUsers(_ context.Context, filter userDomain.ListFilter) ([]*userDomain.User, error) {
+	f.lookups++
+	users := make([]*userDomain.User, 0)
+	for _, user := range f.users {
+		for _, name := range filter.Names {
+			if user.FullName == name {
+				users = append(users, user)
+			}
+		}
+		for _, woltUserID := range filter.WoltUserIDs {
+			if f.woltMatches[woltUserID] == user.TransportID {
+				users = append(users, user)
+			}
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeStore) FindCandidates(_ context.Context, name string) ([]*userDomain.Candidate, error) {
+	candidates := make([]*userDomain.Candidate, 0)
+	for i, user := range f.users {
+		candidates = append(candidates, &userDomain.Candidate{User: user, Score: 100 - i})
+	}
+	return candidates, nil
+}
+
+func TestNewChain(t *testing.T) {
+	_, err := NewChain(Link{Name: "slack", Store: &fakeStore{}, Policy: PolicyFallback})
+	assert.Error(t, err, "a chain without an authoritative store")
+
+	_, err = NewChain(
+		Link{Name: "db", Store: &fakeStore{}, Policy: PolicyAuthoritative},
+		Link{Name: "db", Store: &fakeStore{}, Policy: PolicyFallback},
+	)
+	assert.Error(t, err, "a chain with duplicate names")
+}
+
+func TestChainListUsers(t *testing.T) {
+	ctx := context.Background()
+	db := &fakeStore{users: []*userDomain.User{{ID: "1", FullName: "Dana", TransportID: "U1"}}}
+	slack := &fakeStore{users: []*userDomain.User{
+		{ID: "U1", FullName: "Dana", TransportID: "U1"},
+		{ID: "U2", FullName: "Noa", TransportID: "U2"},
+		{ID: "U3", FullName: "Noa", TransportID: "U3"},
+	}}
+	chain, err := NewChain(
+		Link{Name: "db", Store: db, Policy: PolicyAuthoritative},
+		Link{Name: "slack", Store: slack, Policy: PolicyFallback},
+	)
+	require.NoError(t, err)
+
+	users, err := chain.ListUsers(ctx, userDomain.ListFilter{Names: []string{"Dana"}})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "1", users[0].ID)
+	assert.Equal(t, 0, slack.lookups, "the fallback shouldn't be queried once the single user was found")
+
+	users, err = chain.ListUsers(ctx, userDomain.ListFilter{Names: []string{"Dana", "Noa"}})
+	require.NoError(t, err)
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	assert.Equal(t, []string{"1", "U2", "U3"}, ids, "users of the same transport ID should be listed once, from the first store")
+
+	stats := chain.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "db", stats[0].Name)
+	assert.Equal(t, 2, stats[0].Calls)
+	assert.Equal(t, 1, stats[1].Calls)
+}
+
+func TestChainListUsersByWoltUserID(t *testing.T) {
+	ctx := context.Background()
+	woltMatches := map[string]string{"wolt-1": "U1", "wolt-2": "U2"}
+	db := &fakeStore{users: []*userDomain.User{{ID: "1", FullName: "Dana", TransportID: "U1"}}, woltMatches: woltMatches}
+	slack := &fakeStore{users: []*userDomain.User{
+		{ID: "U1", FullName: "Dana", TransportID: "U1"},
+		{ID: "U2", FullName: "Noa", TransportID: "U2"},
+	}, woltMatches: woltMatches}
+	chain, err := NewChain(
+		Link{Name: "db", Store: db, Policy: PolicyAuthoritative},
+		Link{Name: "slack", Store: slack, Policy: PolicyFallback},
+	)
+	require.NoError(t, err)
+
+	users, err := chain.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{"wolt-1"}})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "1", users[0].ID)
+	assert.Equal(t, 0, slack.lookups, "the fallback shouldn't be queried once the single user was found")
+
+	users, err = chain.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{"wolt-2"}})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "U2", users[0].ID, "a user matched in a fallback store should be found there")
+}
+
+func TestChainWriteThroughCache(t *testing.T) {
+	ctx := context.Background()
+	cache := &fakeStore{}
+	db := &fakeStore{}
+	slack := &fakeStore{users: []*userDomain.User{{ID: "U1", FullName: "Dana", TransportID: "U1"}}}
+	chain, err := NewChain(
+		Link{Name: "cache", Store: cache, Policy: PolicyWriteThroughCache},
+		Link{Name: "db", Store: db, Policy: PolicyAuthoritative},
+		Link{Name: "slack", Store: slack, Policy: PolicyFallback},
+	)
+	require.NoError(t, err)
+
+	user, err := chain.GetUser(ctx, "U1")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	require.Len(t, cache.users, 1, "a user found in a later store should be cached")
+	assert.Empty(t, db.users)
+
+	slack.lookups = 0
+	user, err = chain.GetUser(ctx, "U1")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	assert.Equal(t, 0, slack.lookups, "a cached user should be taken from the cache")
+
+	require.NoError(t, chain.AddUser(ctx, &userDomain.User{ID: "2", FullName: "Noa", TransportID: "U2"}))
+	assert.Len(t, db.users, 1)
+	assert.Len(t, cache.users, 2, "writes should go through to the cache")
+}
+
+func TestChainFindCandidates(t *testing.T) {
+	db := &fakeStore{users: []*userDomain.User{{ID: "1", FullName: "Dana", TransportID: "U1"}}}
+	slack := &fakeStore{users: []*userDomain.User{
+		{ID: "U2", FullName: "Dan", TransportID: "U2"},
+		{ID: "U1", FullName: "Dana", TransportID: "U1"},
+	}}
+	chain, err := NewChain(
+		Link{Name: "db", Store: db, Policy: PolicyAuthoritative},
+		Link{Name: "slack", Store: slack, Policy: PolicyFallback},
+	)
+	require.NoError(t, err)
+
+	candidates, err := chain.FindCandidates(context.Background(), "Dana")
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, "1", candidates[0].User.ID)
+	assert.Equal(t, "U2", candidates[1].User.ID)
+}
+
+// lockedBuffer is a buffer safe for writing logs while reading them
+type lockedBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestChainLogStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := &fakeStore{users: []*userDomain.User{{ID: "1", FullName: "Dana", TransportID: "U1"}}}
+	chain, err := NewChain(Link{Name: "db", Store: db, Policy: PolicyAuthoritative})
+	require.NoError(t, err)
+	_, err = chain.GetUser(ctx, "1")
+	require.NoError(t, err)
+
+	logs := &lockedBuffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	done := make(chan struct{})
+	go func() {
+		chain.LogStats(ctx, time.Millisecond)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), `User store "db" stats: 1 calls, 0 errors`)
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("LogStats didn't return once the context was done")
+	}
+}
+
+func TestStoreStatsString(t *testing.T) {
+	stats := StoreStats{Name: "slack", Calls: 4, Errors: 1, TotalDuration: 8 * time.Millisecond, MaxDuration: 5 * time.Millisecond}
+	assert.Equal(t, "4 calls, 1 errors, average 2ms, max 5ms", stats.String())
+	assert.Equal(t, "0 calls, 0 errors, average 0s, max 0s", StoreStats{Name: "db"}.String())
+}
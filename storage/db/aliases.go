@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	userDomain "github.com/oriser/bolt/user"
+)
+
+// isAlias returns whether the name is already used as an alias of a user other than exceptUserID
+func (d *DBStore) isAlias(name, exceptUserID string) (bool, error) {
+	sql, args, err := sq.Select("COUNT(*)").From("user_aliases").Where(sq.And{
+		sq.Eq{"alias": name},
+		sq.NotEq{"user_id": exceptUserID},
+	}).ToSql()
+	if err != nil {
+		return false, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	var count int
+	if err = d.db.Get(&count, sql, args...); err != nil {
+		return false, newExecError("counting aliases", sql, err, args...)
+	}
+	return count > 0, nil
+}
+
+func (d *DBStore) AddAlias(ctx context.Context, userID, alias string) error {
+	if alias == "" {
+		return fmt.Errorf("empty alias")
+	}
+	if _, err := d.GetUser(ctx, userID); err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	// An alias can't be a name of another user, otherwise listing by that name will find more than one user
+	users, err := d.ListUsers(ctx, userDomain.ListFilter{Names: []string{alias}})
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	if len(users) > 0 {
+		return &userDomain.ErrAlreadyExists{Name: alias}
+	}
+
+	sql, args, err := sq.Insert("user_aliases").Values(alias, userID, time.Now()).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating insert SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		if isUniqueConstraintError(err, "user_aliases.alias") {
+			return &userDomain.ErrAlreadyExists{Name: alias}
+		}
+		return newExecError("adding alias", sql, err, args...)
+	}
+
+	return nil
+}
+
+func (d *DBStore) RemoveAlias(_ context.Context, alias string) error {
+	sql, args, err := sq.Delete("user_aliases").Where("alias=?", alias).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating delete SQL: %w", err)
+	}
+
+	res, err := d.db.Exec(sql, args...)
+	if err != nil {
+		return newExecError("removing alias", sql, err, args...)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("alias %q not found", alias)
+	}
+	return nil
+}
+
+func (d *DBStore) ListAliases(_ context.Context, userID string) ([]string, error) {
+	sql, args, err := sq.Select("alias").From("user_aliases").Where("user_id=?", userID).OrderBy("alias").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	aliases := make([]string, 0)
+	if err = d.db.Select(&aliases, sql, args...); err != nil {
+		return nil, newExecError("selecting aliases", sql, err, args...)
+	}
+	return aliases, nil
+}
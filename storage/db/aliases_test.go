@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAliases(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	user := getDummyUser().User()
+	other := getDummyUser().User()
+	require.NoError(t, dbTest.db.AddUser(ctx, user))
+	require.NoError(t, dbTest.db.AddUser(ctx, other))
+
+	alias := randomStringAlpha(8)
+	secondAlias := randomStringAlpha(9)
+	require.NoError(t, dbTest.db.AddAlias(ctx, user.ID, alias))
+	require.NoError(t, dbTest.db.AddAlias(ctx, user.ID, secondAlias))
+
+	t.Run("List aliases", func(t *testing.T) {
+		aliases, err := dbTest.db.ListAliases(ctx, user.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{alias, secondAlias}, aliases)
+
+		aliases, err = dbTest.db.ListAliases(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Empty(t, aliases)
+	})
+
+	t.Run("List users by alias", func(t *testing.T) {
+		users, err := dbTest.db.ListUsers(ctx, userDomain.ListFilter{Names: []string{alias}})
+		require.NoError(t, err)
+		testExpectedUsers(t, []*userDomain.User{user}, users)
+
+		// The user should be listed once when matched by both its name and alias
+		users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{Names: []string{user.FullName, alias, other.FullName}})
+		require.NoError(t, err)
+		testExpectedUsers(t, []*userDomain.User{user, other}, users)
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		var alreadyExists *userDomain.ErrAlreadyExists
+		// Alias of another user
+		require.ErrorAs(t, dbTest.db.AddAlias(ctx, other.ID, alias), &alreadyExists)
+		// Name of another user
+		require.ErrorAs(t, dbTest.db.AddAlias(ctx, user.ID, other.FullName), &alreadyExists)
+		// Adding a user with the name of an alias
+		withAliasName := getDummyUser().User()
+		withAliasName.FullName = alias
+		require.ErrorAs(t, dbTest.db.AddUser(ctx, withAliasName), &alreadyExists)
+		// Renaming a user to an alias of another user
+		renamed := *other
+		renamed.FullName = alias
+		require.ErrorAs(t, dbTest.db.UpdateUser(ctx, &renamed), &alreadyExists)
+		// Non existing user
+		assert.Error(t, dbTest.db.AddAlias(ctx, randomStringAlpha(5), randomStringAlpha(10)))
+	})
+
+	t.Run("Remove alias", func(t *testing.T) {
+		require.NoError(t, dbTest.db.RemoveAlias(ctx, secondAlias))
+		users, err := dbTest.db.ListUsers(ctx, userDomain.ListFilter{Names: []string{secondAlias}})
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		assert.Error(t, dbTest.db.RemoveAlias(ctx, secondAlias))
+	})
+
+	t.Run("Delete user removes aliases", func(t *testing.T) {
+		require.NoError(t, dbTest.db.DeleteUser(ctx, user.ID))
+		aliases, err := dbTest.db.ListAliases(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, aliases)
+
+		// The alias is free to use again
+		require.NoError(t, dbTest.db.AddAlias(ctx, other.ID, alias))
+	})
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/oriser/bolt/channel"
+)
+
+type channelConfigModel struct {
+	*channel.Config
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// GetChannelConfig returns the configuration of the channel, or a configuration without overrides if it was never set
+func (d *DBStore) GetChannelConfig(_ context.Context, channelID string) (*channel.Config, error) {
+	sql, args, err := sq.Select("*").From("channel_configs").Where("channel_id=?", channelID).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	var configs []*channelConfigModel
+	err = d.db.Select(&configs, sql, args...)
+	if err != nil {
+		return nil, newExecError("selecting channel config", sql, err, args...)
+	}
+
+	if len(configs) == 0 {
+		return &channel.Config{ChannelID: channelID}, nil
+	}
+
+	return configs[0].Config, nil
+}
+
+func (d *DBStore) SaveChannelConfig(_ context.Context, config *channel.Config) error {
+	if config == nil {
+		return fmt.Errorf("nil channel config")
+	}
+	if config.ChannelID == "" {
+		return fmt.Errorf("empty channel ID")
+	}
+	model := &channelConfigModel{Config: config, UpdatedAt: time.Now()}
+
+	sql, args, err := sq.Replace("channel_configs").
+		Columns("channel_id", "split_strategy", "dont_join_after", "destination_emoji", "debt_tracking",
+			"debt_reminder_interval", "debt_maximum_duration", "progress_updates", "live_roster", "updated_at").
+		Values(model.ChannelID, model.SplitStrategy, model.DontJoinAfter, model.DestinationEmoji, model.DebtTracking,
+			model.DebtReminderInterval, model.DebtMaximumDuration, model.ProgressUpdates, model.LiveRoster, model.UpdatedAt).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating replace SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("saving channel config", sql, err, args...)
+	}
+
+	return nil
+}
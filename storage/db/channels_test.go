@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/channel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelConfig(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+
+	proportional := channel.SplitProportional
+	noCutoff := ""
+	emoji := "office"
+	off := false
+	on := true
+	interval := 6 * time.Hour
+
+	tests := []struct {
+		name    string
+		configs []*channel.Config
+	}{
+		{
+			name:    "No overrides",
+			configs: nil,
+		},
+		{
+			name: "Partial overrides",
+			configs: []*channel.Config{
+				{
+					SplitStrategy:        &proportional,
+					DestinationEmoji:     &emoji,
+					DebtReminderInterval: &interval,
+				},
+			},
+		},
+		{
+			name: "All overrides",
+			configs: []*channel.Config{
+				{
+					SplitStrategy:        &proportional,
+					DontJoinAfter:        &noCutoff,
+					DestinationEmoji:     &emoji,
+					DebtTracking:         &off,
+					DebtReminderInterval: &interval,
+					DebtMaximumDuration:  &interval,
+					ProgressUpdates:      &on,
+					LiveRoster:           &on,
+				},
+			},
+		},
+		{
+			name: "Override back",
+			configs: []*channel.Config{
+				{
+					SplitStrategy: &proportional,
+					DebtTracking:  &off,
+				},
+				{
+					DebtTracking: &on,
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			channelID := randomStringAlpha(6)
+
+			expected := &channel.Config{ChannelID: channelID}
+			for _, config := range tc.configs {
+				config.ChannelID = channelID
+				require.NoError(t, dbTest.db.SaveChannelConfig(ctx, config))
+				expected = config
+			}
+
+			got, err := dbTest.db.GetChannelConfig(ctx, channelID)
+			require.NoError(t, err)
+			assert.Equal(t, expected, got)
+		})
+	}
+
+	t.Run("Empty channel ID", func(t *testing.T) {
+		err := dbTest.db.SaveChannelConfig(ctx, &channel.Config{})
+		assert.Error(t, err)
+	})
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"embed"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database"
@@ -32,15 +34,20 @@ type DBStore struct {
 	db *sqlx.DB
 }
 
+// Versions of the migrations creating the users table, and making the names of users unique
+const (
+	usersTableVersion      = 2
+	uniqueUserNamesVersion = 6
+)
+
 func New(db *sqlx.DB, migrationDriver database.Driver, dbName string) (*DBStore, error) {
-	d, err := iofs.New(migrations, "migrations")
+	m, err := newMigrate(migrationDriver, dbName)
 	if err != nil {
-		return nil, fmt.Errorf("new iofs: %w", err)
+		return nil, err
 	}
 
-	m, err := migrate.NewWithInstance("iofs", d, dbName, migrationDriver)
-	if err != nil {
-		return nil, fmt.Errorf("new migration instance: %w", err)
+	if err = checkDuplicateUserNames(db, m); err != nil {
+		return nil, err
 	}
 
 	err = m.Up()
@@ -52,3 +59,43 @@ func New(db *sqlx.DB, migrationDriver database.Driver, dbName string) (*DBStore,
 		db: db,
 	}, nil
 }
+
+func newMigrate(migrationDriver database.Driver, dbName string) (*migrate.Migrate, error) {
+	d, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("new iofs: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", d, dbName, migrationDriver)
+	if err != nil {
+		return nil, fmt.Errorf("new migration instance: %w", err)
+	}
+	return m, nil
+}
+
+// checkDuplicateUserNames fails before the names of users are made unique if different users (by their transport ID) have the same name.
+// Users with the same name and transport ID are merged by the migration, but different users must be renamed or deleted first.
+func checkDuplicateUserNames(db *sqlx.DB, m *migrate.Migrate) error {
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get migration version: %w", err)
+	}
+	if version < usersTableVersion || version >= uniqueUserNamesVersion {
+		return nil
+	}
+
+	var names []string
+	if err = db.Select(&names, "SELECT full_name FROM users GROUP BY full_name HAVING COUNT(DISTINCT transport_id) > 1 ORDER BY full_name"); err != nil {
+		return fmt.Errorf("select duplicate user names: %w", err)
+	}
+	if len(names) > 0 {
+		for i, name := range names {
+			names[i] = fmt.Sprintf("%q", name)
+		}
+		return fmt.Errorf("different users have the same name, rename or delete them in the users table before upgrading: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -1,11 +1,14 @@
 package db
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
+	userDomain "github.com/oriser/bolt/user"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -31,3 +34,78 @@ func (d *DBTest) Cleanup(t *testing.T) {
 	err := d.db.db.Close()
 	assert.NoError(t, err)
 }
+
+// newDBTestAtVersion returns a database migrated up to the given version, and a function migrating it to the latest version
+func newDBTestAtVersion(t *testing.T, version uint) (*sqlx.DB, func() (*DBStore, error)) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+
+	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	require.NoError(t, err)
+	m, err := newMigrate(driver, "")
+	require.NoError(t, err)
+	require.NoError(t, m.Migrate(version))
+
+	return db, func() (*DBStore, error) {
+		return New(db, driver, "")
+	}
+}
+
+func TestMigrateDuplicateUserNames(t *testing.T) {
+	t.Parallel()
+
+	db, migrateUp := newDBTestAtVersion(t, uniqueUserNamesVersion-1)
+	for i, user := range []struct{ id, name, transportID string }{
+		{"first", "Dana Levi", "U1"},
+		{"second", "Dana Levi", "U1"},
+		{"third", "Yossi Cohen", "U2"},
+	} {
+		_, err := db.Exec("INSERT INTO users VALUES (?, ?, '', '', '', ?, ?)", user.id, user.name, user.transportID, time.Now().Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+	}
+	_, err := db.Exec("INSERT INTO debts VALUES ('debt', 'first', 'third', 'order', 10, 'slack', '', ?)", time.Now())
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO debts VALUES ('other-debt', 'third', 'first', 'order', 20, 'slack', '', ?)", time.Now())
+	require.NoError(t, err)
+
+	store, err := migrateUp()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	users, err := store.ListUsers(ctx, userDomain.ListFilter{Names: []string{"Dana Levi"}})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "second", users[0].ID, "the last added user should be kept")
+
+	debts, err := store.ListDebtsForOrderID("order")
+	require.NoError(t, err)
+	require.Len(t, debts, 2)
+	for _, debt := range debts {
+		assert.Contains(t, []string{debt.BorrowerID, debt.LenderID}, "second", "debts of the merged user should be moved to the kept one")
+		assert.NotContains(t, []string{debt.BorrowerID, debt.LenderID}, "first")
+	}
+}
+
+func TestMigrateDuplicateUserNamesOfDifferentUsers(t *testing.T) {
+	t.Parallel()
+
+	db, migrateUp := newDBTestAtVersion(t, uniqueUserNamesVersion-1)
+	for _, user := range []struct{ id, name, transportID string }{
+		{"first", "Dana Levi", "U1"},
+		{"second", "Dana Levi", "U2"},
+	} {
+		_, err := db.Exec("INSERT INTO users VALUES (?, ?, '', '', '', ?, ?)", user.id, user.name, user.transportID, time.Now())
+		require.NoError(t, err)
+	}
+
+	_, err := migrateUp()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"Dana Levi"`)
+
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM users"))
+	assert.Equal(t, 2, count, "users shouldn't be deleted")
+}
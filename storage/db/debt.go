@@ -58,3 +58,35 @@ func (d *DBStore) ListDebtsForOrderID(orderID string) ([]*debt.Debt, error) {
 
 	return debts, nil
 }
+
+func (d *DBStore) ListDebts(filter debt.ListFilter) ([]*debt.Debt, error) {
+	baseSql := sq.Select("*").From("debts")
+
+	sqFilter := sq.Or{}
+	if len(filter.BorrowerIDs) > 0 {
+		sqFilter = append(sqFilter, sq.Eq{"borrower_id": filter.BorrowerIDs})
+	}
+	if len(filter.LenderIDs) > 0 {
+		sqFilter = append(sqFilter, sq.Eq{"lender_id": filter.LenderIDs})
+	}
+
+	if len(sqFilter) > 0 {
+		baseSql = baseSql.Where(sqFilter)
+	}
+	if !filter.Since.IsZero() {
+		baseSql = baseSql.Where(sq.GtOrEq{"created_at": filter.Since})
+	}
+
+	sql, args, err := baseSql.OrderBy("created_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating list SQL: %w", err)
+	}
+
+	debts := []*debt.Debt{}
+	err = d.db.Select(&debts, sql, args...)
+	if err != nil {
+		return nil, newExecError("selecting debts", sql, err, args...)
+	}
+
+	return debts, nil
+}
@@ -165,3 +165,80 @@ func TestAddListForOrderAndRemove(t *testing.T) {
 		})
 	}
 }
+
+func TestListDebts(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	lenderDebts := []*debtDomain.Debt{getDummyDebt().Debt(), getDummyDebt().Debt()}
+	lenderDebts[1].LenderID = lenderDebts[0].LenderID
+	borrowerDebt := getDummyDebt().Debt()
+	otherDebt := getDummyDebt().Debt()
+
+	for _, debt := range append(lenderDebts, borrowerDebt) {
+		require.NoError(t, dbTest.db.AddDebt(debt))
+	}
+	since := time.Now()
+	require.NoError(t, dbTest.db.AddDebt(otherDebt))
+
+	tests := []struct {
+		name          string
+		filter        debtDomain.ListFilter
+		expectedDebts []*debtDomain.Debt
+	}{
+		{
+			name:          "No filter",
+			filter:        debtDomain.ListFilter{},
+			expectedDebts: append(lenderDebts, borrowerDebt, otherDebt),
+		},
+		{
+			name:          "By lender",
+			filter:        debtDomain.ListFilter{LenderIDs: []string{lenderDebts[0].LenderID}},
+			expectedDebts: lenderDebts,
+		},
+		{
+			name:          "By borrower",
+			filter:        debtDomain.ListFilter{BorrowerIDs: []string{borrowerDebt.BorrowerID, "not-exists"}},
+			expectedDebts: []*debtDomain.Debt{borrowerDebt},
+		},
+		{
+			name:          "By lender or borrower",
+			filter:        debtDomain.ListFilter{LenderIDs: []string{lenderDebts[0].LenderID}, BorrowerIDs: []string{borrowerDebt.BorrowerID}},
+			expectedDebts: append(lenderDebts, borrowerDebt),
+		},
+		{
+			name:          "Since",
+			filter:        debtDomain.ListFilter{Since: since},
+			expectedDebts: []*debtDomain.Debt{otherDebt},
+		},
+		{
+			name:          "By lender since",
+			filter:        debtDomain.ListFilter{LenderIDs: []string{lenderDebts[0].LenderID, otherDebt.LenderID}, Since: since},
+			expectedDebts: []*debtDomain.Debt{otherDebt},
+		},
+		{
+			name:          "Nothing matched",
+			filter:        debtDomain.ListFilter{LenderIDs: []string{"not-exists"}},
+			expectedDebts: []*debtDomain.Debt{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			debts, err := dbTest.db.ListDebts(tc.filter)
+			require.NoError(t, err)
+			require.Len(t, debts, len(tc.expectedDebts))
+
+			gotIDs := make([]string, len(debts))
+			for i, debt := range debts {
+				gotIDs[i] = debt.ID
+			}
+			for _, expectedDebt := range tc.expectedDebts {
+				assert.Contains(t, gotIDs, expectedDebt.ID)
+			}
+		})
+	}
+}
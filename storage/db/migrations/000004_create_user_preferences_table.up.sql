@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS user_preferences (
+    transport_id TEXT PRIMARY KEY,
+    reminder_interval INTEGER NOT NULL,
+    quiet_hours_start INTEGER NOT NULL,
+    quiet_hours_end INTEGER NOT NULL,
+    host_paid_notifications BOOLEAN NOT NULL,
+    notification_mode INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL
+);
\ No newline at end of file
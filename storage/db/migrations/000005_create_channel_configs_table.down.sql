@@ -0,0 +1 @@
+DROP TABLE IF EXISTS channel_configs;
\ No newline at end of file
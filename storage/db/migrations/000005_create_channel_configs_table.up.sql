@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS channel_configs (
+    channel_id TEXT PRIMARY KEY,
+    split_strategy INTEGER,
+    dont_join_after TEXT,
+    destination_emoji TEXT,
+    debt_tracking BOOLEAN,
+    debt_reminder_interval INTEGER,
+    debt_maximum_duration INTEGER,
+    progress_updates BOOLEAN,
+    updated_at DATETIME NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS users_full_name_idx;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Names must be unique for matching Wolt participants. Users with the same name and transport ID are the same user added more than once,
+-- so they are merged into the last added of them. Different users with the same name fail the migration before it runs (see checkDuplicateUserNames).
+CREATE TEMP TABLE merged_users AS
+    SELECT duplicate.id AS id, survivor.id AS survivor_id
+    FROM users AS duplicate
+    JOIN users AS survivor ON survivor.rowid = (
+        SELECT MAX(rowid) FROM users WHERE full_name = duplicate.full_name AND transport_id = duplicate.transport_id
+    )
+    WHERE duplicate.rowid != survivor.rowid;
+UPDATE debts SET borrower_id = (SELECT survivor_id FROM merged_users WHERE merged_users.id = debts.borrower_id)
+    WHERE borrower_id IN (SELECT id FROM merged_users);
+UPDATE debts SET lender_id = (SELECT survivor_id FROM merged_users WHERE merged_users.id = debts.lender_id)
+    WHERE lender_id IN (SELECT id FROM merged_users);
+DELETE FROM users WHERE id IN (SELECT id FROM merged_users);
+DROP TABLE merged_users;
+CREATE UNIQUE INDEX IF NOT EXISTS users_full_name_idx ON users (full_name);
\ No newline at end of file
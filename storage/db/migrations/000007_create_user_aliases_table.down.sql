@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_aliases;
\ No newline at end of file
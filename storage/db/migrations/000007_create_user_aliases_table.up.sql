@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS user_aliases (
+    alias TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS user_aliases_user_id_idx ON user_aliases (user_id);
\ No newline at end of file
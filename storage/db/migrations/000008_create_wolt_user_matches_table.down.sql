@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wolt_user_matches;
\ No newline at end of file
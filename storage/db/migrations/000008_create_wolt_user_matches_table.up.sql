@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS wolt_user_matches (
+    wolt_user_id TEXT PRIMARY KEY,
+    transport_id TEXT NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS wolt_user_matches_transport_id_idx ON wolt_user_matches (transport_id);
\ No newline at end of file
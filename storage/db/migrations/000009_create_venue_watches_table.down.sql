@@ -0,0 +1 @@
+DROP TABLE IF EXISTS venue_watches;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS venue_watches (
+    id TEXT PRIMARY KEY,
+    slug TEXT NOT NULL,
+    venue_name TEXT NOT NULL,
+    receiver TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    requested_by TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
\ No newline at end of file
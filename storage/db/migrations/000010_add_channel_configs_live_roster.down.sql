@@ -0,0 +1 @@
+ALTER TABLE channel_configs DROP COLUMN live_roster;
\ No newline at end of file
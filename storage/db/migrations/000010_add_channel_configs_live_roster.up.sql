@@ -0,0 +1 @@
+ALTER TABLE channel_configs ADD COLUMN live_roster BOOLEAN;
\ No newline at end of file
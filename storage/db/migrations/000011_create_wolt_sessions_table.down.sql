@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wolt_sessions;
\ No newline at end of file
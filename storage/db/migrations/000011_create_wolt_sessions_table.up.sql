@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS wolt_sessions (
+    account TEXT PRIMARY KEY,
+    refresh_token BLOB NOT NULL,
+    updated_at DATETIME NOT NULL
+);
\ No newline at end of file
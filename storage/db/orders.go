@@ -42,3 +42,42 @@ func (d *DBStore) SaveOrder(_ context.Context, order *order.Order) error {
 
 	return nil
 }
+
+func (d *DBStore) ListOrders(_ context.Context, filter order.ListFilter) ([]*order.Order, error) {
+	baseSql := sq.Select("*").From("orders")
+
+	sqFilter := sq.And{}
+	if filter.Receiver != "" {
+		sqFilter = append(sqFilter, sq.Eq{"receiver": filter.Receiver})
+	}
+	if !filter.Since.IsZero() {
+		sqFilter = append(sqFilter, sq.GtOrEq{"db_created_at": filter.Since})
+	}
+
+	if len(sqFilter) > 0 {
+		baseSql = baseSql.Where(sqFilter)
+	}
+
+	sql, args, err := baseSql.OrderBy("db_created_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating list SQL: %w", err)
+	}
+
+	var orders []*orderModel
+	err = d.db.Select(&orders, sql, args...)
+	if err != nil {
+		return nil, newExecError("selecting orders", sql, err, args...)
+	}
+
+	ret := make([]*order.Order, len(orders))
+	for i, model := range orders {
+		if len(model.MarshaledParticipants) > 0 {
+			if err = json.Unmarshal(model.MarshaledParticipants, &model.Participants); err != nil {
+				return nil, fmt.Errorf("unmarshal participants of order %q: %w", model.ID, err)
+			}
+		}
+		ret[i] = model.Order
+	}
+
+	return ret, nil
+}
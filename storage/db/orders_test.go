@@ -88,3 +88,63 @@ func TestSaveOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestListOrders(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	oldOrder := getDummyOrder()
+	require.NoError(t, dbTest.db.SaveOrder(ctx, oldOrder))
+	_, err := dbTest.db.db.Exec("UPDATE orders SET db_created_at=? WHERE id=?", time.Now().Add(-48*time.Hour), oldOrder.ID)
+	require.NoError(t, err)
+
+	newOrder := getDummyOrder()
+	require.NoError(t, dbTest.db.SaveOrder(ctx, newOrder))
+
+	otherReceiverOrder := getDummyOrder()
+	otherReceiverOrder.Receiver = "other"
+	require.NoError(t, dbTest.db.SaveOrder(ctx, otherReceiverOrder))
+
+	tests := []struct {
+		name        string
+		filter      order.ListFilter
+		expectedIDs []string
+	}{
+		{
+			name:        "No filter",
+			expectedIDs: []string{oldOrder.ID, newOrder.ID, otherReceiverOrder.ID},
+		},
+		{
+			name:        "By receiver",
+			filter:      order.ListFilter{Receiver: "receiver"},
+			expectedIDs: []string{oldOrder.ID, newOrder.ID},
+		},
+		{
+			name:        "Since",
+			filter:      order.ListFilter{Since: time.Now().Add(-24 * time.Hour)},
+			expectedIDs: []string{newOrder.ID, otherReceiverOrder.ID},
+		},
+		{
+			name:        "By receiver and since",
+			filter:      order.ListFilter{Receiver: "other", Since: time.Now().Add(-24 * time.Hour)},
+			expectedIDs: []string{otherReceiverOrder.ID},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			orders, err := dbTest.db.ListOrders(ctx, tc.filter)
+			require.NoError(t, err)
+			require.Len(t, orders, len(tc.expectedIDs))
+
+			for i, gotOrder := range orders {
+				assert.Equal(t, tc.expectedIDs[i], gotOrder.ID)
+				assert.Equal(t, newOrder.Participants, gotOrder.Participants)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	userDomain "github.com/oriser/bolt/user"
+)
+
+type preferencesModel struct {
+	*userDomain.Preferences
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// GetPreferences returns the preferences of the user, or the default preferences if the user never changed them
+func (d *DBStore) GetPreferences(_ context.Context, transportID string) (*userDomain.Preferences, error) {
+	sql, args, err := sq.Select("*").From("user_preferences").Where("transport_id=?", transportID).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	var preferences []*preferencesModel
+	err = d.db.Select(&preferences, sql, args...)
+	if err != nil {
+		return nil, newExecError("selecting preferences", sql, err, args...)
+	}
+
+	if len(preferences) == 0 {
+		return userDomain.DefaultPreferences(transportID), nil
+	}
+
+	return preferences[0].Preferences, nil
+}
+
+func (d *DBStore) SavePreferences(_ context.Context, preferences *userDomain.Preferences) error {
+	if preferences == nil {
+		return fmt.Errorf("nil preferences")
+	}
+	if preferences.TransportID == "" {
+		return fmt.Errorf("empty transport ID")
+	}
+	model := &preferencesModel{Preferences: preferences, UpdatedAt: time.Now()}
+
+	sql, args, err := sq.Replace("user_preferences").Values(model.TransportID, model.ReminderInterval, model.QuietHoursStart,
+		model.QuietHoursEnd, model.HostPaidNotifications, model.NotificationMode, model.UpdatedAt).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating replace SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("saving preferences", sql, err, args...)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferences(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		preferences []*userDomain.Preferences
+	}{
+		{
+			name:        "Defaults",
+			preferences: nil,
+		},
+		{
+			name: "Save once",
+			preferences: []*userDomain.Preferences{
+				{
+					ReminderInterval:      6 * time.Hour,
+					QuietHoursStart:       22,
+					QuietHoursEnd:         8,
+					HostPaidNotifications: false,
+					NotificationMode:      userDomain.NotificationModeDigest,
+				},
+			},
+		},
+		{
+			name: "Override",
+			preferences: []*userDomain.Preferences{
+				{
+					ReminderInterval:      6 * time.Hour,
+					QuietHoursStart:       22,
+					QuietHoursEnd:         8,
+					HostPaidNotifications: false,
+					NotificationMode:      userDomain.NotificationModeDigest,
+				},
+				{
+					ReminderInterval:      12 * time.Hour,
+					QuietHoursStart:       0,
+					QuietHoursEnd:         0,
+					HostPaidNotifications: true,
+					NotificationMode:      userDomain.NotificationModeImmediate,
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transportID := randomStringAlpha(6)
+
+			expected := userDomain.DefaultPreferences(transportID)
+			for _, preferences := range tc.preferences {
+				preferences.TransportID = transportID
+				require.NoError(t, dbTest.db.SavePreferences(ctx, preferences))
+				expected = preferences
+			}
+
+			got, err := dbTest.db.GetPreferences(ctx, transportID)
+			require.NoError(t, err)
+			assert.Equal(t, expected, got)
+		})
+	}
+
+	t.Run("Empty transport ID", func(t *testing.T) {
+		err := dbTest.db.SavePreferences(ctx, &userDomain.Preferences{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/oriser/bolt/session"
+)
+
+// GetSession returns the session of the account, or nil if it never signed in
+func (d *DBStore) GetSession(_ context.Context, account string) (*session.Session, error) {
+	sql, args, err := sq.Select("*").From("wolt_sessions").Where("account=?", account).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	var sessions []*session.Session
+	if err = d.db.Select(&sessions, sql, args...); err != nil {
+		return nil, newExecError("selecting session", sql, err, args...)
+	}
+
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return sessions[0], nil
+}
+
+func (d *DBStore) SaveSession(_ context.Context, s *session.Session) error {
+	if s == nil {
+		return fmt.Errorf("nil session")
+	}
+	if s.Account == "" {
+		return fmt.Errorf("empty account")
+	}
+	s.UpdatedAt = time.Now()
+
+	sql, args, err := sq.Replace("wolt_sessions").Columns("account", "refresh_token", "updated_at").
+		Values(s.Account, s.RefreshToken, s.UpdatedAt).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating replace SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("saving session", sql, err, args...)
+	}
+	return nil
+}
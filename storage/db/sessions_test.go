@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oriser/bolt/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+
+	s, err := dbTest.db.GetSession(ctx, "bot")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	require.NoError(t, dbTest.db.SaveSession(ctx, &session.Session{Account: "bot", RefreshToken: []byte("first")}))
+	require.NoError(t, dbTest.db.SaveSession(ctx, &session.Session{Account: "other", RefreshToken: []byte("other")}))
+	require.NoError(t, dbTest.db.SaveSession(ctx, &session.Session{Account: "bot", RefreshToken: []byte("rotated")}))
+
+	s, err = dbTest.db.GetSession(ctx, "bot")
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	assert.Equal(t, []byte("rotated"), s.RefreshToken)
+	assert.False(t, s.UpdatedAt.IsZero())
+
+	assert.Error(t, dbTest.db.SaveSession(ctx, &session.Session{RefreshToken: []byte("no account")}))
+}
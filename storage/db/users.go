@@ -2,11 +2,15 @@ package db
 
 import (
 	"context"
+	gosql "database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
 	userDomain "github.com/oriser/bolt/user"
 )
 
@@ -22,6 +26,11 @@ func (d *DBStore) AddUser(_ context.Context, user *userDomain.User) error {
 	if user.ID == "" {
 		user.ID = uuid.NewString()
 	}
+	if taken, err := d.isNameTaken(user.FullName, user.ID); err != nil {
+		return fmt.Errorf("check name: %w", err)
+	} else if taken {
+		return &userDomain.ErrAlreadyExists{Name: user.FullName}
+	}
 	model := &userModel{User: user, CreatedAt: time.Now()}
 
 	sql, args, err := sq.Insert("users").Values(model.ID, model.FullName, model.Email, model.Phone,
@@ -31,12 +40,113 @@ func (d *DBStore) AddUser(_ context.Context, user *userDomain.User) error {
 	}
 
 	if _, err = d.db.Exec(sql, args...); err != nil {
+		if isUniqueConstraintError(err, "users.full_name") {
+			return &userDomain.ErrAlreadyExists{Name: user.FullName}
+		}
 		return newExecError("adding user", sql, err, args...)
 	}
 
 	return nil
 }
 
+// uniqueConstraintErrorPrefix prefixes the columns of the violated unique constraint in SQLite errors
+const uniqueConstraintErrorPrefix = "UNIQUE constraint failed: "
+
+// isUniqueConstraintError returns whether the error is a violation of the unique constraint on exactly the given column (as <table>.<column>)
+func isUniqueConstraintError(err error, column string) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.TrimPrefix(sqliteErr.Error(), uniqueConstraintErrorPrefix) == column
+}
+
+// isNameTaken returns whether the name is already used as a name or an alias of a user other than exceptUserID
+func (d *DBStore) isNameTaken(name, exceptUserID string) (bool, error) {
+	sql, args, err := sq.Select("COUNT(*)").From("users").Where(sq.And{
+		sq.Eq{"full_name": name},
+		sq.NotEq{"id": exceptUserID},
+	}).ToSql()
+	if err != nil {
+		return false, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	var count int
+	if err = d.db.Get(&count, sql, args...); err != nil {
+		return false, newExecError("counting users", sql, err, args...)
+	}
+	if count > 0 {
+		return true, nil
+	}
+	return d.isAlias(name, exceptUserID)
+}
+
+func (d *DBStore) UpdateUser(_ context.Context, user *userDomain.User) error {
+	if user == nil {
+		return fmt.Errorf("nil user")
+	}
+	if user.ID == "" {
+		return fmt.Errorf("empty user ID")
+	}
+	if taken, err := d.isNameTaken(user.FullName, user.ID); err != nil {
+		return fmt.Errorf("check name: %w", err)
+	} else if taken {
+		return &userDomain.ErrAlreadyExists{Name: user.FullName}
+	}
+
+	sql, args, err := sq.Update("users").SetMap(map[string]interface{}{
+		"full_name":    user.FullName,
+		"email":        user.Email,
+		"phone":        user.Phone,
+		"timezone":     user.Timezone,
+		"transport_id": user.TransportID,
+	}).Where("id=?", user.ID).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating update SQL: %w", err)
+	}
+
+	res, err := d.db.Exec(sql, args...)
+	if err != nil {
+		if isUniqueConstraintError(err, "users.full_name") {
+			return &userDomain.ErrAlreadyExists{Name: user.FullName}
+		}
+		return newExecError("updating user", sql, err, args...)
+	}
+
+	return expectAffectedUser(res, user.ID)
+}
+
+func (d *DBStore) DeleteUser(_ context.Context, id string) error {
+	sql, args, err := sq.Delete("user_aliases").Where("user_id=?", id).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating delete aliases SQL: %w", err)
+	}
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("deleting user aliases", sql, err, args...)
+	}
+
+	sql, args, err = sq.Delete("users").Where("id=?", id).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating delete SQL: %w", err)
+	}
+
+	res, err := d.db.Exec(sql, args...)
+	if err != nil {
+		return newExecError("deleting user", sql, err, args...)
+	}
+
+	return expectAffectedUser(res, id)
+}
+
+func expectAffectedUser(res gosql.Result, id string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("user with ID %s not found", id)
+	}
+	return nil
+}
+
 func (d *DBStore) GetUser(_ context.Context, id string) (*userDomain.User, error) {
 	sql, args, err := sq.Select("*").From("users").Where("id=?", id).ToSql()
 	if err != nil {
@@ -65,11 +175,36 @@ func (d *DBStore) ListUsers(_ context.Context, filter userDomain.ListFilter) ([]
 	sqFilter := sq.Or{}
 	if len(filter.Names) > 0 {
 		sqFilter = append(sqFilter, sq.Eq{"full_name": filter.Names})
+
+		aliasesSql, aliasesArgs, err := sq.Select("user_id").From("user_aliases").Where(sq.Eq{"alias": filter.Names}).ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("generating aliases SQL: %w", err)
+		}
+		sqFilter = append(sqFilter, sq.Expr(fmt.Sprintf("id IN (%s)", aliasesSql), aliasesArgs...))
 	}
 	if filter.TransportID != "" {
 		sqFilter = append(sqFilter, sq.Eq{"transport_id": filter.TransportID})
 	}
+	// Wolt users which were never matched have no match, so empty Wolt user IDs match nothing
+	if woltUserIDs := nonEmpty(filter.WoltUserIDs); len(woltUserIDs) > 0 {
+		matchesSql, matchesArgs, err := sq.Select("transport_id").From("wolt_user_matches").Where(sq.Eq{"wolt_user_id": woltUserIDs}).ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("generating Wolt matches SQL: %w", err)
+		}
+		sqFilter = append(sqFilter, sq.Expr(fmt.Sprintf("transport_id IN (%s)", matchesSql), matchesArgs...))
+	}
+	// Users without an email have an empty email
+	if emails := nonEmpty(filter.Emails); len(emails) > 0 {
+		for i, email := range emails {
+			emails[i] = strings.ToLower(email)
+		}
+		sqFilter = append(sqFilter, sq.Eq{"LOWER(email)": emails})
+	}
 
+	if len(sqFilter) == 0 && len(filter.WoltUserIDs)+len(filter.Emails) > 0 {
+		// Filtered just by empty Wolt user IDs or emails, which shouldn't match anything
+		return []*userDomain.User{}, nil
+	}
 	if len(sqFilter) > 0 {
 		baseSql = baseSql.Where(sqFilter)
 	}
@@ -92,3 +227,28 @@ func (d *DBStore) ListUsers(_ context.Context, filter userDomain.ListFilter) ([]
 
 	return ret, nil
 }
+
+// FindCandidates returns the users with exactly the given name or alias, as custom users don't have partial matches
+func (d *DBStore) FindCandidates(ctx context.Context, name string) ([]*userDomain.Candidate, error) {
+	users, err := d.ListUsers(ctx, userDomain.ListFilter{Names: []string{name}})
+	if err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+
+	candidates := make([]*userDomain.Candidate, len(users))
+	for i, user := range users {
+		candidates[i] = &userDomain.Candidate{User: user, Score: 100}
+	}
+	return candidates, nil
+}
+
+// nonEmpty returns a copy of the values without the empty ones
+func nonEmpty(values []string) []string {
+	ret := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			ret = append(ret, value)
+		}
+	}
+	return ret
+}
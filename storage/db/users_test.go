@@ -204,3 +204,219 @@ func TestAddUser(t *testing.T) {
 		})
 	}
 }
+
+func TestAddUserDuplicateName(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	user := getDummyUser().User()
+	require.NoError(t, dbTest.db.AddUser(ctx, user))
+
+	duplicate := getDummyUser().User()
+	duplicate.FullName = user.FullName
+	err := dbTest.db.AddUser(ctx, duplicate)
+	var alreadyExists *userDomain.ErrAlreadyExists
+	require.ErrorAs(t, err, &alreadyExists)
+	assert.Equal(t, user.FullName, alreadyExists.Name)
+
+	users, err := dbTest.db.ListUsers(ctx, userDomain.ListFilter{Names: []string{user.FullName}})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{user}, users)
+}
+
+func TestIsUniqueConstraintError(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	insert := "INSERT INTO users (id, full_name, email, phone, timezone, transport_id, created_at) VALUES (?, 'Dana Levi', '', '', '', '', ?)"
+	_, err := dbTest.db.db.Exec(insert, "first", time.Now())
+	require.NoError(t, err)
+	_, err = dbTest.db.db.Exec(insert, "second", time.Now())
+	require.Error(t, err)
+
+	assert.True(t, isUniqueConstraintError(err, "users.full_name"))
+	assert.False(t, isUniqueConstraintError(err, "users.full"), "only the exact column should match")
+	assert.False(t, isUniqueConstraintError(fmt.Errorf("UNIQUE constraint failed: users.full_name"), "users.full_name"), "only SQLite errors should match")
+}
+
+func TestUpdateUser(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	user := getDummyUser().User()
+	other := getDummyUser().User()
+	require.NoError(t, dbTest.db.AddUser(ctx, user))
+	require.NoError(t, dbTest.db.AddUser(ctx, other))
+
+	t.Run("Rename", func(t *testing.T) {
+		user.FullName = fmt.Sprintf("%s %s", randomStringAlpha(5), randomStringAlpha(5))
+		require.NoError(t, dbTest.db.UpdateUser(ctx, user))
+
+		got, err := dbTest.db.GetUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("Rename to existing name", func(t *testing.T) {
+		renamed := *user
+		renamed.FullName = other.FullName
+		var alreadyExists *userDomain.ErrAlreadyExists
+		require.ErrorAs(t, dbTest.db.UpdateUser(ctx, &renamed), &alreadyExists)
+
+		got, err := dbTest.db.GetUser(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		notFound := getDummyUser().WithCustomID(randomStringAlpha(5)).User()
+		assert.Error(t, dbTest.db.UpdateUser(ctx, notFound))
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	user := getDummyUser().User()
+	other := getDummyUser().User()
+	require.NoError(t, dbTest.db.AddUser(ctx, user))
+	require.NoError(t, dbTest.db.AddUser(ctx, other))
+
+	require.NoError(t, dbTest.db.DeleteUser(ctx, user.ID))
+	_, err := dbTest.db.GetUser(ctx, user.ID)
+	assert.Error(t, err)
+
+	users, err := dbTest.db.ListUsers(ctx, userDomain.ListFilter{})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{other}, users)
+
+	// The name can be used again after the deletion
+	sameName := getDummyUser().User()
+	sameName.FullName = user.FullName
+	require.NoError(t, dbTest.db.AddUser(ctx, sameName))
+
+	// Already deleted
+	assert.Error(t, dbTest.db.DeleteUser(ctx, user.ID))
+}
+
+func TestListUsersByEmail(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	user := getDummyUser().User()
+	other := getDummyUser().User()
+	noEmail := getDummyUser().User()
+	noEmail.Email = ""
+	for _, u := range []*userDomain.User{user, other, noEmail} {
+		require.NoError(t, dbTest.db.AddUser(ctx, u))
+	}
+
+	// Emails are matched case insensitive
+	users, err := dbTest.db.ListUsers(ctx, userDomain.ListFilter{Emails: []string{strings.ToUpper(user.Email)}})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{user}, users)
+
+	users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{Emails: []string{user.Email, other.Email, ""}})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{user, other}, users)
+
+	// Empty emails shouldn't match users without an email
+	users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{Emails: []string{""}})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestFindCandidates(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	user := getDummyUser().User()
+	other := getDummyUser().User()
+	require.NoError(t, dbTest.db.AddUser(ctx, user))
+	require.NoError(t, dbTest.db.AddUser(ctx, other))
+	alias := randomStringAlpha(8)
+	require.NoError(t, dbTest.db.AddAlias(ctx, user.ID, alias))
+
+	for _, name := range []string{user.FullName, alias} {
+		candidates, err := dbTest.db.FindCandidates(ctx, name)
+		require.NoError(t, err)
+		require.Len(t, candidates, 1)
+		assert.Equal(t, 100, candidates[0].Score)
+		testExpectedUsers(t, []*userDomain.User{user}, []*userDomain.User{candidates[0].User})
+	}
+
+	// Custom users don't have partial matches
+	candidates, err := dbTest.db.FindCandidates(ctx, user.FullName[:len(user.FullName)-1])
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+}
+
+func TestListUsersByWoltUserID(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+	matched := getDummyUser().User()
+	otherMatched := getDummyUser().User()
+	notMatched := getDummyUser().User()
+	for _, user := range []*userDomain.User{matched, otherMatched, notMatched} {
+		require.NoError(t, dbTest.db.AddUser(ctx, user))
+	}
+	matchedWoltUserID, otherMatchedWoltUserID := randomStringAlpha(24), randomStringAlpha(24)
+	require.NoError(t, dbTest.db.SaveWoltMatch(ctx, matchedWoltUserID, matched.TransportID))
+	require.NoError(t, dbTest.db.SaveWoltMatch(ctx, otherMatchedWoltUserID, otherMatched.TransportID))
+
+	users, err := dbTest.db.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{matchedWoltUserID}})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{matched}, users)
+
+	users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{matchedWoltUserID, otherMatchedWoltUserID}})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{matched, otherMatched}, users)
+
+	users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{matchedWoltUserID}, Names: []string{notMatched.FullName}})
+	require.NoError(t, err)
+	testExpectedUsers(t, []*userDomain.User{matched, notMatched}, users)
+
+	// Empty or unknown Wolt user IDs shouldn't match users which were never matched
+	users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{""}})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	users, err = dbTest.db.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{randomStringAlpha(24)}})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/oriser/bolt/venue"
+)
+
+func (d *DBStore) AddVenueWatch(_ context.Context, watch *venue.Watch) error {
+	if watch == nil {
+		return fmt.Errorf("nil venue watch")
+	}
+	if watch.ID == "" {
+		watch.ID = uuid.NewString()
+	}
+	watch.CreatedAt = time.Now()
+
+	sql, args, err := sq.Insert("venue_watches").Values(watch.ID, watch.Slug, watch.VenueName, watch.Receiver,
+		watch.MessageID, watch.RequestedBy, watch.CreatedAt, watch.ExpiresAt).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating insert SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("adding venue watch", sql, err, args...)
+	}
+	return nil
+}
+
+func (d *DBStore) DeleteVenueWatch(_ context.Context, id string) error {
+	sql, args, err := sq.Delete("venue_watches").Where("id=?", id).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating delete SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("deleting venue watch", sql, err, args...)
+	}
+	return nil
+}
+
+func (d *DBStore) ListVenueWatches(_ context.Context) ([]*venue.Watch, error) {
+	sql, args, err := sq.Select("*").From("venue_watches").OrderBy("created_at").ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	watches := []*venue.Watch{}
+	if err = d.db.Select(&watches, sql, args...); err != nil {
+		return nil, newExecError("selecting venue watches", sql, err, args...)
+	}
+	return watches, nil
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/venue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVenueWatches(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+
+	watches, err := dbTest.db.ListVenueWatches(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, watches)
+
+	expiresAt := time.Now().Add(time.Hour).Round(0)
+	first := &venue.Watch{Slug: "tasty-venue", VenueName: "A Tasty Venue", Receiver: "channel", MessageID: "123.456", RequestedBy: "user", ExpiresAt: expiresAt}
+	second := &venue.Watch{Slug: "another-venue", VenueName: "Another Venue", Receiver: "channel", RequestedBy: "user", ExpiresAt: expiresAt}
+	require.NoError(t, dbTest.db.AddVenueWatch(ctx, first))
+	require.NoError(t, dbTest.db.AddVenueWatch(ctx, second))
+	assert.NotEmpty(t, first.ID)
+
+	watches, err = dbTest.db.ListVenueWatches(ctx)
+	require.NoError(t, err)
+	require.Len(t, watches, 2)
+	assert.Equal(t, first.ID, watches[0].ID)
+	assert.Equal(t, first.Slug, watches[0].Slug)
+	assert.Equal(t, first.MessageID, watches[0].MessageID)
+	assert.True(t, expiresAt.Equal(watches[0].ExpiresAt))
+	assert.Equal(t, second.ID, watches[1].ID)
+
+	require.NoError(t, dbTest.db.DeleteVenueWatch(ctx, first.ID))
+	watches, err = dbTest.db.ListVenueWatches(ctx)
+	require.NoError(t, err)
+	require.Len(t, watches, 1)
+	assert.Equal(t, second.ID, watches[0].ID)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// GetWoltMatch returns the transport ID of the user matched to the Wolt user, or an empty string if the Wolt user was never matched
+func (d *DBStore) GetWoltMatch(_ context.Context, woltUserID string) (string, error) {
+	sql, args, err := sq.Select("transport_id").From("wolt_user_matches").Where("wolt_user_id=?", woltUserID).ToSql()
+	if err != nil {
+		return "", fmt.Errorf("generating select SQL: %w", err)
+	}
+
+	var transportIDs []string
+	if err = d.db.Select(&transportIDs, sql, args...); err != nil {
+		return "", newExecError("selecting Wolt match", sql, err, args...)
+	}
+
+	if len(transportIDs) == 0 {
+		return "", nil
+	}
+	return transportIDs[0], nil
+}
+
+func (d *DBStore) SaveWoltMatch(_ context.Context, woltUserID, transportID string) error {
+	if woltUserID == "" {
+		return fmt.Errorf("empty Wolt user ID")
+	}
+	if transportID == "" {
+		return fmt.Errorf("empty transport ID")
+	}
+
+	sql, args, err := sq.Replace("wolt_user_matches").Columns("wolt_user_id", "transport_id", "updated_at").
+		Values(woltUserID, transportID, time.Now()).ToSql()
+	if err != nil {
+		return fmt.Errorf("generating replace SQL: %w", err)
+	}
+
+	if _, err = d.db.Exec(sql, args...); err != nil {
+		return newExecError("saving Wolt match", sql, err, args...)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWoltMatches(t *testing.T) {
+	t.Parallel()
+
+	dbTest := NewDBTest(t)
+	t.Cleanup(func() {
+		dbTest.Cleanup(t)
+	})
+
+	ctx := context.Background()
+
+	transportID, err := dbTest.db.GetWoltMatch(ctx, "wolt-1")
+	require.NoError(t, err)
+	assert.Empty(t, transportID)
+
+	require.NoError(t, dbTest.db.SaveWoltMatch(ctx, "wolt-1", "U1"))
+	require.NoError(t, dbTest.db.SaveWoltMatch(ctx, "wolt-2", "U2"))
+	require.NoError(t, dbTest.db.SaveWoltMatch(ctx, "wolt-1", "U3"))
+
+	transportID, err = dbTest.db.GetWoltMatch(ctx, "wolt-1")
+	require.NoError(t, err)
+	assert.Equal(t, "U3", transportID)
+
+	transportID, err = dbTest.db.GetWoltMatch(ctx, "wolt-2")
+	require.NoError(t, err)
+	assert.Equal(t, "U2", transportID)
+
+	assert.Error(t, dbTest.db.SaveWoltMatch(ctx, "", "U1"))
+	assert.Error(t, dbTest.db.SaveWoltMatch(ctx, "wolt-3", ""))
+}
@@ -0,0 +1,397 @@
+package file
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oriser/bolt/storage/names"
+	userDomain "github.com/oriser/bolt/user"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	// Path of a YAML (.yaml/.yml) or CSV (.csv) file with the users, empty for not using a users file
+	Path           string        `env:"USERS_FILE"`
+	ReloadInterval time.Duration `env:"USERS_FILE_RELOAD_INTERVAL" envDefault:"10s"`
+	// FuzzyMinimumScore is the minimum score (0-100) for a user from the file to be a candidate of a name
+	FuzzyMinimumScore int `env:"USERS_FILE_FUZZY_MINIMUM_SCORE" envDefault:"75"`
+	// Nicknames are replaced by their full names when comparing names, in addition to names.DefaultNicknames
+	Nicknames map[string]string
+	// WoltMatches are the saved matches of Wolt users, for listing users by their Wolt user IDs
+	WoltMatches userDomain.WoltMatchStore `json:"-"`
+}
+
+// entry is a single user in the file
+type entry struct {
+	Name        string   `yaml:"name"`
+	Aliases     []string `yaml:"aliases"`
+	Email       string   `yaml:"email"`
+	Phone       string   `yaml:"phone"`
+	Timezone    string   `yaml:"timezone"`
+	TransportID string   `yaml:"transport_id"`
+	Payment     []string `yaml:"payment"`
+}
+
+type yamlFile struct {
+	Users []entry `yaml:"users"`
+}
+
+// csvSeparator separates multiple values (aliases, payment methods) in a single CSV column
+const csvSeparator = ";"
+
+type fileUser struct {
+	user    *userDomain.User
+	aliases []string
+}
+
+// FileStorage is a read only user store of the users listed in a file, reloaded whenever the file changes.
+// Like users from Slack, the ID of the users is their transport ID.
+type FileStorage struct {
+	path              string
+	reloadInterval    time.Duration
+	fuzzyMinimumScore int
+	normalizer        *names.Normalizer
+	woltMatches       userDomain.WoltMatchStore
+
+	lock    sync.RWMutex
+	users   []fileUser
+	modTime time.Time
+	size    int64
+}
+
+func New(cfg Config) (*FileStorage, error) {
+	s := &FileStorage{
+		path:              cfg.Path,
+		reloadInterval:    cfg.ReloadInterval,
+		fuzzyMinimumScore: cfg.FuzzyMinimumScore,
+		normalizer:        names.NewNormalizer(cfg.Nicknames),
+		woltMatches:       cfg.WoltMatches,
+	}
+	if _, err := s.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run reloads the file every ReloadInterval if it changed, until the context is done.
+// If the changed file can't be loaded, the users loaded before are kept.
+func (s *FileStorage) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := s.reloadIfChanged()
+			if err != nil {
+				log.Printf("Error reloading users file %s: %v\n", s.path, err)
+			} else if reloaded {
+				log.Printf("Reloaded users file %s\n", s.path)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reloadIfChanged loads the users from the file if it was modified since it was last loaded, returning whether it was loaded
+func (s *FileStorage) reloadIfChanged() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("stat users file: %w", err)
+	}
+
+	s.lock.RLock()
+	changed := !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+	s.lock.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	users, err := load(s.path)
+	if err != nil {
+		return false, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.users = users
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return true, nil
+}
+
+func load(path string) ([]fileUser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open users file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		entries, err = parseYAML(f)
+	case ".csv":
+		entries, err = parseCSV(f)
+	default:
+		return nil, fmt.Errorf("unknown users file type %q, expected .yaml, .yml or .csv", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse users file: %w", err)
+	}
+
+	users := make([]fileUser, len(entries))
+	transportIDs := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		user, err := e.toUser()
+		if err != nil {
+			return nil, fmt.Errorf("user #%d: %w", i+1, err)
+		}
+		if transportIDs[user.TransportID] {
+			return nil, fmt.Errorf("user #%d: more than one user with transport ID %s", i+1, user.TransportID)
+		}
+		transportIDs[user.TransportID] = true
+		users[i] = fileUser{user: user, aliases: trimAll(e.Aliases)}
+	}
+	return users, nil
+}
+
+func parseYAML(r io.Reader) ([]entry, error) {
+	var parsed yamlFile
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&parsed); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return parsed.Users, nil
+}
+
+// parseCSV parses a CSV file with a header row, the columns can be in any order and only name and transport_id are required
+func parseCSV(r io.Reader) ([]entry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	entries := make([]entry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		var e entry
+		for i, column := range header {
+			value := strings.TrimSpace(record[i])
+			switch strings.ToLower(strings.TrimSpace(column)) {
+			case "name":
+				e.Name = value
+			case "aliases":
+				e.Aliases = splitValues(value)
+			case "email":
+				e.Email = value
+			case "phone":
+				e.Phone = value
+			case "timezone":
+				e.Timezone = value
+			case "transport_id":
+				e.TransportID = value
+			case "payment":
+				e.Payment = splitValues(value)
+			default:
+				return nil, fmt.Errorf("unknown column %q", column)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func splitValues(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, csvSeparator)
+}
+
+func trimAll(values []string) []string {
+	ret := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			ret = append(ret, value)
+		}
+	}
+	return ret
+}
+
+func (e entry) toUser() (*userDomain.User, error) {
+	name := strings.TrimSpace(e.Name)
+	if name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	transportID := strings.TrimSpace(e.TransportID)
+	if transportID == "" {
+		return nil, fmt.Errorf("missing transport ID of %s", name)
+	}
+	timezone := strings.TrimSpace(e.Timezone)
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("timezone of %s: %w", name, err)
+		}
+	}
+
+	var payments []userDomain.PaymentMethod
+	for _, method := range trimAll(e.Payment) {
+		payment, err := userDomain.ParsePaymentMethod(method)
+		if err != nil {
+			return nil, fmt.Errorf("payment preferences of %s: %w", name, err)
+		}
+		payments = append(payments, payment)
+	}
+
+	return &userDomain.User{
+		ID:                 transportID,
+		FullName:           name,
+		Email:              strings.TrimSpace(e.Email),
+		Phone:              strings.TrimSpace(e.Phone),
+		PaymentPreferences: payments,
+		Timezone:           timezone,
+		TransportID:        transportID,
+	}, nil
+}
+
+// snapshot returns the currently loaded users, which aren't changed by reloads
+func (s *FileStorage) snapshot() []fileUser {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.users
+}
+
+func (s *FileStorage) AddUser(_ context.Context, _ *userDomain.User) error {
+	return fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) UpdateUser(_ context.Context, _ *userDomain.User) error {
+	return fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) DeleteUser(_ context.Context, _ string) error {
+	return fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) AddAlias(_ context.Context, _, _ string) error {
+	return fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) RemoveAlias(_ context.Context, _ string) error {
+	return fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) GetPreferences(_ context.Context, _ string) (*userDomain.Preferences, error) {
+	return nil, fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) SavePreferences(_ context.Context, _ *userDomain.Preferences) error {
+	return fmt.Errorf("not implemented for file storage")
+}
+
+func (s *FileStorage) ListAliases(_ context.Context, userID string) ([]string, error) {
+	for _, u := range s.snapshot() {
+		if u.user.ID == userID {
+			return u.aliases, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (s *FileStorage) GetUser(_ context.Context, id string) (*userDomain.User, error) {
+	for _, u := range s.snapshot() {
+		if u.user.ID == id {
+			user := *u.user
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+// matches returns whether the user matches any of the filter's fields, names are compared case insensitively.
+// matchedTransportIDs are the transport IDs of the users the filter's Wolt users were matched to.
+func (u fileUser) matches(filter userDomain.ListFilter, matchedTransportIDs map[string]bool) bool {
+	for _, name := range filter.Names {
+		if strings.EqualFold(u.user.FullName, strings.TrimSpace(name)) {
+			return true
+		}
+		for _, alias := range u.aliases {
+			if strings.EqualFold(alias, strings.TrimSpace(name)) {
+				return true
+			}
+		}
+	}
+	if filter.TransportID != "" && u.user.TransportID == filter.TransportID {
+		return true
+	}
+	if u.user.TransportID != "" && matchedTransportIDs[u.user.TransportID] {
+		return true
+	}
+	for _, email := range filter.Emails {
+		if email != "" && strings.EqualFold(u.user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *FileStorage) ListUsers(ctx context.Context, filter userDomain.ListFilter) ([]*userDomain.User, error) {
+	users := make([]*userDomain.User, 0)
+	if filter.CustomOnly {
+		// Users from the file are an external directory, rather than users added manually
+		return users, nil
+	}
+
+	matchedTransportIDs, err := userDomain.MatchedTransportIDs(ctx, s.woltMatches, filter.WoltUserIDs)
+	if err != nil {
+		return nil, fmt.Errorf("matched transport IDs: %w", err)
+	}
+
+	all := len(filter.Names)+len(filter.WoltUserIDs)+len(filter.Emails) == 0 && filter.TransportID == ""
+	for _, u := range s.snapshot() {
+		if all || u.matches(filter, matchedTransportIDs) {
+			user := *u.user
+			users = append(users, &user)
+		}
+	}
+	return users, nil
+}
+
+// FindCandidates returns the users whose name or one of the aliases is similar to the given name, best matches first
+func (s *FileStorage) FindCandidates(_ context.Context, name string) ([]*userDomain.Candidate, error) {
+	candidates := make([]*userDomain.Candidate, 0)
+	for _, u := range s.snapshot() {
+		score := s.normalizer.Score(name, u.user.FullName)
+		for _, alias := range u.aliases {
+			if aliasScore := s.normalizer.Score(name, alias); aliasScore > score {
+				score = aliasScore
+			}
+		}
+		if score >= s.fuzzyMinimumScore {
+			user := *u.user
+			candidates = append(candidates, &userDomain.Candidate{User: &user, Score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, nil
+}
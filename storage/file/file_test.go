@@ -0,0 +1,191 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const usersYAML = `users:
+  - name: Dana Cohen
+    aliases: [Dana C, Dani]
+    email: Dana@Example.com
+    timezone: Asia/Jerusalem
+    transport_id: U1
+    payment: [Bit, paybox]
+  - name: Noa Levi
+    transport_id: U2
+`
+
+const usersCSV = `name,transport_id,aliases,email,timezone,payment
+Dana Cohen,U1,Dana C;Dani,Dana@Example.com,Asia/Jerusalem,Bit;paybox
+Noa Levi,U2,,,,
+`
+
+func newTestStorage(t *testing.T, name, content string) (*FileStorage, string) {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	s, err := New(Config{Path: path, ReloadInterval: time.Millisecond, FuzzyMinimumScore: 75, Nicknames: map[string]string{"Nono": "Noa"}})
+	require.NoError(t, err)
+	return s, path
+}
+
+func TestLoad(t *testing.T) {
+	expected := &userDomain.User{
+		ID:                 "U1",
+		FullName:           "Dana Cohen",
+		Email:              "Dana@Example.com",
+		PaymentPreferences: []userDomain.PaymentMethod{userDomain.PaymentMethodBit, userDomain.PaymentMethodPaybox},
+		Timezone:           "Asia/Jerusalem",
+		TransportID:        "U1",
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{name: "users.yaml", content: usersYAML},
+		{name: "users.csv", content: usersCSV},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, _ := newTestStorage(t, tc.name, tc.content)
+
+			user, err := s.GetUser(ctx, "U1")
+			require.NoError(t, err)
+			assert.Equal(t, expected, user)
+
+			aliases, err := s.ListAliases(ctx, "U1")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Dana C", "Dani"}, aliases)
+
+			users, err := s.ListUsers(ctx, userDomain.ListFilter{})
+			require.NoError(t, err)
+			assert.Len(t, users, 2)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{name: "users.yaml", content: "users:\n  - name: Dana\n"},
+		{name: "users.yaml", content: "users:\n  - name: Dana\n    transport_id: U1\n    payment: [Cash]\n"},
+		{name: "users.yaml", content: "users:\n  - name: Dana\n    transport_id: U1\n    timezone: Nowhere/Land\n"},
+		{name: "users.yaml", content: "users:\n  - name: Dana\n    transport_id: U1\n  - name: Noa\n    transport_id: U1\n"},
+		{name: "users.yaml", content: "users:\n  - name: Dana\n    transport: U1\n"},
+		{name: "users.csv", content: "name,transport_id,nickname\nDana,U1,Dani\n"},
+		{name: "users.json", content: "{}"},
+	} {
+		path := filepath.Join(t.TempDir(), tc.name)
+		require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+		_, err := New(Config{Path: path})
+		assert.Error(t, err, tc.content)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestStorage(t, "users.yaml", usersYAML)
+
+	listIDs := func(filter userDomain.ListFilter) []string {
+		users, err := s.ListUsers(ctx, filter)
+		require.NoError(t, err)
+		ids := make([]string, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"U1"}, listIDs(userDomain.ListFilter{Names: []string{"dana cohen"}}))
+	assert.Equal(t, []string{"U1"}, listIDs(userDomain.ListFilter{Names: []string{"Dani"}}), "matching an alias")
+	assert.Equal(t, []string{"U1"}, listIDs(userDomain.ListFilter{Emails: []string{"dana@example.com"}}))
+	assert.Equal(t, []string{"U2"}, listIDs(userDomain.ListFilter{TransportID: "U2"}))
+	assert.Empty(t, listIDs(userDomain.ListFilter{Emails: []string{""}}), "Noa has no email")
+	assert.Empty(t, listIDs(userDomain.ListFilter{CustomOnly: true}))
+	assert.Empty(t, listIDs(userDomain.ListFilter{WoltUserIDs: []string{"wolt-1"}}), "Wolt users aren't known without matches")
+}
+
+// woltMatches are saved matches of Wolt users, by the Wolt user ID
+type woltMatches map[string]string
+
+func (m woltMatches) GetWoltMatch(_ context.Context, woltUserID string) (string, error) {
+	return m[woltUserID], nil
+}
+
+func (m woltMatches) SaveWoltMatch(_ context.Context, woltUserID, transportID string) error {
+	m[woltUserID] = transportID
+	return nil
+}
+
+func TestListUsersByWoltUserID(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "users.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(usersYAML), 0o600))
+	s, err := New(Config{Path: path, WoltMatches: woltMatches{"wolt-1": "U1", "wolt-2": "U2", "wolt-3": "U3"}})
+	require.NoError(t, err)
+
+	listIDs := func(filter userDomain.ListFilter) []string {
+		users, err := s.ListUsers(ctx, filter)
+		require.NoError(t, err)
+		ids := make([]string, len(users))
+		for i, user := range users {
+			ids[i] = user.ID
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"U1"}, listIDs(userDomain.ListFilter{WoltUserIDs: []string{"wolt-1"}}))
+	assert.Equal(t, []string{"U1", "U2"}, listIDs(userDomain.ListFilter{WoltUserIDs: []string{"wolt-1", "wolt-2"}}))
+	assert.Equal(t, []string{"U1", "U2"}, listIDs(userDomain.ListFilter{WoltUserIDs: []string{"wolt-1"}, TransportID: "U2"}))
+	assert.Empty(t, listIDs(userDomain.ListFilter{WoltUserIDs: []string{"wolt-3"}}), "matched to a user which isn't in the file")
+	assert.Empty(t, listIDs(userDomain.ListFilter{WoltUserIDs: []string{"wolt-4", ""}}), "never matched")
+}
+
+func TestFindCandidates(t *testing.T) {
+	s, _ := newTestStorage(t, "users.yaml", usersYAML)
+
+	candidates, err := s.FindCandidates(context.Background(), "Dana Coen")
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "U1", candidates[0].User.ID)
+	assert.Less(t, candidates[0].Score, 100)
+
+	// Names are compared the same way as Slack names, ignoring the script, diacritics and nicknames
+	for _, name := range []string{"נועה לוי", "Nóa Lëvi", "Nono Levi"} {
+		candidates, err = s.FindCandidates(context.Background(), name)
+		require.NoError(t, err)
+		require.NotEmpty(t, candidates, name)
+		assert.Equal(t, "U2", candidates[0].User.ID, name)
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s, path := newTestStorage(t, "users.yaml", usersYAML)
+	go s.Run(ctx)
+
+	require.NoError(t, os.WriteFile(path, []byte("users:\n  - name: Tom\n    transport_id: U3\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		users, err := s.ListUsers(ctx, userDomain.ListFilter{})
+		return err == nil && len(users) == 1 && users[0].ID == "U3"
+	}, time.Second, time.Millisecond)
+
+	// A broken file keeps the users loaded before
+	require.NoError(t, os.WriteFile(path, []byte("users: [[["), 0o600))
+	time.Sleep(20 * time.Millisecond)
+	users, err := s.ListUsers(ctx, userDomain.ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "U3", users[0].ID)
+}
@@ -0,0 +1,222 @@
+// Package names compares people names written differently, like Wolt names and the names in the user stores
+package names
+
+import (
+	"strings"
+	"unicode"
+
+	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
+)
+
+// latinDiacritics maps Latin letters with diacritics to their base letters
+var latinDiacritics = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'œ': "oe",
+	'ř': "r",
+	'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// hebrewLetters maps Hebrew letters to their most common Latin transliteration
+var hebrewLetters = map[rune]string{
+	'א': "a", 'ב': "b", 'ג': "g", 'ד': "d", 'ה': "h", 'ו': "v", 'ז': "z", 'ח': "ch", 'ט': "t", 'י': "y",
+	'כ': "k", 'ך': "ch", 'ל': "l", 'מ': "m", 'ם': "m", 'נ': "n", 'ן': "n", 'ס': "s", 'ע': "a", 'פ': "p",
+	'ף': "f", 'צ': "tz", 'ץ': "tz", 'ק': "k", 'ר': "r", 'ש': "sh", 'ת': "t",
+}
+
+// hebrewWithGeresh maps Hebrew letters followed by a geresh (like ג׳) to the foreign sound they represent
+var hebrewWithGeresh = map[rune]string{
+	'ג': "j", 'ז': "zh", 'צ': "ch", 'ץ': "ch", 'ת': "th",
+}
+
+// DefaultNicknames maps common nicknames (in English and Hebrew) to the full name they are short for
+var DefaultNicknames = map[string]string{
+	"dani": "daniel", "danny": "daniel", "דני": "דניאל",
+	"yossi": "yosef", "yosi": "yosef", "joe": "joseph", "יוסי": "יוסף",
+	"avi": "avraham", "אבי": "אברהם",
+	"moti": "mordechai", "מוטי": "מרדכי",
+	"kobi": "yaakov", "koby": "yaakov", "קובי": "יעקב",
+	"itzik": "yitzhak", "איציק": "יצחק",
+	"shuki": "yehoshua", "שוקי": "יהושע",
+	"benny": "binyamin", "beni": "binyamin", "בני": "בנימין",
+	"eli": "eliyahu", "אלי": "אליהו",
+	"mike": "michael", "mickey": "michael", "miki": "michael", "מיקי": "מיכאל",
+	"tomi": "tom", "טומי": "תום",
+	"rafi": "refael", "רפי": "רפאל",
+	"shlomi": "shlomo", "שלומי": "שלמה",
+	"gabi": "gavriel", "גבי": "גבריאל",
+	"alex": "alexander", "sasha": "alexander",
+	"bob": "robert", "rob": "robert", "bobby": "robert",
+	"bill": "william", "will": "william",
+	"jim": "james", "jimmy": "james",
+	"kate": "katherine", "katie": "katherine",
+	"liz": "elizabeth", "beth": "elizabeth",
+	"nick": "nicholas", "nicky": "nicholas",
+	"tony": "anthony", "matt": "matthew", "chris": "christopher",
+	"sam": "samuel", "sami": "samuel",
+}
+
+// Normalizer brings names written differently (other script, diacritics, nicknames) to a comparable form
+type Normalizer struct {
+	nicknames map[string]string
+}
+
+// NewNormalizer returns a normalizer replacing the DefaultNicknames and the extra nicknames by the full names
+func NewNormalizer(extraNicknames map[string]string) *Normalizer {
+	nicknames := make(map[string]string, len(DefaultNicknames)+len(extraNicknames))
+	for nickname, name := range DefaultNicknames {
+		nicknames[nickname] = name
+	}
+	for nickname, name := range extraNicknames {
+		nicknames[strings.ToLower(strings.TrimSpace(nickname))] = strings.ToLower(strings.TrimSpace(name))
+	}
+	return &Normalizer{nicknames: nicknames}
+}
+
+func isHebrew(r rune) bool {
+	return unicode.Is(unicode.Hebrew, r)
+}
+
+func hasHebrew(s string) bool {
+	return strings.IndexFunc(s, isHebrew) != -1
+}
+
+func isGeresh(r rune) bool {
+	return r == '׳' || r == '\'' || r == '`' || r == '’'
+}
+
+// words splits the name to lower case words, removing diacritics, Hebrew vowel points and punctuation (except geresh after Hebrew letters)
+func (n *Normalizer) words(name string) []string {
+	var sb strings.Builder
+	var previous rune
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining marks, like Hebrew vowel points and decomposed diacritics
+			continue
+		case latinDiacritics[r] != "":
+			sb.WriteString(latinDiacritics[r])
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			sb.WriteRune(r)
+		case isGeresh(r) && isHebrew(previous):
+			sb.WriteRune('׳')
+		default:
+			sb.WriteRune(' ')
+		}
+		previous = r
+	}
+	return strings.Fields(sb.String())
+}
+
+// transliterateHebrew transliterates a single Hebrew word to Latin letters
+func transliterateHebrew(word string) string {
+	runes := []rune(word)
+	var sb strings.Builder
+	for i, r := range runes {
+		if r == '׳' {
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == '׳' && hebrewWithGeresh[r] != "" {
+			sb.WriteString(hebrewWithGeresh[r])
+			continue
+		}
+		if r == 'ו' && i > 0 && runes[i-1] != 'ו' && (i+1 == len(runes) || runes[i+1] != 'ו') {
+			// Vav inside a word is usually a vowel
+			sb.WriteString("o")
+			continue
+		}
+		if r == 'ו' && i > 0 && runes[i-1] == 'ו' {
+			// Double vav is a single v
+			continue
+		}
+		if transliterated, ok := hebrewLetters[r]; ok {
+			sb.WriteString(transliterated)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Normalize returns the name in lower case Latin letters, with nicknames replaced by the full names
+func (n *Normalizer) Normalize(name string) string {
+	words := n.words(name)
+	for i, word := range words {
+		if fullName, ok := n.nicknames[strings.TrimSuffix(word, "׳")]; ok {
+			word = fullName
+		}
+		if hasHebrew(word) {
+			word = transliterateHebrew(word)
+		}
+		words[i] = word
+	}
+	return strings.Join(words, " ")
+}
+
+// phoneticReplacements brings spellings of the same sound to a single letter, longer sequences first
+var phoneticReplacements = strings.NewReplacer(
+	"tz", "z", "ts", "z", "zh", "z",
+	"ch", "k", "kh", "k", "ck", "k",
+	"sh", "s", "ph", "p", "th", "t",
+	"c", "k", "q", "k", "f", "p", "v", "b", "w", "b", "x", "ks", "j", "g",
+)
+
+// loosePhoneticReplacements removes the sounds which are written inconsistently between Hebrew and Latin:
+// gutturals (ח is written as h or ch, while ch is כ as well) and v (ו is a vowel as well)
+var loosePhoneticReplacements = strings.NewReplacer(
+	"ch", "", "kh", "", "v", "", "w", "",
+)
+
+// phoneticKey returns the consonants skeleton of a normalized name.
+// Hebrew is usually written without vowels, so comparing skeletons allows matching names transliterated from Hebrew.
+func phoneticKey(normalized string, loose bool) string {
+	if loose {
+		normalized = loosePhoneticReplacements.Replace(normalized)
+	}
+	words := strings.Fields(phoneticReplacements.Replace(normalized))
+	for i, word := range words {
+		var sb strings.Builder
+		var last rune
+		for _, r := range word {
+			if strings.ContainsRune("aeiouyh", r) || r == last {
+				continue
+			}
+			sb.WriteRune(r)
+			last = r
+		}
+		words[i] = sb.String()
+	}
+	return strings.Join(words, " ")
+}
+
+// Score returns how similar the names are (0-100), after bringing them to a comparable form
+func (n *Normalizer) Score(s1, s2 string) int {
+	best := fuzzy.UQRatio(s1, s2)
+
+	normalized1, normalized2 := n.Normalize(s1), n.Normalize(s2)
+	if score := fuzzy.UQRatio(normalized1, normalized2); score > best {
+		best = score
+	}
+
+	if hasHebrew(s1) != hasHebrew(s2) {
+		// Transliteration can't restore the vowels missing in Hebrew, so comparing just the consonants
+		for _, loose := range []bool{false, true} {
+			if score := fuzzy.UQRatio(phoneticKey(normalized1, loose), phoneticKey(normalized2, loose)); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,61 @@
+package names
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corpusMinimumScore is the default of SLACK_STORE_FUZZY_MINIMUM_SCORE
+const corpusMinimumScore = 75
+
+func TestNameScoreCorpus(t *testing.T) {
+	t.Parallel()
+
+	f, err := os.Open("testdata/name_pairs.csv")
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+
+	normalizer := NewNormalizer(nil)
+	for _, record := range records[1:] { // Skipping the header
+		woltName, slackName := record[0], record[1]
+		match, err := strconv.ParseBool(record[2])
+		require.NoError(t, err)
+
+		t.Run(woltName+"/"+slackName, func(t *testing.T) {
+			score := normalizer.Score(woltName, slackName)
+			if match {
+				assert.GreaterOrEqual(t, score, corpusMinimumScore)
+			} else {
+				assert.Less(t, score, corpusMinimumScore)
+			}
+			// The score shouldn't depend on the order of the names
+			assert.Equal(t, score, normalizer.Score(slackName, woltName))
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	normalizer := NewNormalizer(map[string]string{"Jonny": "Jonathan"})
+	for name, expected := range map[string]string{
+		"Zoë  Müller":    "zoe muller",
+		"Jonny B.":       "jonathan b",
+		"Dani":           "daniel",
+		"דני":            "dnyal",
+		"שָׁלוֹם":        "shlom",
+		"ג׳ורג׳":         "jorj",
+		"  Sigurd-Hring": "sigurd hring",
+	} {
+		assert.Equal(t, expected, normalizer.Normalize(name), name)
+	}
+}
@@ -0,0 +1,157 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+type DirectoryConfig struct {
+	RefreshInterval time.Duration `env:"SLACK_DIRECTORY_REFRESH_INTERVAL" envDefault:"6h"`
+	// MinRefreshInterval limits how often a user which isn't found in the directory triggers a refresh
+	MinRefreshInterval time.Duration `env:"SLACK_DIRECTORY_MIN_REFRESH_INTERVAL" envDefault:"1m"`
+}
+
+// Directory is an in-memory snapshot of all Slack users, so finding users doesn't require listing all of them from Slack.
+// It's refreshed periodically, when a user isn't found in it (at most once every MinRefreshInterval) and on users changes events.
+type Directory struct {
+	client             *slack.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+
+	refreshLock sync.Mutex // Making sure just one refresh is running at a time
+	lock        sync.RWMutex
+	users       []slack.User
+	version     int // Incremented on every change of the users
+	refreshedAt time.Time
+}
+
+func NewDirectory(client *slack.Client, cfg DirectoryConfig) *Directory {
+	return &Directory{
+		client:             client,
+		refreshInterval:    cfg.RefreshInterval,
+		minRefreshInterval: cfg.MinRefreshInterval,
+	}
+}
+
+// Users returns the current snapshot of the users and its version, loading the snapshot if it was never loaded
+func (d *Directory) Users(ctx context.Context) ([]slack.User, int, error) {
+	d.lock.RLock()
+	users, version, loaded := d.users, d.version, !d.refreshedAt.IsZero()
+	d.lock.RUnlock()
+	if loaded {
+		return users, version, nil
+	}
+
+	if err := d.refreshIfNotChanged(ctx, version, 0); err != nil {
+		return nil, 0, err
+	}
+	return d.Users(ctx)
+}
+
+// RefreshOnMiss refreshes the snapshot after something wasn't found in the given version of it.
+// It returns whether the snapshot changed since that version, so it's worth searching it again.
+func (d *Directory) RefreshOnMiss(ctx context.Context, version int) (bool, error) {
+	if err := d.refreshIfNotChanged(ctx, version, d.minRefreshInterval); err != nil {
+		return false, err
+	}
+
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.version != version, nil
+}
+
+// refreshIfNotChanged refreshes the snapshot, unless it was changed since the given version or refreshed less than minInterval ago
+func (d *Directory) refreshIfNotChanged(ctx context.Context, version int, minInterval time.Duration) error {
+	d.refreshLock.Lock()
+	defer d.refreshLock.Unlock()
+
+	d.lock.RLock()
+	changed := d.version != version
+	recentlyRefreshed := !d.refreshedAt.IsZero() && time.Since(d.refreshedAt) < minInterval
+	d.lock.RUnlock()
+	if changed || recentlyRefreshed {
+		return nil
+	}
+	return d.refresh(ctx)
+}
+
+// refresh replaces the snapshot with all the users listed from Slack, callers must hold refreshLock
+func (d *Directory) refresh(ctx context.Context) error {
+	users := make([]slack.User, 0)
+
+	var err error
+	paginatedUsers := d.client.GetUsersPaginated()
+	for {
+		paginatedUsers, err = paginatedUsers.Next(ctx)
+		if err != nil {
+			break
+		}
+		users = append(users, paginatedUsers.Users...)
+	}
+	if err = paginatedUsers.Failure(err); err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.users = users
+	d.version++
+	d.refreshedAt = time.Now()
+	return nil
+}
+
+// Upsert adds the user to the snapshot, or replaces it if it's already there
+func (d *Directory) Upsert(user slack.User) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.refreshedAt.IsZero() {
+		// Will be listed once the snapshot is loaded
+		return
+	}
+
+	// Copying, so snapshots returned earlier won't change
+	users := make([]slack.User, 0, len(d.users)+1)
+	for _, existing := range d.users {
+		if existing.ID != user.ID {
+			users = append(users, existing)
+		}
+	}
+	d.users = append(users, user)
+	d.version++
+}
+
+// UpdateUser fetches the user from Slack and updates it in the snapshot
+func (d *Directory) UpdateUser(ctx context.Context, id string) error {
+	user, err := d.client.GetUserInfoContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get user info: %w", err)
+	}
+	d.Upsert(*user)
+	return nil
+}
+
+// Run refreshes the snapshot every RefreshInterval until the context is done
+func (d *Directory) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.refreshLock.Lock()
+			err := d.refresh(ctx)
+			d.refreshLock.Unlock()
+			if err != nil {
+				log.Println("Error refreshing Slack directory:", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUsersList serves users.list with the given users, counting the calls
+type fakeUsersList struct {
+	lock  sync.Mutex
+	users []slack.User
+	calls int
+}
+
+func (f *fakeUsersList) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls++
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "members": f.users})
+}
+
+func (f *fakeUsersList) setUsers(users ...slack.User) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.users = users
+}
+
+func (f *fakeUsersList) callsCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.calls
+}
+
+func newTestDirectory(t *testing.T, minRefreshInterval time.Duration) (*Directory, *fakeUsersList) {
+	t.Helper()
+
+	usersList := &fakeUsersList{}
+	mux := http.NewServeMux()
+	mux.Handle("/users.list", usersList)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := slack.New("ignored", slack.OptionAPIURL(server.URL+"/"))
+	return NewDirectory(client, DirectoryConfig{RefreshInterval: time.Hour, MinRefreshInterval: minRefreshInterval}), usersList
+}
+
+func TestDirectoryRefreshOnMiss(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	directory, usersList := newTestDirectory(t, 0)
+	usersList.setUsers(slack.User{ID: "U1"})
+
+	users, version, err := directory.Users(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	// Loaded just once
+	_, _, err = directory.Users(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usersList.callsCount())
+
+	usersList.setUsers(slack.User{ID: "U1"}, slack.User{ID: "U2"})
+	changed, err := directory.RefreshOnMiss(ctx, version)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	users, newVersion, err := directory.Users(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	// A miss in an old version doesn't refresh again, as the snapshot already changed since
+	changed, err = directory.RefreshOnMiss(ctx, version)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, 2, usersList.callsCount())
+
+	// Users changes events update the snapshot without listing all users
+	directory.Upsert(slack.User{ID: "U2", Name: "renamed"})
+	directory.Upsert(slack.User{ID: "U3"})
+	users, upsertedVersion, err := directory.Users(ctx)
+	require.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.NotEqual(t, newVersion, upsertedVersion)
+	assert.Equal(t, 2, usersList.callsCount())
+}
+
+func TestDirectoryMinRefreshInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	directory, usersList := newTestDirectory(t, time.Hour)
+
+	_, version, err := directory.Users(ctx)
+	require.NoError(t, err)
+
+	changed, err := directory.RefreshOnMiss(ctx, version)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 1, usersList.callsCount())
+}
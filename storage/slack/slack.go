@@ -2,26 +2,36 @@ package slack
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/oriser/bolt/storage/names"
 	userDomain "github.com/oriser/bolt/user"
 	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
 	"github.com/slack-go/slack"
 )
 
-const (
-	FuzzyLimit        = 10
-	FuzzyMinimumScore = 75
-)
+const FuzzyLimit = 10
 
 type Config struct {
+	Directory         DirectoryConfig
 	OauthToken        string        `env:"SLACK_OAUTH_TOKEN,required" json:"-"`
 	MaxCacheEntryTime time.Duration `env:"SLACK_STORE_MAX_CACHE_ENTRY_TIME" envDefault:"144h"` // 6 days
 	SlackAPIUrl       string        `env:"SLACK_API_URL"`                                      // only for testing
+	// FuzzyMinimumScore is the minimum score (0-100) for a Slack user to be considered as a match of a name
+	FuzzyMinimumScore int `env:"SLACK_STORE_FUZZY_MINIMUM_SCORE" envDefault:"75"`
+	// FuzzyConfidentScore is the minimum score for a match to be taken without asking, lower scores are just candidates
+	FuzzyConfidentScore int `env:"SLACK_STORE_FUZZY_CONFIDENT_SCORE" envDefault:"85"`
+	// FuzzyAmbiguityMargin is the minimum score difference between the best match and the next one for not being ambiguous
+	FuzzyAmbiguityMargin int               `env:"SLACK_STORE_FUZZY_AMBIGUITY_MARGIN" envDefault:"5"`
+	Nicknames            map[string]string `env:"SLACK_STORE_NICKNAMES"` // In addition to names.DefaultNicknames
+	// WoltMatches are the saved matches of Wolt users, for listing users by their Wolt user IDs
+	WoltMatches userDomain.WoltMatchStore `json:"-"`
 }
 
 type cacheEntry struct {
@@ -30,10 +40,16 @@ type cacheEntry struct {
 }
 
 type SlackStorage struct {
-	client            *slack.Client
-	lock              sync.RWMutex
-	cache             map[string]cacheEntry
-	maxCacheEntryTime time.Duration
+	client               *slack.Client
+	lock                 sync.RWMutex
+	cache                map[string]cacheEntry
+	maxCacheEntryTime    time.Duration
+	fuzzyMinimumScore    int
+	fuzzyConfidentScore  int
+	fuzzyAmbiguityMargin int
+	normalizer           *names.Normalizer
+	directory            *Directory
+	woltMatches          userDomain.WoltMatchStore
 }
 
 type MatchUser struct {
@@ -46,17 +62,57 @@ func New(cfg Config) *SlackStorage {
 	if cfg.SlackAPIUrl != "" {
 		slackOptions = append(slackOptions, slack.OptionAPIURL(cfg.SlackAPIUrl))
 	}
+	client := slack.New(cfg.OauthToken, slackOptions...)
 	return &SlackStorage{
-		client:            slack.New(cfg.OauthToken, slackOptions...),
-		maxCacheEntryTime: cfg.MaxCacheEntryTime,
-		cache:             make(map[string]cacheEntry),
+		client:               client,
+		maxCacheEntryTime:    cfg.MaxCacheEntryTime,
+		cache:                make(map[string]cacheEntry),
+		fuzzyMinimumScore:    cfg.FuzzyMinimumScore,
+		fuzzyConfidentScore:  cfg.FuzzyConfidentScore,
+		fuzzyAmbiguityMargin: cfg.FuzzyAmbiguityMargin,
+		normalizer:           names.NewNormalizer(cfg.Nicknames),
+		directory:            NewDirectory(client, cfg.Directory),
+		woltMatches:          cfg.WoltMatches,
 	}
 }
 
+// Directory returns the snapshot of Slack users used for finding users, so others can use it instead of listing Slack users
+func (s *SlackStorage) Directory() *Directory {
+	return s.directory
+}
+
 func (s *SlackStorage) AddUser(_ context.Context, _ *userDomain.User) error {
 	return fmt.Errorf("not implemented for slack storage")
 }
 
+func (s *SlackStorage) UpdateUser(_ context.Context, _ *userDomain.User) error {
+	return fmt.Errorf("not implemented for slack storage")
+}
+
+func (s *SlackStorage) DeleteUser(_ context.Context, _ string) error {
+	return fmt.Errorf("not implemented for slack storage")
+}
+
+func (s *SlackStorage) AddAlias(_ context.Context, _, _ string) error {
+	return fmt.Errorf("not implemented for slack storage")
+}
+
+func (s *SlackStorage) RemoveAlias(_ context.Context, _ string) error {
+	return fmt.Errorf("not implemented for slack storage")
+}
+
+func (s *SlackStorage) ListAliases(_ context.Context, _ string) ([]string, error) {
+	return nil, fmt.Errorf("not implemented for slack storage")
+}
+
+func (s *SlackStorage) GetPreferences(_ context.Context, _ string) (*userDomain.Preferences, error) {
+	return nil, fmt.Errorf("not implemented for slack storage")
+}
+
+func (s *SlackStorage) SavePreferences(_ context.Context, _ *userDomain.Preferences) error {
+	return fmt.Errorf("not implemented for slack storage")
+}
+
 func (s *SlackStorage) saveCache(name string, user *userDomain.User) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -143,9 +199,12 @@ func (s *SlackStorage) findMatchedUsers(searchFor string, users []slack.User) ([
 		}
 	}
 
-	fuzzyFunc := fuzzy.UQRatio
+	// Keeping the names as is, the scorer normalizes them
+	noProcess := func(s string) string {
+		return s
+	}
 
-	findings, err := fuzzy.Extract(searchFor, searchedValues, FuzzyLimit, FuzzyMinimumScore, fuzzyFunc)
+	findings, err := fuzzy.Extract(searchFor, searchedValues, FuzzyLimit, s.fuzzyMinimumScore, s.normalizer.Score, noProcess)
 	if err != nil {
 		return nil, fmt.Errorf("search function: %w", err)
 	}
@@ -184,33 +243,36 @@ func (s *SlackStorage) slackUserToUser(user slack.User) *userDomain.User {
 	}
 }
 
-func (s *SlackStorage) filterForSpecificName(users []slack.User, name string, oldFinding *MatchUser) (*MatchUser, error) {
-	finalFinding := &MatchUser{matchScore: -1}
-	if oldFinding != nil {
-		finalFinding = oldFinding
+// confidentMatch returns the best match, or nil if it's not good enough or too close to the next match to be sure it's the right one
+func (s *SlackStorage) confidentMatch(matches []*MatchUser) *MatchUser {
+	if len(matches) == 0 {
+		return nil
 	}
 
-	foundUsers, err := s.findMatchedUsers(name, users)
-	if err != nil {
-		return nil, fmt.Errorf("find matched users: %w", err)
+	sortMatches(matches)
+	best := matches[0]
+	if best.matchScore < s.fuzzyConfidentScore {
+		return nil
 	}
-	for _, matchedUser := range foundUsers {
-		if matchedUser.matchScore > finalFinding.matchScore {
-			finalFinding = matchedUser
-		}
+	if len(matches) > 1 && best.matchScore-matches[1].matchScore < s.fuzzyAmbiguityMargin {
+		return nil
 	}
+	return best
+}
 
-	if finalFinding.matchScore == -1 {
-		return nil, nil
-	}
-	return finalFinding, nil
+func sortMatches(matches []*MatchUser) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].matchScore > matches[j].matchScore
+	})
 }
 
 func (s *SlackStorage) ListUsers(ctx context.Context, filter userDomain.ListFilter) ([]*userDomain.User, error) {
-	findings := make(map[string]*MatchUser) // Map between filtered name to a map of matched users ID to the user itself
-	paginatedUsers := s.client.GetUsersPaginated()
-
 	ret := make([]*userDomain.User, 0)
+	if filter.CustomOnly {
+		// Slack users are never custom users
+		return ret, nil
+	}
+
 	if filter.TransportID != "" {
 		user, err := s.GetUser(ctx, filter.TransportID)
 		if err == nil && user != nil {
@@ -218,10 +280,33 @@ func (s *SlackStorage) ListUsers(ctx context.Context, filter userDomain.ListFilt
 		}
 	}
 
+	// Slack doesn't know Wolt users, the users are found by the transport IDs they were matched to
+	matchedTransportIDs, err := userDomain.MatchedTransportIDs(ctx, s.woltMatches, filter.WoltUserIDs)
+	if err != nil {
+		return nil, fmt.Errorf("matched transport IDs: %w", err)
+	}
+	for transportID := range matchedTransportIDs {
+		user, err := s.GetUser(ctx, transportID)
+		if err == nil && user != nil {
+			ret = append(ret, user)
+		}
+	}
+
+	for _, email := range filter.Emails {
+		user, err := s.getUserByEmail(ctx, email)
+		if err != nil {
+			return nil, fmt.Errorf("get user by email: %w", err)
+		}
+		if user != nil {
+			ret = append(ret, user)
+		}
+	}
+
 	filterByNames := len(filter.Names) > 0
 
-	if filter.TransportID != "" && !filterByNames {
-		// If we asked to filter just by TransportID and the names filter is empty, returning here to avoid listing all users
+	if (filter.TransportID != "" || len(filter.WoltUserIDs) > 0 || len(filter.Emails) > 0) && !filterByNames {
+		// If we asked to filter just by TransportID, Wolt user IDs or emails and the names filter is empty,
+		// returning here to avoid listing all users
 		return ret, nil
 	}
 
@@ -241,45 +326,119 @@ func (s *SlackStorage) ListUsers(ctx context.Context, filter userDomain.ListFilt
 		return ret, nil
 	}
 
-	var err error
-	for {
-		paginatedUsers, err = paginatedUsers.Next(ctx)
-		if err != nil {
-			break
-		}
+	users, version, err := s.directory.Users(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get users from slack directory: %w", err)
+	}
 
-		if !filterByNames {
-			// Just list the users
-			for _, user := range paginatedUsers.Users {
-				ret = append(ret, s.slackUserToUser(user))
-			}
-			continue
+	if !filterByNames {
+		// Just list the users
+		for _, user := range users {
+			ret = append(ret, s.slackUserToUser(user))
 		}
+		return ret, nil
+	}
 
-		// Filtering
+	for refreshed := false; len(usersToFilter) > 0; refreshed = true {
+		notFound := make([]string, 0)
 		for _, name := range usersToFilter {
-			currentFinding := findings[name]
-			currentFinding, err = s.filterForSpecificName(paginatedUsers.Users, name, currentFinding)
+			matches, err := s.findMatchedUsers(name, users)
 			if err != nil {
-				return nil, fmt.Errorf("filter for specific name: %w", err)
+				return nil, fmt.Errorf("find matched users: %w", err)
+			}
+			if len(matches) == 0 {
+				notFound = append(notFound, name)
+				continue
+			}
+
+			matchedUser := s.confidentMatch(matches)
+			if matchedUser == nil {
+				// Uncertain matches can be found using FindCandidates
+				continue
+			}
+			user := s.slackUserToUser(matchedUser.User)
+			s.saveCache(name, user)
+			ret = append(ret, user)
+		}
+
+		// The users might have joined Slack after the directory was refreshed
+		usersToFilter = notFound
+		if len(usersToFilter) == 0 || refreshed {
+			break
+		}
+		changed, err := s.directory.RefreshOnMiss(ctx, version)
+		if err != nil {
+			return nil, fmt.Errorf("refresh slack directory: %w", err)
+		}
+		if !changed {
+			break
+		}
+		if users, version, err = s.directory.Users(ctx); err != nil {
+			return nil, fmt.Errorf("get users from slack directory: %w", err)
+		}
+	}
+
+	return ret, nil
+}
+
+// FindCandidates returns all the Slack users matching the name, best matches first
+func (s *SlackStorage) FindCandidates(ctx context.Context, name string) ([]*userDomain.Candidate, error) {
+	users, version, err := s.directory.Users(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get users from slack directory: %w", err)
+	}
+
+	matches, err := s.findMatchedUsers(name, users)
+	if err != nil {
+		return nil, fmt.Errorf("find matched users: %w", err)
+	}
+	if len(matches) == 0 {
+		// The user might have joined Slack after the directory was refreshed
+		changed, err := s.directory.RefreshOnMiss(ctx, version)
+		if err != nil {
+			return nil, fmt.Errorf("refresh slack directory: %w", err)
+		}
+		if changed {
+			if users, _, err = s.directory.Users(ctx); err != nil {
+				return nil, fmt.Errorf("get users from slack directory: %w", err)
 			}
-			if currentFinding != nil {
-				findings[name] = currentFinding
+			if matches, err = s.findMatchedUsers(name, users); err != nil {
+				return nil, fmt.Errorf("find matched users: %w", err)
 			}
 		}
 	}
 
-	if err = paginatedUsers.Failure(err); err != nil {
-		return nil, fmt.Errorf("get users from slack: %w", err)
+	sortMatches(matches)
+	if len(matches) > FuzzyLimit {
+		matches = matches[:FuzzyLimit]
 	}
 
-	for name, matchedUser := range findings {
-		user := s.slackUserToUser(matchedUser.User)
-		s.saveCache(name, user)
-		ret = append(ret, user)
+	candidates := make([]*userDomain.Candidate, len(matches))
+	for i, match := range matches {
+		candidates[i] = &userDomain.Candidate{User: s.slackUserToUser(match.User), Score: match.matchScore}
 	}
+	return candidates, nil
+}
 
-	return ret, nil
+// getUserByEmail returns the Slack user with the exact email, or nil if there's no such user
+func (s *SlackStorage) getUserByEmail(ctx context.Context, email string) (*userDomain.User, error) {
+	if email == "" {
+		// Looking up an empty email will never find a user
+		return nil, nil
+	}
+
+	user, err := s.client.GetUserByEmailContext(ctx, email)
+	if err != nil {
+		var slackErr slack.SlackErrorResponse
+		if errors.As(err, &slackErr) && slackErr.Err == "users_not_found" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lookup by email: %w", err)
+	}
+	if user.Deleted {
+		return nil, nil
+	}
+	return s.slackUserToUser(*user), nil
 }
 
 func (s *SlackStorage) GetUser(_ context.Context, id string) (*userDomain.User, error) {
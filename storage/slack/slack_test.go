@@ -0,0 +1,88 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	userDomain "github.com/oriser/bolt/user"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserByEmail(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users.lookupByEmail", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("email") {
+		case "dana@example.com":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "user": slack.User{ID: "U1", RealName: "Dana Levi"}})
+		case "unauthorized@example.com":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_auth"})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "users_not_found"})
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	store := New(Config{SlackAPIUrl: server.URL + "/"})
+	ctx := context.Background()
+
+	user, err := store.getUserByEmail(ctx, "dana@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	assert.Equal(t, "U1", user.TransportID)
+
+	user, err = store.getUserByEmail(ctx, "nobody@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, user)
+
+	_, err = store.getUserByEmail(ctx, "unauthorized@example.com")
+	assert.Error(t, err)
+}
+
+// woltMatches are saved matches of Wolt users, by the Wolt user ID
+type woltMatches map[string]string
+
+func (m woltMatches) GetWoltMatch(_ context.Context, woltUserID string) (string, error) {
+	return m[woltUserID], nil
+}
+
+func (m woltMatches) SaveWoltMatch(_ context.Context, woltUserID, transportID string) error {
+	m[woltUserID] = transportID
+	return nil
+}
+
+func TestListUsersByWoltUserID(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users.info", func(w http.ResponseWriter, r *http.Request) {
+		switch r.FormValue("user") {
+		case "U1":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "user": slack.User{ID: "U1", RealName: "Dana Levi"}})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "user_not_found"})
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	store := New(Config{SlackAPIUrl: server.URL + "/", WoltMatches: woltMatches{"wolt-1": "U1", "wolt-2": "U2"}})
+	ctx := context.Background()
+
+	users, err := store.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{"wolt-1"}})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "U1", users[0].TransportID)
+
+	// Wolt users which were never matched, or matched to users which aren't in Slack anymore, match nothing (without listing all the users)
+	users, err = store.ListUsers(ctx, userDomain.ListFilter{WoltUserIDs: []string{"wolt-2", "wolt-3"}})
+	require.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -2,6 +2,7 @@ package testing
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
@@ -10,17 +11,23 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/jmoiron/sqlx"
 	"github.com/oriser/bolt/cmd/run"
+	"github.com/oriser/bolt/order"
 	"github.com/oriser/bolt/service"
+	dbStorage "github.com/oriser/bolt/storage/db"
 	"github.com/oriser/bolt/testing/customslack"
 	"github.com/oriser/bolt/testing/utils"
 	"github.com/oriser/bolt/testing/woltserver"
+	"github.com/oriser/bolt/venue"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/slacktest"
@@ -37,23 +44,36 @@ var (
 		Lat: 32.072447331148844,
 		Lon: 34.77900266647339,
 	}
+	// The venue in the order of Wolt's coordinates (longitude first), for comparing with the office location
+	OfficeVenueLocation = woltserver.Coordinate{
+		Lat: DefaultVenueLocation.Lon,
+		Lon: DefaultVenueLocation.Lat,
+	}
 )
 
 const (
-	WaitForMessageTimeout  = 20 * time.Second
-	OrderReadyTimeout      = 10 * time.Second
-	WaitBetweenStatusCheck = 500 * time.Millisecond
-	DebtReminderInterval   = 3 * time.Second
-	DebtMaximumDuration    = 10 * time.Second
-	AdminSlackUserID       = "ABC123"
-	MaxHttpAttempts        = 10000 // A lot of attempts to make sure the request will succeed at last (we return 502 randomly for tests)
-	MinHttpRetryWait       = time.Millisecond
-	MaxHttpRetryWait       = 5 * time.Millisecond
+	WaitForMessageTimeout     = 20 * time.Second
+	OrderReadyTimeout         = 10 * time.Second
+	WaitBetweenStatusCheck    = 500 * time.Millisecond
+	MinWaitBetweenStatusCheck = 100 * time.Millisecond
+	MaxWaitBetweenStatusCheck = 2 * time.Second
+	StatusChecksPerSecond     = 1000
+	DebtReminderInterval      = 3 * time.Second
+	DebtMaximumDuration       = 10 * time.Second
+	AdminSlackUserID          = "ABC123"
+	MaxHttpAttempts           = 10000 // A lot of attempts to make sure the request will succeed at last (we return 502 randomly for tests)
+	MinHttpRetryWait          = time.Millisecond
+	MaxHttpRetryWait          = 5 * time.Millisecond
+	WoltRateLimit             = 1000
 
 	DefaultNonBotUserID     = "W012A3CDE" // From slack test package, it's not exposed, and it's constant
 	MessageChannel          = "some-channel"
+	OldOrdersChannel        = "old-orders-channel"
+	OrderedVenueName        = "A Venue From An Old Order"
 	DefaultExpectedDelivery = 10
 	DefaultHost             = "Bolt"
+	OfficeLocation          = "32.0707244997673,34.78343904018402"
+	WoltSessionSecret       = "aW50ZWdyYXRpb24tdGVzdC1zZXNzaW9uLXNlY3JldCE=" // 32 bytes, encoded in base64
 )
 
 var timezones = []string{
@@ -69,6 +89,7 @@ type WoltLinkType int
 const (
 	WoltGroupLink WoltLinkType = iota
 	WoltGroupOrderJoinLink
+	WoltVenueLink
 )
 
 type testData struct {
@@ -76,6 +97,12 @@ type testData struct {
 	slackServer *slacktest.Server
 	customSlack *customslack.Handlers
 	boltAddr    string
+
+	// A watch of a closed venue, saved before bolt started so it's resumed on start
+	resumedWatchVenueID   string
+	resumedWatchSlug      string
+	resumedWatchMessageID string
+	orderedVenueSlug      string
 }
 
 func initEnvs(t *testing.T, tdata testData) {
@@ -92,10 +119,15 @@ func initEnvs(t *testing.T, tdata testData) {
 	require.NoError(t, os.Setenv("SLACK_API_URL", tdata.slackServer.GetAPIURL()))
 	require.NoError(t, os.Setenv("ADMIN_SLACK_USER_IDS", AdminSlackUserID))
 	require.NoError(t, os.Setenv("DISABLE_SECRET_VERIFICATION", "true"))
+	// Users are added to Slack during the tests, so the directory should be refreshed on every miss
+	require.NoError(t, os.Setenv("SLACK_DIRECTORY_MIN_REFRESH_INTERVAL", "0"))
 
 	// Service
 	require.NoError(t, os.Setenv("ORDER_READY_TIMEOUT", OrderReadyTimeout.String()))
 	require.NoError(t, os.Setenv("WAIT_BETWEEN_STATUS_CHECK", WaitBetweenStatusCheck.String()))
+	require.NoError(t, os.Setenv("MIN_WAIT_BETWEEN_STATUS_CHECK", MinWaitBetweenStatusCheck.String()))
+	require.NoError(t, os.Setenv("MAX_WAIT_BETWEEN_STATUS_CHECK", MaxWaitBetweenStatusCheck.String()))
+	require.NoError(t, os.Setenv("STATUS_CHECKS_PER_SECOND", strconv.Itoa(StatusChecksPerSecond)))
 	require.NoError(t, os.Setenv("DEBT_REMINDER_INTERVAL", DebtReminderInterval.String()))
 	require.NoError(t, os.Setenv("DEBT_MAXIMUM_DURATION", DebtMaximumDuration.String()))
 	require.NoError(t, os.Setenv("WOLT_BASE_ADDR", "http://"+tdata.woltServer.Addr()))
@@ -103,6 +135,11 @@ func initEnvs(t *testing.T, tdata testData) {
 	require.NoError(t, os.Setenv("WOLT_HTTP_MAX_RETRY_COUNT", strconv.Itoa(MaxHttpAttempts)))
 	require.NoError(t, os.Setenv("WOLT_HTTP_MIN_RETRY_DURATION", MinHttpRetryWait.String()))
 	require.NoError(t, os.Setenv("WOLT_HTTP_MAX_RETRY_DURATION", MaxHttpRetryWait.String()))
+	// Many requests fail randomly and are retried, so the rate limit should be high enough not to slow the tests down
+	require.NoError(t, os.Setenv("WOLT_RATE_LIMIT", strconv.Itoa(WoltRateLimit)))
+	require.NoError(t, os.Setenv("WOLT_AUTH_BASE_ADDR", "http://"+tdata.woltServer.Addr()))
+	require.NoError(t, os.Setenv("WOLT_SESSION_SECRET", WoltSessionSecret))
+	require.NoError(t, os.Setenv("OFFICE_LOCATION", OfficeLocation))
 
 	// main
 	require.NoError(t, os.Setenv("DB_LOCATION", path.Join(tmpDir, "db.sqlite")))
@@ -137,6 +174,40 @@ func initTest(t *testing.T) testData {
 
 	initEnvs(t, tdata)
 
+	t.Log("Signing bolt in to wolt")
+	var loginOutput bytes.Buffer
+	require.NoError(t, run.WoltLogin(strings.NewReader(woltServer.IssueRefreshToken()+"\n"), &loginOutput))
+	require.Contains(t, loginOutput.String(), "Signed in to Wolt")
+
+	tdata.resumedWatchVenueID, tdata.resumedWatchSlug = woltServer.CreateVenueWithSlug(OfficeVenueLocation)
+	require.NoError(t, woltServer.SetVenueClosed(tdata.resumedWatchVenueID, true))
+	tdata.resumedWatchMessageID = utils.GenerateRandomString(utils.NumberLetters, 8)
+	var orderedVenueID string
+	orderedVenueID, tdata.orderedVenueSlug = woltServer.CreateVenueWithSlug(OfficeVenueLocation)
+	seedDB(t, func(store *dbStorage.DBStore) error {
+		err := store.AddVenueWatch(context.Background(), &venue.Watch{
+			Slug:        tdata.resumedWatchSlug,
+			VenueName:   "A Tasty Venue",
+			Receiver:    MessageChannel,
+			MessageID:   tdata.resumedWatchMessageID,
+			RequestedBy: DefaultNonBotUserID,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			return err
+		}
+		// An order from a venue, which is then known by its name
+		return store.SaveOrder(context.Background(), &order.Order{
+			OriginalID: utils.GenerateRandomString(utils.NumberLetters, 8),
+			CreatedAt:  time.Now().Add(-24 * time.Hour),
+			Receiver:   OldOrdersChannel,
+			VenueName:  OrderedVenueName,
+			VenueID:    orderedVenueID,
+			VenueLink:  "https://wolt.com/he/isr/tel-aviv/venue/" + tdata.orderedVenueSlug,
+			Status:     order.StatusDone,
+		})
+	})
+
 	errCh := make(chan error, 1)
 	go func() {
 		t.Log("Running bolt")
@@ -155,6 +226,20 @@ func initTest(t *testing.T) testData {
 	return tdata
 }
 
+// seedDB saves data in bolt's DB before it starts, like data saved before bolt restarted
+func seedDB(t *testing.T, seed func(store *dbStorage.DBStore) error) {
+	t.Helper()
+
+	db, err := sqlx.Connect("sqlite3", os.Getenv("DB_LOCATION"))
+	require.NoError(t, err)
+	defer db.Close()
+	driver, err := sqlite3.WithInstance(db.DB, &sqlite3.Config{})
+	require.NoError(t, err)
+	store, err := dbStorage.New(db, driver, "")
+	require.NoError(t, err)
+	require.NoError(t, seed(store))
+}
+
 func isOutsideWorkingHours(t *testing.T, tzString string) bool {
 	t.Helper()
 	currentTime := time.Now()
@@ -245,7 +330,23 @@ func buildSlackReactionEvent(t *testing.T, itemUser, timestamp, reaction string,
 	return buildGenericSlackEvent(t, &rawEvent)
 }
 
-func buildSlackLinkEvent(t *testing.T, messageTimestamp, groupShortID string, linkType WoltLinkType) []byte {
+func buildSlackMentionEvent(t *testing.T, messageTimestamp, text string) []byte {
+	mentionEvent := &slackevents.AppMentionEvent{
+		Type:      "app_mention",
+		User:      DefaultNonBotUserID,
+		Text:      text,
+		TimeStamp: messageTimestamp,
+		Channel:   MessageChannel,
+	}
+
+	marshaled, err := json.Marshal(mentionEvent)
+	require.NoError(t, err)
+	rawEvent := json.RawMessage(marshaled)
+
+	return buildGenericSlackEvent(t, &rawEvent)
+}
+
+func buildSlackLinkEvent(t *testing.T, messageTimestamp, linkID string, linkType WoltLinkType) []byte {
 	t.Helper()
 
 	linkEvent := &slackevents.LinkSharedEvent{
@@ -263,12 +364,14 @@ func buildSlackLinkEvent(t *testing.T, messageTimestamp, groupShortID string, li
 		linkFormatString = "https://wolt.com/group/%s"
 	case WoltGroupOrderJoinLink:
 		linkFormatString = "https://wolt.com/en/group-order/%s/join"
+	case WoltVenueLink:
+		linkFormatString = "https://wolt.com/en/isr/tel-aviv/restaurant/%s"
 	}
 
 	setLinksToEvent(t, []sharedLinks{
 		{
 			Domain: "wolt.com",
-			URL:    fmt.Sprintf(linkFormatString, groupShortID),
+			URL:    fmt.Sprintf(linkFormatString, linkID),
 		},
 	}, linkEvent)
 
@@ -779,4 +882,128 @@ func TestSlackPurchaseGroup(t *testing.T) {
 			time.Sleep(50 * time.Millisecond)
 		})
 	}
+
+	t.Run("Venue link", func(t *testing.T) {
+		t.Parallel()
+		venueID, slug := tdata.woltServer.CreateVenueWithSlug(OfficeVenueLocation)
+
+		timestamp := utils.GenerateRandomString(utils.NumberLetters, 8)
+		evt := buildSlackLinkEvent(t, timestamp, slug, WoltVenueLink)
+		resp, err := http.Post("http://"+tdata.boltAddr+"/events-endpoint", "application/json", bytes.NewReader(evt))
+		require.NoError(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+
+		msg, err := WaitForOutboundSlackMessage(WaitForMessageTimeout, tdata.slackServer,
+			fmt.Sprintf("*<https://wolt.com/he/isr/tel-aviv/venue/%s|A Tasty Venue>*", slug),
+			MessageChannel, timestamp, ContainsMatch)
+		require.NoError(t, err)
+		assert.Contains(t, msg.Text, ":large_green_circle: Venue is open for delivery")
+		assert.Contains(t, msg.Text, "Delivery to the office: 0.5 km away, 10 nis delivery, 20-40 minutes")
+
+		// Watching an open venue, by its link and by its ID
+		for _, venueRef := range []string{"https://wolt.com/en/isr/tel-aviv/restaurant/" + slug, venueID} {
+			data := url.Values{}
+			data.Set("user_id", DefaultNonBotUserID)
+			data.Set("channel_id", MessageChannel)
+			data.Set("command", "/watch-venue")
+			data.Set("text", venueRef)
+			resp, err = http.Post("http://"+tdata.boltAddr+"/watch-venue", "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf(":large_green_circle: <https://wolt.com/he/isr/tel-aviv/venue/%s|A Tasty Venue> is open for delivery right now", slug), string(respBody))
+		}
+
+		// Names are of venues Bolt tracked orders from
+		for venueRef, expected := range map[string]string{
+			strings.ToLower(OrderedVenueName): fmt.Sprintf(":large_green_circle: <https://wolt.com/he/isr/tel-aviv/venue/%s|A Tasty Venue> is open for delivery right now", tdata.orderedVenueSlug),
+			"A Venue Nobody Ordered From":     `"A Venue Nobody Ordered From" isn't a link or ID of a Wolt venue, or a name of a venue I tracked orders from`,
+		} {
+			data := url.Values{}
+			data.Set("user_id", DefaultNonBotUserID)
+			data.Set("channel_id", MessageChannel)
+			data.Set("command", "/watch-venue")
+			data.Set("text", venueRef)
+			resp, err = http.Post("http://"+tdata.boltAddr+"/watch-venue", "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+			require.NoError(t, err)
+			respBody, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(respBody), expected)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	t.Run("Resumed venue watch", func(t *testing.T) {
+		t.Parallel()
+
+		// The watch saved before bolt started keeps polling the venue until it opens
+		time.Sleep(2 * WaitBetweenStatusCheck)
+		require.NoError(t, tdata.woltServer.SetVenueClosed(tdata.resumedWatchVenueID, false))
+
+		_, err := WaitForOutboundSlackMessage(WaitForMessageTimeout, tdata.slackServer,
+			fmt.Sprintf(":large_green_circle: <https://wolt.com/he/isr/tel-aviv/venue/%s|A Tasty Venue> is now open for delivery", tdata.resumedWatchSlug),
+			MessageChannel, tdata.resumedWatchMessageID, EqualMatch)
+		require.NoError(t, err)
+	})
+
+	t.Run("Lunch command", func(t *testing.T) {
+		t.Parallel()
+		_, slug := tdata.woltServer.CreateVenueWithSlug(OfficeVenueLocation)
+
+		data := url.Values{}
+		data.Set("user_id", DefaultNonBotUserID)
+		data.Set("channel_id", MessageChannel)
+		data.Set("command", "/lunch")
+		data.Set("text", "https://wolt.com/en/isr/tel-aviv/restaurant/"+slug)
+		resp, err := http.Post("http://"+tdata.boltAddr+"/lunch", "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "OK, I started a group order from A Tasty Venue", string(respBody))
+
+		msg, err := WaitForOutboundSlackMessage(WaitForMessageTimeout, tdata.slackServer,
+			fmt.Sprintf(":knife_fork_plate: <@%s> is hosting a group order from <https://wolt.com/he/isr/tel-aviv/venue/%s|A Tasty Venue>, join here: https://wolt.com/group/", DefaultNonBotUserID, slug),
+			MessageChannel, "", ContainsMatch)
+		require.NoError(t, err)
+		shortID := regexp.MustCompile(`/group/([A-Z0-9]+)`).FindStringSubmatch(msg.Text)
+		require.Len(t, shortID, 2)
+
+		order, err := tdata.woltServer.GetOrderByShortID(shortID[1])
+		require.NoError(t, err)
+		assert.Equal(t, woltserver.AccountName, order.Host)
+		require.NoError(t, tdata.woltServer.UpdateOrderStatus(order.ID, woltserver.StatusCanceled))
+
+		_, err = WaitForOutboundSlackMessage(WaitForMessageTimeout, tdata.slackServer,
+			fmt.Sprintf("Order for group ID %s was canceled", shortID[1]), MessageChannel, msg.Timestamp, EqualMatch)
+		require.NoError(t, err)
+	})
+
+	t.Run("Deadline mention", func(t *testing.T) {
+		t.Parallel()
+
+		// Mentions which don't start with a command aren't answered, the cleanup fails on unexpected replies
+		for _, text := range []string{"<@U0BOLT> hi", "what's the deadline <@U0BOLT>?"} {
+			evt := buildSlackMentionEvent(t, utils.GenerateRandomString(utils.NumberLetters, 8), text)
+			resp, err := http.Post("http://"+tdata.boltAddr+"/events-endpoint", "application/json", bytes.NewReader(evt))
+			require.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+		}
+
+		for text, expectedReply := range map[string]string{
+			"<@U0BOLT> deadline 25:00": "invalid deadline: \"25:00\" is not a valid time (HH:MM format)\nUSAGE: @Bolt deadline <HH:MM> | off",
+			"<@U0BOLT> deadline off":   "There's no deadline to remove.",
+		} {
+			timestamp := utils.GenerateRandomString(utils.NumberLetters, 8)
+			evt := buildSlackMentionEvent(t, timestamp, text)
+			resp, err := http.Post("http://"+tdata.boltAddr+"/events-endpoint", "application/json", bytes.NewReader(evt))
+			require.NoError(t, err)
+			assert.Equal(t, 200, resp.StatusCode)
+
+			_, err = WaitForOutboundSlackMessage(WaitForMessageTimeout, tdata.slackServer, expectedReply, MessageChannel, timestamp, ContainsMatch)
+			require.NoError(t, err, "reply to %q", text)
+		}
+	})
 }
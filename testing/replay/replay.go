@@ -0,0 +1,209 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/wolt"
+)
+
+var ErrNoRecordings = fmt.Errorf("no recorded exchanges. Make sure the directory has fixtures saved by wolt.Recorder")
+
+// Server replays a Wolt session recorded by wolt.Recorder.
+// Every request is answered with the next recorded response of its method and path, and the last one is repeated once they run out,
+// so polling an order walks through its recorded timeline (like active, purchased and then delivered).
+// Dates in the responses are moved by the time passed since the recording, as if the session happens now.
+type Server struct {
+	server    *httptest.Server
+	l         sync.Mutex
+	timelines map[string]*timeline // Method and path to their responses
+	started   time.Time            // When the first exchange was recorded
+	shift     time.Duration
+	t         *testing.T
+}
+
+type timeline struct {
+	exchanges []wolt.RecordedExchange
+	next      int
+}
+
+func timelineKey(method, path string) string {
+	return method + " " + path
+}
+
+// NewServer loads the recorded session from the fixtures in dir
+func NewServer(t *testing.T, dir string) (*Server, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list fixtures: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, ErrNoRecordings
+	}
+	sort.Strings(paths) // The recorder names them in the order they were recorded
+
+	s := &Server{
+		timelines: make(map[string]*timeline),
+		t:         t,
+	}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read fixture %s: %w", path, err)
+		}
+		var exchange wolt.RecordedExchange
+		if err = json.Unmarshal(content, &exchange); err != nil {
+			return nil, fmt.Errorf("unmarshal fixture %s: %w", path, err)
+		}
+
+		if s.started.IsZero() || exchange.RecordedAt.Before(s.started) {
+			s.started = exchange.RecordedAt
+		}
+		key := timelineKey(exchange.Method, exchange.Path)
+		if _, ok := s.timelines[key]; !ok {
+			s.timelines[key] = &timeline{}
+		}
+		s.timelines[key].exchanges = append(s.timelines[key].exchanges, exchange)
+	}
+
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(s.handler))
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.server.Listener.Addr().String()
+}
+
+func (s *Server) Start() {
+	s.l.Lock()
+	s.shift = time.Since(s.started)
+	s.l.Unlock()
+	s.server.Start()
+}
+
+func (s *Server) Stop() {
+	s.server.Close()
+}
+
+// Served returns how many requests with the method and path were answered with a recorded response
+func (s *Server) Served(method, path string) int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	tl, ok := s.timelines[timelineKey(method, path)]
+	if !ok {
+		return 0
+	}
+	return tl.next
+}
+
+// Remaining returns how many recorded responses weren't replayed yet, of all the endpoints
+func (s *Server) Remaining() int {
+	s.l.Lock()
+	defer s.l.Unlock()
+	remaining := 0
+	for _, tl := range s.timelines {
+		remaining += len(tl.exchanges) - tl.next
+	}
+	return remaining
+}
+
+func (s *Server) handler(res http.ResponseWriter, req *http.Request) {
+	exchange, shift, ok := s.nextExchange(req.Method, req.URL.Path)
+	if !ok {
+		s.t.Logf("No recorded response for %s %s", req.Method, req.URL.Path)
+		res.WriteHeader(http.StatusNotFound)
+		_, _ = res.Write([]byte(fmt.Sprintf("Error: no recorded response for %s %s", req.Method, req.URL.Path)))
+		return
+	}
+
+	if exchange.ResponseBody == nil {
+		res.WriteHeader(exchange.Status)
+		_, _ = res.Write([]byte(exchange.ResponseText))
+		return
+	}
+
+	body, err := shiftDates(exchange.ResponseBody, shift)
+	if err != nil {
+		s.t.Logf("Error shifting dates of response for %s %s: %v", req.Method, req.URL.Path, err)
+		res.WriteHeader(http.StatusInternalServerError)
+		_, _ = res.Write([]byte(fmt.Sprintf("Error: %v", err)))
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(exchange.Status)
+	_, _ = res.Write(body)
+}
+
+func (s *Server) nextExchange(method, path string) (wolt.RecordedExchange, time.Duration, bool) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	tl, ok := s.timelines[timelineKey(method, path)]
+	if !ok {
+		return wolt.RecordedExchange{}, 0, false
+	}
+	if tl.next == len(tl.exchanges) {
+		return tl.exchanges[len(tl.exchanges)-1], s.shift, true
+	}
+	exchange := tl.exchanges[tl.next]
+	tl.next++
+	return exchange, s.shift, true
+}
+
+// shiftDates moves every date (like {"$date": 1653132616708}) in the JSON by shift
+func shiftDates(body json.RawMessage, shift time.Duration) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("decode JSON: %w", err)
+	}
+
+	shifted, err := shiftValueDates(value, shift.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(shifted)
+}
+
+func shiftValueDates(value interface{}, shiftMillis int64) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if date, ok := v["$date"].(json.Number); ok && len(v) == 1 {
+			millis, err := date.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("parse date %q: %w", date, err)
+			}
+			v["$date"] = millis + shiftMillis
+			return v, nil
+		}
+		for key, child := range v {
+			shifted, err := shiftValueDates(child, shiftMillis)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = shifted
+		}
+		return v, nil
+	case []interface{}:
+		for i, child := range v {
+			shifted, err := shiftValueDates(child, shiftMillis)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = shifted
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,99 @@
+package replay
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/wolt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Recorded against woltserver rather than real Wolt traffic, with the purchase of the order added to its last responses.
+// It's sanitized like every recording, so the participants are pseudonyms.
+const (
+	deliveredOrderDir     = "testdata/delivered-order"
+	deliveredOrderShortID = "0LVAZSHT"
+	deliveredOrderPath    = "/v1/group_order/guest/sbia59zy1ipld2gdeskza7er/participants/me"
+)
+
+func newReplayClient(t *testing.T, s *Server) *wolt.Client {
+	addr := "http://" + s.Addr()
+	c, err := wolt.NewClient(wolt.WoltAddr{BaseAddr: addr, APIBaseAddr: addr, AuthBaseAddr: addr}, wolt.RetryConfig{
+		HTTPMaxRetries:       3,
+		HTTPMinRetryDuration: time.Millisecond,
+		HTTPMaxRetryDuration: time.Millisecond,
+	}, wolt.ClientConfig{VenueTTL: time.Hour, VenueStatusTTL: time.Minute})
+	require.NoError(t, err)
+	return c
+}
+
+func TestReplayDeliveredOrder(t *testing.T) {
+	s, err := NewServer(t, deliveredOrderDir)
+	require.NoError(t, err)
+	s.Start()
+	defer s.Stop()
+
+	ctx := context.Background()
+	g, err := newReplayClient(t, s).GroupWithExistingID(deliveredOrderShortID)
+	require.NoError(t, err)
+	require.NoError(t, g.Join(ctx))
+
+	details, err := g.Details(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, wolt.StatusActive, details.Status)
+	assert.Equal(t, "Participant 1", details.Host)
+	assert.WithinDuration(t, time.Now().Add(-12*time.Minute), details.CreatedAt, time.Minute, "dates should be moved to the time of the replay")
+
+	v, err := g.VenueDetails(ctx, details)
+	require.NoError(t, err)
+	_, err = v.CalculateDeliveryRate(details.ParsedDeliveryCoordinate)
+	require.NoError(t, err)
+
+	require.NoError(t, g.MarkAsReady(ctx))
+
+	var statuses []wolt.Status
+	for !details.IsDelivered() {
+		require.Less(t, len(statuses), 5, "order should be delivered by the end of the recording")
+		details, err = g.Details(ctx)
+		require.NoError(t, err)
+		statuses = append(statuses, details.Status)
+
+		if details.Status.Purchased() {
+			assert.True(t, details.DeliveryEta.After(details.PurchaseDatetime))
+			assert.True(t, details.PurchaseDatetime.After(details.CreatedAt))
+		}
+	}
+	assert.Equal(t, []wolt.Status{wolt.StatusActive, wolt.StatusPurchased, wolt.StatusPurchased}, statuses)
+	assert.Contains(t, details.Purchase.DeliveryStatusLog, wolt.DeliveryStatusDelivered)
+
+	rates, err := details.RateByPerson()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"Participant 2": 52, "Participant 3": 38}, rates)
+
+	assert.Zero(t, s.Remaining(), "the whole recording should be replayed")
+	assert.Equal(t, 5, s.Served(http.MethodPatch, deliveredOrderPath))
+
+	// Once the recording runs out, its last response is repeated
+	details, err = g.Details(ctx)
+	require.NoError(t, err)
+	assert.True(t, details.IsDelivered())
+}
+
+func TestReplayUnrecordedRequest(t *testing.T) {
+	s, err := NewServer(t, deliveredOrderDir)
+	require.NoError(t, err)
+	s.Start()
+	defer s.Stop()
+
+	g, err := newReplayClient(t, s).GroupWithExistingID("UNKNOWN1")
+	require.NoError(t, err)
+	assert.Error(t, g.Join(context.Background()))
+}
+
+func TestNewServerWithoutRecordings(t *testing.T) {
+	_, err := NewServer(t, t.TempDir())
+	assert.ErrorIs(t, err, ErrNoRecordings)
+}
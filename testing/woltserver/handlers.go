@@ -2,9 +2,11 @@ package woltserver
 
 import (
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
+	"strings"
 
 	"github.com/Masterminds/sprig"
 	"github.com/gorilla/mux"
@@ -130,7 +132,105 @@ func (ws *WoltServer) getVenueHandler(res http.ResponseWriter, req *http.Request
 	}
 
 	jsonTmpl := template.Must(template.New("venue").Funcs(sprig.HtmlFuncMap()).Parse(venueTemplate))
-	if err = jsonTmpl.Execute(res, v); err != nil {
+	if err = jsonTmpl.Execute(res, ws.venueSnapshot(v)); err != nil {
+		ws.writeError(res, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (ws *WoltServer) getVenueBySlugHandler(res http.ResponseWriter, req *http.Request) {
+	slug, err := ws.extractID(req)
+	if err != nil {
+		ws.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	v, ok := ws.getVenueBySlug(slug)
+	if !ok {
+		ws.writeError(res, http.StatusBadRequest, ErrNoSuchVenue)
+		return
+	}
+
+	jsonTmpl := template.Must(template.New("venue").Funcs(sprig.HtmlFuncMap()).Parse(venueTemplate))
+	if err = jsonTmpl.Execute(res, ws.venueSnapshot(v)); err != nil {
+		ws.writeError(res, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (ws *WoltServer) withAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		accessToken := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ws.isValidAccessToken(accessToken) {
+			ws.writeError(res, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+		handler(res, req)
+	}
+}
+
+func (ws *WoltServer) accessTokenHandler(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ws.writeError(res, http.StatusMethodNotAllowed, fmt.Errorf("unexpected method %s", req.Method))
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		ws.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+	if grantType := req.PostForm.Get("grant_type"); grantType != "refresh_token" {
+		ws.writeError(res, http.StatusBadRequest, fmt.Errorf("unsupported grant type %q", grantType))
+		return
+	}
+
+	accessToken, refreshToken, ok := ws.exchangeRefreshToken(req.PostForm.Get("refresh_token"))
+	if !ok {
+		ws.writeError(res, http.StatusBadRequest, ErrInvalidGrant)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(res).Encode(map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(AccessTokenTTL.Seconds()),
+		"token_type":    "Bearer",
+	})
+}
+
+func (ws *WoltServer) createOrderHandler(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		ws.writeError(res, http.StatusMethodNotAllowed, fmt.Errorf("unexpected method %s", req.Method))
+		return
+	}
+
+	var body struct {
+		VenueID      string `json:"venue_id"`
+		DeliveryInfo struct {
+			Location struct {
+				Coordinates struct {
+					Coordinates []float64 `json:"coordinates"`
+				} `json:"coordinates"`
+			} `json:"location"`
+		} `json:"delivery_info"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		ws.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := ws.getVenue(body.VenueID); !ok {
+		ws.writeError(res, http.StatusBadRequest, ErrNoSuchVenue)
+		return
+	}
+	coordinates := body.DeliveryInfo.Location.Coordinates.Coordinates
+	if len(coordinates) != 2 {
+		ws.writeError(res, http.StatusBadRequest, fmt.Errorf("expected 2 delivery coordinates but got %d", len(coordinates)))
+		return
+	}
+
+	order := ws.createOrder(AccountName, body.VenueID, Coordinate{Lat: coordinates[0], Lon: coordinates[1]})
+	jsonTmpl := template.Must(template.New("details").Funcs(sprig.HtmlFuncMap()).Parse(detailsTemplate))
+	if err := jsonTmpl.Execute(res, order); err != nil {
 		ws.writeError(res, http.StatusInternalServerError, err)
 		return
 	}
@@ -49,6 +49,7 @@ type Order struct {
 	ShortID        string
 	VenueID        string
 	Host           string
+	HostID         string
 	Status         OrderStatus
 	Location       Coordinate
 	DeliveryMethod DeliveryMethod
@@ -64,7 +65,9 @@ type Coordinate struct {
 
 type Venue struct {
 	ID       string
+	Slug     string
 	Location Coordinate
+	Closed   bool // Venues are open for delivery unless closed
 }
 
 func init() {
@@ -75,6 +78,11 @@ func generateWoltID() string {
 	return utils.GenerateRandomString(append(utils.LowerLetters, utils.NumberLetters...), 24)
 }
 
+// generateWoltVenueID generates a venue ID, which is hexadecimal like the IDs of real Wolt venues
+func generateWoltVenueID() string {
+	return utils.GenerateRandomString([]rune("0123456789abcdef"), 24)
+}
+
 func generateWoltShortID() string {
 	return utils.GenerateRandomString(append(utils.CapitalLetters, utils.NumberLetters...), 8)
 }
@@ -103,14 +111,13 @@ func (p *Participant) AddItem(amount int) {
 }
 
 func newOrder(host, venueID string, location Coordinate) Order {
-	hostID := "a1ufke9dwe2wkn7pmw6qcwx9"
 	hostParticipant := newParticipant(host)
-	hostParticipant.ID = hostID
 	return Order{
 		ID:             generateWoltID(),
 		ShortID:        generateWoltShortID(),
 		VenueID:        venueID,
 		Host:           host,
+		HostID:         hostParticipant.ID,
 		Location:       location,
 		Status:         StatusActive,
 		DeliveryMethod: DeliveryMethodHome,
@@ -138,8 +145,10 @@ func newItem(basePrice, endAmount int) Item {
 }
 
 func newVenue(location Coordinate) Venue {
+	id := generateWoltVenueID()
 	return Venue{
-		ID:       generateWoltID(),
+		ID:       id,
+		Slug:     "tasty-venue-" + id,
 		Location: location,
 	}
 }
@@ -15,15 +15,25 @@ import (
 var ErrNoSuchOrder = fmt.Errorf("no such order. Make sure you created an order first")
 var ErrNoSuchParticipant = fmt.Errorf("no such participant. Make sure you created a participant in that order fist")
 var ErrNoSuchVenue = fmt.Errorf("no such venue. Make sure you created a venue fist")
+var ErrUnauthorized = fmt.Errorf("unauthorized. Make sure you signed in with an issued refresh token")
+var ErrInvalidGrant = fmt.Errorf("invalid grant. Make sure you issued the refresh token and didn't use it already")
+
+// AccountName is the name of the signed in user, the host of the orders created by it
+const AccountName = "Bolt Office"
+
+// AccessTokenTTL is how long access tokens are valid for
+const AccessTokenTTL = 30 * time.Minute
 
 type WoltServer struct {
-	router       *mux.Router
-	server       *httptest.Server
-	l            sync.RWMutex
-	orders       map[string]*Order // ID to order
-	shortIDOrder map[string]string // Order short ID to ID
-	venues       map[string]*Venue
-	t            *testing.T
+	router        *mux.Router
+	server        *httptest.Server
+	l             sync.RWMutex
+	orders        map[string]*Order // ID to order
+	shortIDOrder  map[string]string // Order short ID to ID
+	venues        map[string]*Venue
+	refreshTokens map[string]bool      // Refresh tokens which weren't used yet
+	accessTokens  map[string]time.Time // Access token to its expiry
+	t             *testing.T
 }
 
 func NewWoltServer(t *testing.T) *WoltServer {
@@ -33,12 +43,14 @@ func NewWoltServer(t *testing.T) *WoltServer {
 	server := httptest.NewUnstartedServer(router)
 
 	ws := &WoltServer{
-		router:       router,
-		server:       server,
-		orders:       make(map[string]*Order),
-		shortIDOrder: make(map[string]string),
-		venues:       make(map[string]*Venue),
-		t:            t,
+		router:        router,
+		server:        server,
+		orders:        make(map[string]*Order),
+		shortIDOrder:  make(map[string]string),
+		venues:        make(map[string]*Venue),
+		refreshTokens: make(map[string]bool),
+		accessTokens:  make(map[string]time.Time),
+		t:             t,
 	}
 	ws.registerDefaults()
 	return ws
@@ -51,6 +63,10 @@ func (ws *WoltServer) registerDefaults() {
 		"/v1/group_order/guest/code/{id}":            ws.orderDetailsFromShortID,
 		"/v1/group_order/guest/join/{id}":            ws.joinByIDHandler,
 		"/v3/venues/{id}":                            ws.getVenueHandler,
+		"/v3/venues/slug/{id}":                       ws.getVenueBySlugHandler,
+		"/v1/wauth2/access_token":                    ws.accessTokenHandler,
+		"/v1/group_order":                            ws.withAuth(ws.createOrderHandler),
+		"/v1/group_order/{id}/participants/me":       ws.withAuth(ws.orderDetailsHandler),
 	}
 	for pattern, handler := range defaults {
 		ws.RegisterEndpoint(pattern, handler)
@@ -90,6 +106,53 @@ func (ws *WoltServer) GetOrder(orderID string) (*Order, error) {
 	return o, nil
 }
 
+// GetOrderByShortID returns the order with the short ID in its link
+func (ws *WoltServer) GetOrderByShortID(shortID string) (*Order, error) {
+	o, ok, err := ws.getOrderByShortID(shortID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNoSuchOrder
+	}
+	return o, nil
+}
+
+// IssueRefreshToken issues a refresh token of the account, like the one of a browser signed in to Wolt.
+// Like Wolt, refresh tokens are rotated, each of them can be used once.
+func (ws *WoltServer) IssueRefreshToken() string {
+	ws.l.Lock()
+	defer ws.l.Unlock()
+	return ws.issueRefreshToken()
+}
+
+func (ws *WoltServer) issueRefreshToken() string {
+	token := fmt.Sprintf("refresh-%016x", rand.Int63())
+	ws.refreshTokens[token] = true
+	return token
+}
+
+// exchangeRefreshToken uses the refresh token, and returns a new access token and refresh token
+func (ws *WoltServer) exchangeRefreshToken(refreshToken string) (string, string, bool) {
+	ws.l.Lock()
+	defer ws.l.Unlock()
+	if !ws.refreshTokens[refreshToken] {
+		return "", "", false
+	}
+	delete(ws.refreshTokens, refreshToken)
+
+	accessToken := fmt.Sprintf("access-%016x", rand.Int63())
+	ws.accessTokens[accessToken] = time.Now().Add(AccessTokenTTL)
+	return accessToken, ws.issueRefreshToken(), true
+}
+
+func (ws *WoltServer) isValidAccessToken(accessToken string) bool {
+	ws.l.RLock()
+	defer ws.l.RUnlock()
+	expiresAt, ok := ws.accessTokens[accessToken]
+	return ok && time.Now().Before(expiresAt)
+}
+
 func (ws *WoltServer) GetVenue(venueID string) (*Venue, error) {
 	v, ok := ws.getVenue(venueID)
 	if !ok {
@@ -154,6 +217,25 @@ func (ws *WoltServer) CreateVenue(location Coordinate) string {
 	return v.ID
 }
 
+// SetVenueClosed closes the venue for delivery, or opens it
+func (ws *WoltServer) SetVenueClosed(venueID string, closed bool) error {
+	ws.l.Lock()
+	defer ws.l.Unlock()
+
+	v, ok := ws.venues[venueID]
+	if !ok {
+		return ErrNoSuchVenue
+	}
+	v.Closed = closed
+	return nil
+}
+
+// CreateVenueWithSlug creates a venue, returning its ID and the slug of its link
+func (ws *WoltServer) CreateVenueWithSlug(location Coordinate) (ID, slug string) {
+	v := ws.createVenue(location)
+	return v.ID, v.Slug
+}
+
 func (ws *WoltServer) unsafeGetOrderByShortID(shortID string) (*Order, bool, error) {
 	orderID, ok := ws.shortIDOrder[shortID]
 	if !ok {
@@ -198,6 +280,25 @@ func (ws *WoltServer) getVenue(id string) (*Venue, bool) {
 	return v, ok
 }
 
+// venueSnapshot copies the venue, so it can be used while it's changed
+func (ws *WoltServer) venueSnapshot(v *Venue) Venue {
+	ws.l.RLock()
+	defer ws.l.RUnlock()
+	return *v
+}
+
+func (ws *WoltServer) getVenueBySlug(slug string) (*Venue, bool) {
+	ws.l.RLock()
+	defer ws.l.RUnlock()
+
+	for _, v := range ws.venues {
+		if v.Slug == slug {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
 func (ws *WoltServer) createVenue(location Coordinate) *Venue {
 	v := newVenue(location)
 
@@ -0,0 +1,35 @@
+package user
+
+import (
+	"context"
+	"fmt"
+)
+
+// WoltMatchStore saves the user (by the transport ID) each Wolt user was matched to, so the next orders find the user by the Wolt user ID
+type WoltMatchStore interface {
+	// GetWoltMatch returns the transport ID of the user matched to the Wolt user, or an empty string if the Wolt user was never matched
+	GetWoltMatch(ctx context.Context, woltUserID string) (string, error)
+	SaveWoltMatch(ctx context.Context, woltUserID, transportID string) error
+}
+
+// MatchedTransportIDs returns the transport IDs of the users the Wolt users were matched to, for stores which list users by ListFilter.WoltUserIDs
+// using matches saved elsewhere. Wolt users which were never matched (or without a match store) are skipped.
+func MatchedTransportIDs(ctx context.Context, store WoltMatchStore, woltUserIDs []string) (map[string]bool, error) {
+	transportIDs := make(map[string]bool, len(woltUserIDs))
+	if store == nil {
+		return transportIDs, nil
+	}
+	for _, woltUserID := range woltUserIDs {
+		if woltUserID == "" {
+			continue
+		}
+		transportID, err := store.GetWoltMatch(ctx, woltUserID)
+		if err != nil {
+			return nil, fmt.Errorf("get match of Wolt user ID %s: %w", woltUserID, err)
+		}
+		if transportID != "" {
+			transportIDs[transportID] = true
+		}
+	}
+	return transportIDs, nil
+}
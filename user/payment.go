@@ -1,5 +1,10 @@
 package user
 
+import (
+	"fmt"
+	"strings"
+)
+
 type PaymentMethod int
 
 //goland:noinspection ALL
@@ -22,3 +27,13 @@ func (p PaymentMethod) String() string {
 
 type Payment struct {
 }
+
+// ParsePaymentMethod parses the name of a payment method (case insensitive)
+func ParsePaymentMethod(method string) (PaymentMethod, error) {
+	for p, str := range paymentsString {
+		if strings.EqualFold(str, strings.TrimSpace(method)) {
+			return p, nil
+		}
+	}
+	return PaymentMethodInvalid, fmt.Errorf("unknown payment method %q", method)
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"fmt"
+	"time"
+)
+
+type NotificationMode int
+
+const (
+	NotificationModeImmediate NotificationMode = iota
+	NotificationModeDigest
+)
+
+var notificationModesString = map[NotificationMode]string{
+	NotificationModeImmediate: "immediate",
+	NotificationModeDigest:    "digest",
+}
+
+func (n NotificationMode) String() string {
+	return notificationModesString[n]
+}
+
+func ParseNotificationMode(mode string) (NotificationMode, error) {
+	for m, str := range notificationModesString {
+		if str == mode {
+			return m, nil
+		}
+	}
+	return NotificationModeImmediate, fmt.Errorf("unknown notification mode %q", mode)
+}
+
+// Default quiet hours, no reminders will be sent from DefaultQuietHoursStart until DefaultQuietHoursEnd (at the user's timezone)
+const (
+	DefaultQuietHoursStart = 21
+	DefaultQuietHoursEnd   = 9
+)
+
+// Preferences are the notification preferences of a user, identified by its TransportID
+type Preferences struct {
+	TransportID           string           `db:"transport_id"`
+	ReminderInterval      time.Duration    `db:"reminder_interval"` // Zero means using the default reminder interval
+	QuietHoursStart       int              `db:"quiet_hours_start"`
+	QuietHoursEnd         int              `db:"quiet_hours_end"` // Equal to QuietHoursStart means no quiet hours at all
+	HostPaidNotifications bool             `db:"host_paid_notifications"`
+	NotificationMode      NotificationMode `db:"notification_mode"`
+}
+
+func DefaultPreferences(transportID string) *Preferences {
+	return &Preferences{
+		TransportID:           transportID,
+		ReminderInterval:      0,
+		QuietHoursStart:       DefaultQuietHoursStart,
+		QuietHoursEnd:         DefaultQuietHoursEnd,
+		HostPaidNotifications: true,
+		NotificationMode:      NotificationModeImmediate,
+	}
+}
+
+// InQuietHours returns whether the given time (already at the user's timezone) is in the user's quiet hours
+func (p *Preferences) InQuietHours(t time.Time) bool {
+	if p.QuietHoursStart == p.QuietHoursEnd {
+		return false
+	}
+
+	hour := t.Hour()
+	if p.QuietHoursStart < p.QuietHoursEnd {
+		return hour >= p.QuietHoursStart && hour < p.QuietHoursEnd
+	}
+	// Quiet hours are crossing midnight
+	return hour >= p.QuietHoursStart || hour < p.QuietHoursEnd
+}
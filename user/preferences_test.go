@@ -0,0 +1,56 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInQuietHours(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour int) time.Time {
+		return time.Date(2022, 5, 21, hour, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		start    int
+		end      int
+		quiet    []int
+		notQuiet []int
+	}{
+		{
+			name:     "Default crossing midnight",
+			start:    DefaultQuietHoursStart,
+			end:      DefaultQuietHoursEnd,
+			quiet:    []int{21, 23, 0, 8},
+			notQuiet: []int{9, 12, 20},
+		},
+		{
+			name:     "Same day",
+			start:    13,
+			end:      15,
+			quiet:    []int{13, 14},
+			notQuiet: []int{12, 15, 0},
+		},
+		{
+			name:     "Disabled",
+			start:    5,
+			end:      5,
+			notQuiet: []int{4, 5, 6, 23},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			preferences := &Preferences{QuietHoursStart: tc.start, QuietHoursEnd: tc.end}
+			for _, hour := range tc.quiet {
+				assert.True(t, preferences.InQuietHours(at(hour)), "hour %d", hour)
+			}
+			for _, hour := range tc.notQuiet {
+				assert.False(t, preferences.InQuietHours(at(hour)), "hour %d", hour)
+			}
+		})
+	}
+}
@@ -23,13 +23,41 @@ func (u *ErrNotFound) Error() string {
 	return fmt.Sprintf("user with name %s not found", u.Name)
 }
 
+type ErrAlreadyExists struct {
+	Name string
+}
+
+func (u *ErrAlreadyExists) Error() string {
+	return fmt.Sprintf("user with name %s already exists", u.Name)
+}
+
+// Candidate is a user which might be the one searched by name, Score is how close the match is (0-100)
+type Candidate struct {
+	User  *User
+	Score int
+}
+
 type Store interface {
 	AddUser(ctx context.Context, user *User) error
+	UpdateUser(ctx context.Context, user *User) error
+	DeleteUser(ctx context.Context, id string) error
 	GetUser(ctx context.Context, id string) (*User, error)
 	ListUsers(ctx context.Context, filter ListFilter) ([]*User, error)
+	// FindCandidates returns all the users which might be the one with the given name, best matches first.
+	// Unlike ListUsers, it returns uncertain matches as well.
+	FindCandidates(ctx context.Context, name string) ([]*Candidate, error)
+	// AddAlias links another name (for example a Wolt display name) to the user, so listing by that name will find the user
+	AddAlias(ctx context.Context, userID, alias string) error
+	RemoveAlias(ctx context.Context, alias string) error
+	ListAliases(ctx context.Context, userID string) ([]string, error)
+	GetPreferences(ctx context.Context, transportID string) (*Preferences, error)
+	SavePreferences(ctx context.Context, preferences *Preferences) error
 }
 
 type ListFilter struct {
-	Names       []string
+	Names       []string // Matching full names as well as aliases
 	TransportID string
+	WoltUserIDs []string // Matching the users the Wolt users were matched to before (see WoltMatchStore)
+	Emails      []string // Matching emails exactly (case insensitive)
+	CustomOnly  bool     // Just users added manually (for example using /add-user), without users from external directories like Slack
 }
@@ -0,0 +1,24 @@
+package venue
+
+import (
+	"context"
+	"time"
+)
+
+// Watch is a request to let the receiver know once a venue opens for delivery
+type Watch struct {
+	ID          string    `db:"id"`
+	Slug        string    `db:"slug"`
+	VenueName   string    `db:"venue_name"`
+	Receiver    string    `db:"receiver"`   // For example a Slack channel
+	MessageID   string    `db:"message_id"` // The thread to reply in, empty for replying in the receiver itself
+	RequestedBy string    `db:"requested_by"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"` // Giving up watching the venue after that time
+}
+
+type Store interface {
+	AddVenueWatch(ctx context.Context, watch *Watch) error
+	DeleteVenueWatch(ctx context.Context, id string) error
+	ListVenueWatches(ctx context.Context) ([]*Watch, error)
+}
@@ -0,0 +1,254 @@
+package wolt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"time"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+	"github.com/prometheus/common/log"
+	"golang.org/x/time/rate"
+)
+
+// DefaultUserAgent is the User-Agent requests to Wolt are sent with by default, of a browser
+const DefaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.16; rv:84.0) Gecko/20100101 Firefox/84.0"
+
+type WoltAddr struct {
+	BaseAddr     string
+	APIBaseAddr  string
+	AuthBaseAddr string // Needed just for signing in
+
+	baseAddrParsed *url.URL
+	apiAddrParsed  *url.URL
+	authAddrParsed *url.URL
+}
+
+type RetryConfig struct {
+	HTTPMaxRetries       int
+	HTTPMinRetryDuration time.Duration
+	HTTPMaxRetryDuration time.Duration
+}
+
+// ClientConfig configures how requests are sent to Wolt
+type ClientConfig struct {
+	UserAgent      string            // Default is DefaultUserAgent
+	Headers        map[string]string // Sent with every request, overriding the default headers
+	RequestTimeout time.Duration     // Of every attempt of a request, zero means no timeout
+	RateLimit      float64           // Maximum requests per second to Wolt (including retries), zero means no limit
+	RateBurst      int               // Requests which can be sent at once before being limited
+	VenueTTL       time.Duration     // How long cached venues are used for their static data, zero means not caching
+	VenueStatusTTL time.Duration     // How long cached venues are used for their state (online, delivering)
+	Recorder       *Recorder         // Records every request and response when set
+}
+
+func (w *WoltAddr) parse() error {
+	u, err := url.Parse(w.BaseAddr)
+	if err != nil {
+		return fmt.Errorf("parse base addr: %w", err)
+	}
+	w.baseAddrParsed = u
+
+	u, err = url.Parse(w.APIBaseAddr)
+	if err != nil {
+		return fmt.Errorf("parse api addr: %w", err)
+	}
+	w.apiAddrParsed = u
+
+	if w.AuthBaseAddr != "" {
+		u, err = url.Parse(w.AuthBaseAddr)
+		if err != nil {
+			return fmt.Errorf("parse auth addr: %w", err)
+		}
+		w.authAddrParsed = u
+	}
+	return nil
+}
+
+// Client sends requests to Wolt. It's safe for concurrent use.
+// The connections and the rate limit are shared by everything created with it, while every group keeps its own cookies.
+type Client struct {
+	woltAddrs   WoltAddr
+	retryConfig RetryConfig
+	timeout     time.Duration
+	transport   http.RoundTripper
+	headers     map[string]string
+	venues      *VenueCache
+	venueReqs   requester
+}
+
+func NewClient(woltAddrs WoltAddr, retryConfig RetryConfig, cfg ClientConfig) (*Client, error) {
+	if err := woltAddrs.parse(); err != nil {
+		return nil, fmt.Errorf("parse wolt addrs: %w", err)
+	}
+
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	headers := map[string]string{
+		"User-Agent":   userAgent,
+		"Origin":       woltAddrs.BaseAddr,
+		"Content-Type": "application/json;charset=utf-8",
+	}
+	for key, val := range cfg.Headers {
+		headers[key] = val
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 32 // All the requests are sent to few hosts
+	var roundTripper http.RoundTripper = transport
+	if cfg.Recorder != nil {
+		roundTripper = &recordingTransport{base: roundTripper, recorder: cfg.Recorder}
+	}
+	if cfg.RateLimit > 0 {
+		burst := cfg.RateBurst
+		if burst < 1 {
+			burst = 1
+		}
+		roundTripper = &rateLimitedTransport{base: roundTripper, limiter: rate.NewLimiter(rate.Limit(cfg.RateLimit), burst)}
+	}
+
+	c := &Client{
+		woltAddrs:   woltAddrs,
+		retryConfig: retryConfig,
+		timeout:     cfg.RequestTimeout,
+		transport:   roundTripper,
+		headers:     headers,
+	}
+
+	venueReqs, err := c.newRequester(nil)
+	if err != nil {
+		return nil, fmt.Errorf("new venues requester: %w", err)
+	}
+	c.venueReqs = venueReqs
+	c.venues = newVenueCache(c.VenueByID, cfg.VenueTTL, cfg.VenueStatusTTL)
+	return c, nil
+}
+
+// rateLimitedTransport waits for the limiter before every request, until the request's context is done
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, fmt.Errorf("wait for rate limit: %w", err)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// requester sends requests to Wolt with its own cookies, with the headers of a browser.
+// If it has an auth token source, the requests are sent as the signed in account.
+type requester struct {
+	client  *http.Client
+	headers map[string]string
+	auth    TokenSource
+}
+
+func (c *Client) newRequester(auth TokenSource) (requester, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return requester{}, fmt.Errorf("cookiejar: %w", err)
+	}
+
+	client := retryablehttp.NewClient()
+	client.HTTPClient = &http.Client{
+		Transport: c.transport,
+		Jar:       jar,
+		Timeout:   c.timeout,
+	}
+	client.RetryWaitMax = c.retryConfig.HTTPMaxRetryDuration
+	client.RetryWaitMin = c.retryConfig.HTTPMinRetryDuration
+	client.RetryMax = c.retryConfig.HTTPMaxRetries
+	client.Logger = nil
+	client.RequestLogHook = func(logger retryablehttp.Logger, request *http.Request, i int) {
+		if i != 0 {
+			log.Errorf("Retrying request for %s (attempt %d)", request.URL.String(), i)
+		}
+	}
+
+	return requester{
+		client:  client.StandardClient(),
+		headers: c.headers,
+		auth:    auth,
+	}, nil
+}
+
+func (r *requester) prepareReq(ctx context.Context, method, url string, body io.Reader, extraHeaders map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, val := range r.headers {
+		req.Header.Set(key, val)
+	}
+
+	if r.auth != nil {
+		accessToken, err := r.auth.AccessToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	for key, val := range extraHeaders {
+		req.Header.Set(key, val)
+	}
+
+	return req, nil
+}
+
+// sendReq sends the request, the caller should close the body of the returned response
+func (r *requester) sendReq(req *http.Request) (*http.Response, error) {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending https req: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("got non 200 response: %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+// readResp sends the request and reads the whole response body
+func (r *requester) readResp(req *http.Request) ([]byte, error) {
+	resp, err := r.sendReq(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	output, err := io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("reading output: %w", err)
+	}
+	return output, nil
+}
+
+func (c *Client) joinBaseAddr(p string) string {
+	u := *c.woltAddrs.baseAddrParsed
+	u.Path = path.Join(u.Path, p)
+	return u.String()
+}
+
+func (c *Client) joinApiAddr(p string) string {
+	u := *c.woltAddrs.apiAddrParsed
+	u.Path = path.Join(u.Path, p)
+	return u.String()
+}
+
+func (c *Client) joinAuthAddr(p string) string {
+	u := *c.woltAddrs.authAddrParsed
+	u.Path = path.Join(u.Path, p)
+	return u.String()
+}
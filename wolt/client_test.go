@@ -0,0 +1,87 @@
+package wolt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, addr string, cfg ClientConfig) *Client {
+	c, err := NewClient(WoltAddr{BaseAddr: addr, APIBaseAddr: addr}, RetryConfig{
+		HTTPMaxRetries:       3,
+		HTTPMinRetryDuration: time.Millisecond,
+		HTTPMaxRetryDuration: time.Millisecond,
+	}, cfg)
+	require.NoError(t, err)
+	return c
+}
+
+func TestClientHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		headers <- req.Header
+		res.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	c := newTestClient(t, server.URL, ClientConfig{UserAgent: "bolt-test", Headers: map[string]string{"Accept-Language": "he"}})
+	_, err := c.VenueBySlug(context.Background(), "tasty-venue")
+	require.Error(t, err)
+
+	received := <-headers
+	assert.Equal(t, "bolt-test", received.Get("User-Agent"))
+	assert.Equal(t, "he", received.Get("Accept-Language"))
+	assert.Equal(t, server.URL, received.Get("Origin"))
+}
+
+func TestClientContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+
+	c := newTestClient(t, server.URL, ClientConfig{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.VenueBySlug(ctx, "tasty-venue")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClientRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+
+	// Every lookup is retried 3 times (4 requests), the first request is in the burst
+	c := newTestClient(t, server.URL, ClientConfig{RateLimit: 100, RateBurst: 1})
+	start := time.Now()
+	_, err := c.VenueBySlug(context.Background(), "tasty-venue")
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+
+	// Waiting for the rate limit is aborted with the context
+	slow := newTestClient(t, server.URL, ClientConfig{RateLimit: 0.1, RateBurst: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	_, err = slow.VenueBySlug(ctx, "tasty-venue")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
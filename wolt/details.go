@@ -19,11 +19,21 @@ type Item struct {
 	EndAmount float64 `json:"end_amount"`
 }
 
+// BotName is the name Bolt joins group orders with
+const BotName = "Wolt Bot"
+
+// Statuses of a participant in a group order
+const (
+	ParticipantStatusJoined = "joined" // Still choosing
+	ParticipantStatusReady  = "ready"  // Done choosing
+)
+
 type Participant struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Status    string `json:"status"`
 	UserID    string `json:"user_id"`
+	Email     string `json:"email"` // Not always available
 	Basket    struct {
 		Items []Item `json:"items"`
 	} `json:"basket"`
@@ -36,6 +46,15 @@ func (p *Participant) Name() string {
 	return p.FirstName
 }
 
+// Subtotal is the total price of the participant's basket
+func (p *Participant) Subtotal() float64 {
+	total := 0.0
+	for _, item := range p.Basket.Items {
+		total += item.EndAmount / 100
+	}
+	return total
+}
+
 type Status string
 type DeliveryStatus string
 type DeliveryStatusToTimeMap map[DeliveryStatus]time.Time
@@ -155,10 +174,7 @@ func (o *OrderDetails) host() (string, error) {
 func (o *OrderDetails) RateByPerson() (map[string]float64, error) {
 	output := make(map[string]float64)
 	for _, participant := range o.Participants {
-		total := 0.0
-		for _, item := range participant.Basket.Items {
-			total += item.EndAmount / 100
-		}
+		total := participant.Subtotal()
 		if total == 0 {
 			continue
 		}
@@ -169,6 +185,26 @@ func (o *OrderDetails) RateByPerson() (map[string]float64, error) {
 	return output, nil
 }
 
+// UserIDByName returns the Wolt user ID of every participant by the participant's name
+func (o *OrderDetails) UserIDByName() map[string]string {
+	output := make(map[string]string, len(o.Participants))
+	for _, participant := range o.Participants {
+		output[participant.Name()] = participant.UserID
+	}
+	return output
+}
+
+// EmailByName returns the email of every participant with a known email by the participant's name
+func (o *OrderDetails) EmailByName() map[string]string {
+	output := make(map[string]string)
+	for _, participant := range o.Participants {
+		if participant.Email != "" {
+			output[participant.Name()] = participant.Email
+		}
+	}
+	return output
+}
+
 func (o *OrderDetails) IsDelivered() bool {
 	return o.Purchase.DeliveryStatus == DeliveryStatusDelivered
 }
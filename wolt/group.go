@@ -2,94 +2,108 @@ package wolt
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"path"
 	"strings"
-	"time"
 
 	"github.com/Jeffail/gabs/v2"
-	retryablehttp "github.com/hashicorp/go-retryablehttp"
-	"github.com/prometheus/common/log"
 	"golang.org/x/net/html"
 )
 
-type WoltAddr struct {
-	BaseAddr    string
-	APIBaseAddr string
+// Group is a group order, with the cookies of Bolt as its participant
+type Group struct {
+	requester
+	client   *Client
+	prettyID string
+	id       string
+	url      string
+}
+
+func (c *Client) newGroup(id string, auth TokenSource) (*Group, error) {
+	r, err := c.newRequester(auth)
+	if err != nil {
+		return nil, fmt.Errorf("new requester: %w", err)
+	}
 
-	baseAddrParsed *url.URL
-	apiAddrParsed  *url.URL
+	return &Group{
+		requester: r,
+		client:    c,
+		prettyID:  id,
+	}, nil
 }
 
-type RetryConfig struct {
-	HTTPMaxRetries       int
-	HTTPMinRetryDuration time.Duration
-	HTTPMaxRetryDuration time.Duration
+// GroupWithExistingID returns the group order with the ID in its link, which should be joined before using it
+func (c *Client) GroupWithExistingID(id string) (*Group, error) {
+	return c.newGroup(id, nil)
 }
 
-func (w *WoltAddr) parse() error {
-	u, err := url.Parse(w.BaseAddr)
+// CreateGroup creates a group order on the venue, delivered to the location, as the signed in Wolt account.
+// That account is the host of the group, so the group shouldn't be joined.
+func (c *Client) CreateGroup(ctx context.Context, auth TokenSource, venueID, name string, location Coordinate) (*Group, error) {
+	g, err := c.newGroup("", auth)
+	if err != nil {
+		return nil, fmt.Errorf("new group: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"venue_id":        venueID,
+		"name":            name,
+		"emoji":           "burger",
+		"delivery_method": "homedelivery",
+		"delivery_info": map[string]interface{}{
+			"location": map[string]interface{}{
+				"coordinates": map[string]interface{}{
+					"type":        "Point",
+					"coordinates": []float64{location.Lat, location.Lon},
+				},
+			},
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("parse base addr: %w", err)
+		return nil, fmt.Errorf("marshal group order: %w", err)
 	}
-	w.baseAddrParsed = u
 
-	u, err = url.Parse(w.APIBaseAddr)
+	req, err := g.prepareReq(ctx, "POST", c.joinApiAddr("/v1/group_order"), bytes.NewReader(body), nil)
 	if err != nil {
-		return fmt.Errorf("parse api addr: %w", err)
+		return nil, fmt.Errorf("new request: %w", err)
 	}
-	w.apiAddrParsed = u
-	return nil
-}
-
-type Group struct {
-	woltAddrs WoltAddr
-	prettyID  string
-	id        string
-	auth      string
-	client    *http.Client
-	headers   map[string]string
-}
 
-func newGroup(woltAddrs WoltAddr, retryConfig RetryConfig, id string) (*Group, error) {
-	jar, err := cookiejar.New(nil)
+	output, err := g.readResp(req)
 	if err != nil {
-		return nil, fmt.Errorf("cookiejar: %w", err)
+		return nil, fmt.Errorf("create group http res: %w", err)
 	}
 
-	client := retryablehttp.NewClient()
-	client.HTTPClient.Jar = jar
-	client.RetryWaitMax = retryConfig.HTTPMaxRetryDuration
-	client.RetryWaitMin = retryConfig.HTTPMinRetryDuration
-	client.RetryMax = retryConfig.HTTPMaxRetries
-	client.Logger = nil
-	client.RequestLogHook = func(logger retryablehttp.Logger, request *http.Request, i int) {
-		if i != 0 {
-			log.Errorf("Retrying request for %s (attempt %d)", request.URL.String(), i)
-		}
+	gc, err := gabs.ParseJSON(output)
+	if err != nil {
+		return nil, fmt.Errorf("parse group order JSON: %w", err)
 	}
-
-	if err = woltAddrs.parse(); err != nil {
-		return nil, fmt.Errorf("parse wolt addrs: %w", err)
+	id, ok := gc.S("id").Data().(string)
+	if !ok {
+		return nil, fmt.Errorf("find group id from JSON")
+	}
+	groupURL, ok := gc.S("url").Data().(string)
+	if !ok {
+		return nil, fmt.Errorf("find group URL from JSON")
 	}
 
-	return &Group{
-		woltAddrs: woltAddrs,
-		prettyID:  id,
-		client:    client.StandardClient(),
-		headers: map[string]string{
-			"User-Agent":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.16; rv:84.0) Gecko/20100101 Firefox/84.0",
-			"Origin":       woltAddrs.BaseAddr,
-			"Content-Type": "application/json;charset=utf-8",
-		}}, nil
+	g.id = id
+	g.prettyID = path.Base(groupURL)
+	g.url = groupURL
+	return g, nil
 }
 
-func NewGroupWithExistingID(woltAddrs WoltAddr, retryConfig RetryConfig, id string) (*Group, error) {
-	return newGroup(woltAddrs, retryConfig, id)
+// URL returns the link for joining the group, known just for groups created by Bolt
+func (g *Group) URL() string {
+	return g.url
+}
+
+// ShortID returns the ID of the group in its link
+func (g *Group) ShortID() string {
+	return g.prettyID
 }
 
 func isIDMatch(n *html.Node, id string) bool {
@@ -120,36 +134,19 @@ func findByID(n *html.Node, id string) *html.Node {
 	return nil
 }
 
-func (g *Group) joinBaseAddr(p string) string {
-	u := *g.woltAddrs.baseAddrParsed
-	u.Path = path.Join(u.Path, p)
-	return u.String()
-}
-
-func (g *Group) joinApiAddr(p string) string {
-	u := *g.woltAddrs.apiAddrParsed
-	u.Path = path.Join(u.Path, p)
-	return u.String()
-}
-
 // This function is used to get the real group ID (instead of the short one) from the Wolt API.
 // It sends a GET request to the Wolt API to get the group details JSON and extracts the ID from it.
-func (g *Group) assignIDFromExternalScript() error {
-	req, err := g.prepareReq("GET", g.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/code/%s", g.prettyID)), nil, nil)
+func (g *Group) assignIDFromExternalScript(ctx context.Context) error {
+	req, err := g.prepareReq(ctx, "GET", g.client.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/code/%s", g.prettyID)), nil, nil)
 	if err != nil {
 		return fmt.Errorf("new request: %w", err)
 	}
 
-	resp, err := g.sendReq(req)
+	fullResponse, err := g.readResp(req)
 	if err != nil {
 		return fmt.Errorf("getting http response: %w", err)
 	}
 
-	fullResponse, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
-	if err != nil {
-		return fmt.Errorf("reading output: %w", err)
-	}
-
 	gc, err := gabs.ParseJSON(fullResponse)
 	if err != nil {
 		return fmt.Errorf("parse group order JSON: %w", err)
@@ -194,131 +191,80 @@ func (g *Group) assignIDFromHTML(data io.Reader) error {
 	return nil
 }
 
-func (g *Group) prepareReq(method, url string, body io.Reader, extraHeaders map[string]string) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, err
-	}
-
-	for key, val := range g.headers {
-		req.Header.Set(key, val)
-	}
-
-	for key, val := range extraHeaders {
-		req.Header.Set(key, val)
-	}
-
-	return req, nil
-}
-
-func (g *Group) sendReq(req *http.Request) (*http.Response, error) {
-	resp, err := g.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("sending https req: %w", err)
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("got non 200 response: %d", resp.StatusCode)
+// participantsMeAddr returns the address of Bolt as a participant of the group, as a guest or as the signed in account
+func (g *Group) participantsMeAddr() string {
+	if g.auth != nil {
+		return g.client.joinApiAddr(fmt.Sprintf("/v1/group_order/%s/participants/me", g.id))
 	}
-
-	return resp, nil
+	return g.client.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/%s/participants/me", g.id))
 }
 
-func (g *Group) joinByRealID() error {
-	body := bytes.NewBuffer([]byte(`{"first_name":"Wolt Bot"}`))
+func (g *Group) joinByRealID(ctx context.Context) error {
+	body := bytes.NewBuffer([]byte(fmt.Sprintf(`{"first_name":%q}`, BotName)))
 
-	reqURL := g.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/join/%s", g.id))
-	if g.auth != "" {
-		reqURL = g.joinApiAddr(fmt.Sprintf("/v1/group_order/join/%s", g.id))
+	reqURL := g.client.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/join/%s", g.id))
+	if g.auth != nil {
+		reqURL = g.client.joinApiAddr(fmt.Sprintf("/v1/group_order/join/%s", g.id))
 	}
 
 	req, err := g.prepareReq(
+		ctx,
 		"POST",
 		reqURL,
 		body,
-		map[string]string{"Referer": g.woltAddrs.BaseAddr})
+		map[string]string{"Referer": g.client.woltAddrs.BaseAddr})
 	if err != nil {
 		return fmt.Errorf("new request: %w", err)
 	}
 
-	_, err = g.sendReq(req)
-	if err != nil {
+	if _, err = g.readResp(req); err != nil {
 		return fmt.Errorf("join request http res: %w", err)
 	}
 
 	return nil
 }
 
-func (g *Group) Join() error {
-	if err := g.assignIDFromExternalScript(); err != nil {
+func (g *Group) Join(ctx context.Context) error {
+	if err := g.assignIDFromExternalScript(ctx); err != nil {
 		return fmt.Errorf("getting real group ID: %w", err)
 	}
 
-	return g.joinByRealID()
+	return g.joinByRealID(ctx)
 }
 
-func (g *Group) Details() (*OrderDetails, error) {
-	reqURL := g.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/%s/participants/me", g.id))
-	if g.auth != "" {
-		reqURL = g.joinApiAddr(fmt.Sprintf("/v1/group_order/%s/participants/me", g.id))
-	}
-
+func (g *Group) Details(ctx context.Context) (*OrderDetails, error) {
 	b := bytes.NewBuffer([]byte(`{"subscribed":false}`))
-	req, err := g.prepareReq("PATCH", reqURL, b, nil)
+	req, err := g.prepareReq(ctx, "PATCH", g.participantsMeAddr(), b, nil)
 	if err != nil {
 		return nil, fmt.Errorf("new request: %w", err)
 	}
 
-	resp, err := g.sendReq(req)
+	output, err := g.readResp(req)
 	if err != nil {
 		return nil, fmt.Errorf("details http res: %w", err)
 	}
 
-	output, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading output: %w", err)
-	}
-
 	return ParseOrderDetails(output)
 }
 
-func (g *Group) VenueDetails(details *OrderDetails) (*Venue, error) {
-	req, err := g.prepareReq("GET", g.joinApiAddr(fmt.Sprintf("/v3/venues/%s", details.Details.VenueID)), nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("prepare venue request: %w", err)
-	}
-
-	resp, err := g.sendReq(req)
-	if err != nil {
-		return nil, fmt.Errorf("send venue details request: %w", err)
-	}
-
-	output, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading output: %w", err)
-	}
-
-	v, err := ParseVenue(output)
-	if err != nil {
-		return nil, fmt.Errorf("parse venue: %w", err)
-	}
-	return v, nil
+// VenueDetails returns the venue of the group for its static data, possibly cached
+func (g *Group) VenueDetails(ctx context.Context, details *OrderDetails) (*Venue, error) {
+	return g.client.venues.Venue(ctx, details.Details.VenueID)
 }
 
-func (g *Group) MarkAsReady() error {
-	reqURL := g.joinApiAddr(fmt.Sprintf("/v1/group_order/guest/%s/participants/me", g.id))
-	if g.auth != "" {
-		reqURL = g.joinApiAddr(fmt.Sprintf("/v1/group_order/%s/participants/me", g.id))
-	}
+// VenueStatus returns the venue of the group for its state (like whether it's delivering), possibly cached for a short while
+func (g *Group) VenueStatus(ctx context.Context, details *OrderDetails) (*Venue, error) {
+	return g.client.venues.VenueStatus(ctx, details.Details.VenueID)
+}
 
+func (g *Group) MarkAsReady(ctx context.Context) error {
 	b := bytes.NewBuffer([]byte(`{"status":"ready"}`))
-	req, err := g.prepareReq("PATCH", reqURL, b, nil)
+	req, err := g.prepareReq(ctx, "PATCH", g.participantsMeAddr(), b, nil)
 	if err != nil {
 		return fmt.Errorf("new request: %w", err)
 	}
 
-	_, err = g.sendReq(req)
-	if err != nil {
+	if _, err = g.readResp(req); err != nil {
 		return fmt.Errorf("mark as ready http res: %w", err)
 	}
 
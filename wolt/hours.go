@@ -0,0 +1,142 @@
+package wolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	HoursEventOpen  = "open"
+	HoursEventClose = "close"
+)
+
+// HoursEvent is an opening or a closing of a venue, at an offset from the start of the day
+type HoursEvent struct {
+	Type   string
+	Offset time.Duration
+}
+
+// WeeklyHours are the opening and closing events of a venue in each day of the week.
+// A window may span midnight, in which case its closing is the first event of the next day.
+type WeeklyHours map[time.Weekday][]HoursEvent
+
+// TimeWindow is a period in which a venue is open
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time // Zero when the venue doesn't close in the following week
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// UnmarshalJSON parses the weekly hours of a venue. Unknown weekdays and event types are logged and skipped,
+// so a change in Wolt's hours won't fail parsing the whole venue.
+func (w *WeeklyHours) UnmarshalJSON(data []byte) error {
+	var rawHours map[string][]struct {
+		Type  string `json:"type"`
+		Value struct {
+			OffsetMillis int64 `json:"$date"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(data, &rawHours); err != nil {
+		return fmt.Errorf("unmarshal weekly hours: %w", err)
+	}
+	if rawHours == nil {
+		*w = nil
+		return nil
+	}
+
+	hours := make(WeeklyHours, len(rawHours))
+	for day, rawEvents := range rawHours {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			log.Printf("Skipping unexpected weekday %q in weekly hours\n", day)
+			continue
+		}
+		events := make([]HoursEvent, 0, len(rawEvents))
+		for _, rawEvent := range rawEvents {
+			if rawEvent.Type != HoursEventOpen && rawEvent.Type != HoursEventClose {
+				log.Printf("Skipping unexpected event type %q in weekly hours of %s\n", rawEvent.Type, day)
+				continue
+			}
+			events = append(events, HoursEvent{
+				Type:   rawEvent.Type,
+				Offset: time.Duration(rawEvent.Value.OffsetMillis) * time.Millisecond,
+			})
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			return events[i].Offset < events[j].Offset
+		})
+		hours[weekday] = events
+	}
+
+	*w = hours
+	return nil
+}
+
+type datedHoursEvent struct {
+	HoursEvent
+	At time.Time
+}
+
+// eventsAround returns the events from a week before t until a week after it, in the order they happen
+func (w WeeklyHours) eventsAround(t time.Time, loc *time.Location) []datedHoursEvent {
+	t = t.In(loc)
+	events := make([]datedHoursEvent, 0)
+	for dayDiff := -7; dayDiff <= 7; dayDiff++ {
+		dayStart := time.Date(t.Year(), t.Month(), t.Day()+dayDiff, 0, 0, 0, 0, loc)
+		for _, event := range w[dayStart.Weekday()] {
+			events = append(events, datedHoursEvent{HoursEvent: event, At: dayStart.Add(event.Offset)})
+		}
+	}
+	return events
+}
+
+// IsOpen returns whether t is in one of the open windows
+func (w WeeklyHours) IsOpen(t time.Time, loc *time.Location) bool {
+	open := false
+	for _, event := range w.eventsAround(t, loc) {
+		if event.At.After(t) {
+			break
+		}
+		open = event.Type == HoursEventOpen
+	}
+	return open
+}
+
+// NextWindow returns the window in which the venue is open at t (which started before t), or the next window if it's closed at t.
+// It returns false if the venue doesn't open in the following week.
+func (w WeeklyHours) NextWindow(t time.Time, loc *time.Location) (TimeWindow, bool) {
+	var window TimeWindow
+	open := false
+	for _, event := range w.eventsAround(t, loc) {
+		isOpenEvent := event.Type == HoursEventOpen
+		if !event.At.After(t) {
+			if isOpenEvent && !open {
+				window.Start = event.At
+			}
+			open = isOpenEvent
+			continue
+		}
+
+		if isOpenEvent && !open {
+			window.Start = event.At
+			open = true
+		} else if !isOpenEvent && open {
+			window.End = event.At
+			return window, true
+		}
+	}
+	return window, open
+}
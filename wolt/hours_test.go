@@ -0,0 +1,104 @@
+package wolt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Open 09:00-23:00 on Sunday, and 11:30 on Monday until 01:00 on Tuesday
+const testHoursJSON = `{
+	"sunday": [{"type": "open", "value": {"$date": 32400000}}, {"type": "close", "value": {"$date": 82800000}}],
+	"monday": [{"type": "open", "value": {"$date": 41400000}}],
+	"tuesday": [{"type": "close", "value": {"$date": 3600000}}]
+}`
+
+func parseTestHours(t *testing.T) WeeklyHours {
+	var hours WeeklyHours
+	require.NoError(t, json.Unmarshal([]byte(testHoursJSON), &hours))
+	return hours
+}
+
+func TestWeeklyHoursUnmarshal(t *testing.T) {
+	hours := parseTestHours(t)
+	assert.Equal(t, WeeklyHours{
+		time.Sunday:  {{Type: HoursEventOpen, Offset: 9 * time.Hour}, {Type: HoursEventClose, Offset: 23 * time.Hour}},
+		time.Monday:  {{Type: HoursEventOpen, Offset: 11*time.Hour + 30*time.Minute}},
+		time.Tuesday: {{Type: HoursEventClose, Offset: time.Hour}},
+	}, hours)
+
+	// Unknown weekdays and event types are skipped
+	var unknown WeeklyHours
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"someday": [{"type": "open", "value": {"$date": 0}}],
+		"sunday": [{"type": "maybe", "value": {"$date": 0}}, {"type": "open", "value": {"$date": 32400000}}]
+	}`), &unknown))
+	assert.Equal(t, WeeklyHours{time.Sunday: {{Type: HoursEventOpen, Offset: 9 * time.Hour}}}, unknown)
+
+	var invalid WeeklyHours
+	assert.Error(t, json.Unmarshal([]byte(`{"sunday": "always"}`), &invalid))
+}
+
+func TestWeeklyHoursWindows(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jerusalem")
+	require.NoError(t, err)
+	hours := parseTestHours(t)
+	// 2024-01-07 is a Sunday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name           string
+		t              time.Time
+		expectedOpen   bool
+		expectedWindow TimeWindow
+	}{
+		{"Before opening", at(7, 8, 0), false, TimeWindow{Start: at(7, 9, 0), End: at(7, 23, 0)}},
+		{"Open", at(7, 12, 0), true, TimeWindow{Start: at(7, 9, 0), End: at(7, 23, 0)}},
+		{"After closing", at(7, 23, 30), false, TimeWindow{Start: at(8, 11, 30), End: at(9, 1, 0)}},
+		{"After midnight", at(9, 0, 30), true, TimeWindow{Start: at(8, 11, 30), End: at(9, 1, 0)}},
+		{"Closed until next week", at(9, 2, 0), false, TimeWindow{Start: at(14, 9, 0), End: at(14, 23, 0)}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOpen, hours.IsOpen(tc.t, loc))
+			window, ok := hours.NextWindow(tc.t, loc)
+			require.True(t, ok)
+			assert.True(t, tc.expectedWindow.Start.Equal(window.Start), "expected start %s, got %s", tc.expectedWindow.Start, window.Start)
+			assert.True(t, tc.expectedWindow.End.Equal(window.End), "expected end %s, got %s", tc.expectedWindow.End, window.End)
+		})
+	}
+
+	_, ok := WeeklyHours{}.NextWindow(at(7, 8, 0), loc)
+	assert.False(t, ok)
+}
+
+func TestVenueNextOpeningAndPreorderSlot(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jerusalem")
+	require.NoError(t, err)
+	v := &Venue{OpeningTimes: parseTestHours(t), PreorderEnabled: true, TimezoneLocation: loc}
+	v.PreorderTimes.Delivery = WeeklyHours{time.Sunday: {{Type: HoursEventOpen, Offset: 12 * time.Hour}, {Type: HoursEventClose, Offset: 22 * time.Hour}}}
+
+	now := time.Date(2024, 1, 7, 8, 0, 0, 0, loc)
+	opening, ok := v.NextOpening(now)
+	require.True(t, ok)
+	assert.True(t, opening.Equal(time.Date(2024, 1, 7, 9, 0, 0, 0, loc)))
+	slot, ok := v.NextPreorderSlot(now)
+	require.True(t, ok)
+	assert.True(t, slot.Equal(time.Date(2024, 1, 7, 12, 0, 0, 0, loc)))
+
+	now = time.Date(2024, 1, 7, 13, 0, 0, 0, loc)
+	_, ok = v.NextOpening(now)
+	assert.False(t, ok, "venue is already open")
+	slot, ok = v.NextPreorderSlot(now)
+	require.True(t, ok)
+	assert.True(t, slot.Equal(now))
+
+	v.PreorderEnabled = false
+	_, ok = v.NextPreorderSlot(now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,278 @@
+package wolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedExchange is a request to Wolt and its response, as saved in a fixture file
+type RecordedExchange struct {
+	RecordedAt   time.Time       `json:"recorded_at"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Query        string          `json:"query,omitempty"`
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	Status       int             `json:"status"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"` // JSON responses
+	ResponseText string          `json:"response_text,omitempty"` // Other responses (like HTML) are redacted, as they can't be sanitized
+}
+
+// redactedValue replaces secrets in recordings
+const redactedValue = "REDACTED"
+
+// secretKeys are keys of values which are removed from recordings
+var secretKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"token":         true,
+	"phone_number":  true,
+	"address":       true,
+	"street":        true,
+	"apartment":     true,
+	"entrance":      true,
+	"door_code":     true,
+}
+
+// userIDKeys are keys of Wolt user IDs, which are replaced by the same pseudonym under all of them so the host is still found in the participants
+var userIDKeys = map[string]bool{
+	"user_id":  true,
+	"guest_id": true,
+	"host_id":  true,
+}
+
+// blankedKeys are keys of values which are removed from recordings without a placeholder, as the client treats them as optional
+var blankedKeys = map[string]bool{
+	"profile_picture_url": true,
+}
+
+// coordinateKeys are keys of coordinates, which are rounded in recordings to about 100 meters
+var coordinateKeys = map[string]bool{
+	"coordinates": true,
+	"lat":         true,
+	"lon":         true,
+}
+
+// coordinatePrecision is the number of decimal places recorded coordinates are rounded to
+const coordinatePrecision = 3
+
+var fixtureNameRe = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// Recorder saves every request to Wolt and its response as a fixture file in a directory, for replaying them in tests.
+// Secrets and personal details are sanitized: tokens, addresses and non-JSON responses are redacted, names, emails and user IDs are replaced
+// by consistent pseudonyms, profile pictures are removed, coordinates are rounded, and no headers (with cookies and the Authorization header) are saved.
+type Recorder struct {
+	dir string
+
+	l          sync.Mutex
+	seq        int
+	pseudonyms map[string]string
+}
+
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create recordings dir: %w", err)
+	}
+	return &Recorder{dir: dir, pseudonyms: make(map[string]string)}, nil
+}
+
+// Dir returns the directory the fixtures are saved in
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// recordingTransport records every request sent through it
+type recordingTransport struct {
+	base     http.RoundTripper
+	recorder *Recorder
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body for recording: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body for recording: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err = t.recorder.record(req, reqBody, resp.StatusCode, respBody); err != nil {
+		// Recording is best effort, it shouldn't fail the request
+		log.Printf("Error recording request for %s: %v\n", req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) record(req *http.Request, reqBody []byte, status int, respBody []byte) error {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	exchange := RecordedExchange{
+		RecordedAt: time.Now(),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      req.URL.RawQuery,
+		Status:     status,
+	}
+
+	if len(reqBody) > 0 {
+		sanitized, err := r.sanitizeRequestBody(req.Header.Get("Content-Type"), reqBody)
+		if err != nil {
+			return fmt.Errorf("sanitize request body: %w", err)
+		}
+		exchange.RequestBody = sanitized
+	}
+
+	if sanitized, err := r.sanitizeJSON(respBody); err == nil {
+		exchange.ResponseBody = sanitized
+	} else if len(bytes.TrimSpace(respBody)) > 0 {
+		exchange.ResponseText = redactedValue
+	}
+
+	content, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal exchange: %w", err)
+	}
+
+	r.seq++
+	name := fmt.Sprintf("%04d-%s-%s.json", r.seq, req.Method, strings.Trim(fixtureNameRe.ReplaceAllString(req.URL.Path, "-"), "-"))
+	if err = os.WriteFile(filepath.Join(r.dir, name), append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write fixture: %w", err)
+	}
+	return nil
+}
+
+// sanitizeRequestBody sanitizes a JSON or a form request body, saving it as JSON
+func (r *Recorder) sanitizeRequestBody(contentType string, body []byte) (json.RawMessage, error) {
+	if !strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return r.sanitizeJSON(body)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("parse form: %w", err)
+	}
+	values := make(map[string]interface{}, len(form))
+	for key := range form {
+		values[key] = form.Get(key)
+	}
+	return json.Marshal(r.sanitizeValue("", values))
+}
+
+func (r *Recorder) sanitizeJSON(body []byte) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(r.sanitizeValue("", value))
+}
+
+func (r *Recorder) sanitizeValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if firstName, ok := v["first_name"].(string); ok {
+			lastName, _ := v["last_name"].(string)
+			v["first_name"] = r.namePseudonym(strings.TrimSpace(firstName + " " + lastName))
+			if _, ok = v["last_name"]; ok {
+				v["last_name"] = ""
+			}
+		}
+		for childKey, child := range v {
+			if childKey == "first_name" || childKey == "last_name" {
+				continue
+			}
+			v[childKey] = r.sanitizeValue(childKey, child)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = r.sanitizeValue(key, child)
+		}
+		return v
+	case string:
+		if v == "" {
+			return v
+		}
+		if secretKeys[key] {
+			return redactedValue
+		}
+		if blankedKeys[key] {
+			return ""
+		}
+		if key == "email" {
+			return r.pseudonym("email", v, "participant-%d@example.com")
+		}
+		if userIDKeys[key] {
+			return r.pseudonym("user_id", v, "user%020d")
+		}
+		return v
+	case json.Number:
+		if !coordinateKeys[key] {
+			return v
+		}
+		coordinate, err := v.Float64()
+		if err != nil {
+			return v
+		}
+		scale := math.Pow10(coordinatePrecision)
+		return json.Number(strconv.FormatFloat(math.Round(coordinate*scale)/scale, 'f', -1, 64))
+	default:
+		return v
+	}
+}
+
+// namePseudonym returns the same pseudonym for the same full name in the whole recording.
+// Bolt's own name is kept, as it's looked for in the participants.
+func (r *Recorder) namePseudonym(name string) string {
+	if name == BotName || name == "" {
+		return name
+	}
+	return r.pseudonym("name", name, "Participant %d")
+}
+
+// pseudonym returns the same pseudonym for the same value of the kind in the whole recording
+func (r *Recorder) pseudonym(kind, value, format string) string {
+	mapKey := kind + "/" + value
+	if pseudonym, ok := r.pseudonyms[mapKey]; ok {
+		return pseudonym
+	}
+	n := 1
+	for existing := range r.pseudonyms {
+		if strings.HasPrefix(existing, kind+"/") {
+			n++
+		}
+	}
+	pseudonym := fmt.Sprintf(format, n)
+	r.pseudonyms[mapKey] = pseudonym
+	return pseudonym
+}
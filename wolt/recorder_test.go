@@ -0,0 +1,102 @@
+package wolt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const recordedDetails = `{
+	"status": "active",
+	"host_id": "host-secret-id",
+	"details": {"venue_id": "venue", "delivery_info": {"location": {"address": "1 Secret St", "coordinates": {"coordinates": [32.0712345, 34.7812345]}}}},
+	"participants": [
+		{"first_name": "Noa", "last_name": "Levi", "email": "noa@example.org", "user_id": "host-secret-id", "guest_id": "host-secret-id", "status": "ready",
+			"profile_picture_url": "https://example.org/secret-picture.png"},
+		{"first_name": "Noa", "last_name": "Katz", "user_id": "noa-secret-id", "status": "joined"},
+		{"first_name": "Wolt Bot", "user_id": "bot", "status": "ready"}
+	],
+	"access_token": "secret-token"
+}`
+
+func TestRecorderSanitizes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/group_order/guest/code/ABCD1234":
+			_, _ = res.Write([]byte(`{"id": "order"}`))
+		case "/v1/group_order/guest/join/order":
+			_, _ = res.Write([]byte(`<html><body>Welcome Noa Levi</body></html>`))
+		default:
+			_, _ = res.Write([]byte(recordedDetails))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	recorder, err := NewRecorder(filepath.Join(t.TempDir(), "session"))
+	require.NoError(t, err)
+	c := newTestClient(t, server.URL, ClientConfig{Recorder: recorder, Headers: map[string]string{"Authorization": "Bearer secret-token"}})
+	ctx := context.Background()
+
+	g, err := c.GroupWithExistingID("ABCD1234")
+	require.NoError(t, err)
+	require.NoError(t, g.Join(ctx))
+	details, err := g.Details(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Noa Levi", details.Host, "responses should reach the caller as they are")
+	_, err = g.Details(ctx)
+	require.NoError(t, err)
+
+	paths, err := filepath.Glob(filepath.Join(recorder.Dir(), "*.json"))
+	require.NoError(t, err)
+	require.Len(t, paths, 4)
+	assert.Equal(t, "0001-GET-v1-group_order-guest-code-ABCD1234.json", filepath.Base(paths[0]))
+
+	var exchanges []RecordedExchange
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "secret-token")
+		assert.NotContains(t, string(content), "Secret St")
+		assert.NotContains(t, string(content), "Levi")
+		assert.NotContains(t, string(content), "secret-id")
+		assert.NotContains(t, string(content), "secret-picture")
+		assert.NotContains(t, string(content), "12345")
+
+		var exchange RecordedExchange
+		require.NoError(t, json.Unmarshal(content, &exchange))
+		exchanges = append(exchanges, exchange)
+	}
+	assert.Equal(t, http.MethodPost, exchanges[1].Method)
+	assert.JSONEq(t, `{"first_name": "Wolt Bot"}`, string(exchanges[1].RequestBody))
+	assert.Equal(t, http.StatusOK, exchanges[1].Status)
+	assert.Equal(t, redactedValue, exchanges[1].ResponseText)
+	assert.Equal(t, http.StatusOK, exchanges[2].Status)
+
+	// Pseudonyms are the same in all the recorded responses, and the recorded details are still parsed
+	for _, exchange := range exchanges[2:] {
+		recorded, err := ParseOrderDetails(exchange.ResponseBody)
+		require.NoError(t, err)
+		assert.Equal(t, "Participant 1", recorded.Host)
+		assert.Equal(t, map[string]string{"Participant 1": "participant-1@example.com"}, recorded.EmailByName())
+		assert.Equal(t, "Participant 2", recorded.Participants[1].Name())
+		assert.Equal(t, "user00000000000000000002", recorded.Participants[1].UserID)
+		assert.Equal(t, []float64{32.071, 34.781}, recorded.Details.DeliveryInfo.Location.Coordinates.Coordinates)
+		assert.Equal(t, BotName, recorded.Participants[2].Name())
+	}
+}
+
+func TestRecorderSanitizesForms(t *testing.T) {
+	recorder, err := NewRecorder(t.TempDir())
+	require.NoError(t, err)
+
+	body, err := recorder.sanitizeRequestBody("application/x-www-form-urlencoded", []byte("grant_type=refresh_token&refresh_token=secret-token"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"grant_type": "refresh_token", "refresh_token": "REDACTED"}`, string(body))
+}
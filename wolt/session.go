@@ -0,0 +1,204 @@
+package wolt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+	"github.com/oriser/bolt/session"
+)
+
+// SessionAccount is the account name the session of Bolt's Wolt account is stored by
+const SessionAccount = "bot"
+
+// accessTokenExpiryMargin is how long before the access token expires it's refreshed
+const accessTokenExpiryMargin = time.Minute
+
+// ErrNotSignedIn is returned when Bolt was never signed in to Wolt, or its session was revoked
+var ErrNotSignedIn = errors.New("not signed in to Wolt")
+
+// TokenSource provides access tokens of a Wolt account, for sending authenticated requests
+type TokenSource interface {
+	AccessToken(ctx context.Context) (string, error)
+}
+
+// SessionManager keeps Bolt signed in to its Wolt account.
+// It stores the refresh token of the account encrypted, and refreshes the access token before it expires.
+// Wolt rotates the refresh token on every refresh, so the stored one is replaced each time.
+type SessionManager struct {
+	requester
+	client *Client
+	store  session.Store
+	aead   cipher.AEAD
+
+	l           sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// sessionKeySize is the size of the AES-256 key the refresh tokens are encrypted with
+const sessionKeySize = 32
+
+// NewSessionManager creates a session manager, encrypting the refresh tokens with the secret, a 32 bytes key encoded in base64
+func (c *Client) NewSessionManager(store session.Store, secret string) (*SessionManager, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("empty session secret")
+	}
+	if c.woltAddrs.authAddrParsed == nil {
+		return nil, fmt.Errorf("empty auth addr")
+	}
+
+	r, err := c.newRequester(nil)
+	if err != nil {
+		return nil, fmt.Errorf("new requester: %w", err)
+	}
+
+	aead, err := newTokenCipher(secret)
+	if err != nil {
+		return nil, fmt.Errorf("new token cipher: %w", err)
+	}
+
+	return &SessionManager{
+		requester: r,
+		client:    c,
+		store:     store,
+		aead:      aead,
+	}, nil
+}
+
+func newTokenCipher(secret string) (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret))
+	if err != nil || len(key) != sessionKeySize {
+		return nil, fmt.Errorf("the session secret should be a %d bytes key encoded in base64, for example from `openssl rand -base64 %d`", sessionKeySize, sessionKeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new AES cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (m *SessionManager) encrypt(token string) ([]byte, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return m.aead.Seal(nonce, nonce, []byte(token), []byte(SessionAccount)), nil
+}
+
+func (m *SessionManager) decrypt(encrypted []byte) (string, error) {
+	if len(encrypted) < m.aead.NonceSize() {
+		return "", fmt.Errorf("encrypted token is too short")
+	}
+	nonce, sealed := encrypted[:m.aead.NonceSize()], encrypted[m.aead.NonceSize():]
+	token, err := m.aead.Open(nil, nonce, sealed, []byte(SessionAccount))
+	if err != nil {
+		return "", fmt.Errorf("open sealed token (was the session secret changed?): %w", err)
+	}
+	return string(token), nil
+}
+
+// Login signs in to the account of the refresh token (taken from a browser signed in to Wolt), and stores the session
+func (m *SessionManager) Login(ctx context.Context, refreshToken string) error {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return fmt.Errorf("empty refresh token")
+	}
+
+	m.l.Lock()
+	defer m.l.Unlock()
+	return m.refresh(ctx, refreshToken)
+}
+
+// SignedIn returns whether there's a stored session
+func (m *SessionManager) SignedIn(ctx context.Context) (bool, error) {
+	s, err := m.store.GetSession(ctx, SessionAccount)
+	if err != nil {
+		return false, fmt.Errorf("get session: %w", err)
+	}
+	return s != nil, nil
+}
+
+// AccessToken returns an access token of the account, refreshing it if it's about to expire
+func (m *SessionManager) AccessToken(ctx context.Context) (string, error) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.accessToken != "" && time.Now().Add(accessTokenExpiryMargin).Before(m.expiresAt) {
+		return m.accessToken, nil
+	}
+
+	s, err := m.store.GetSession(ctx, SessionAccount)
+	if err != nil {
+		return "", fmt.Errorf("get session: %w", err)
+	}
+	if s == nil {
+		return "", ErrNotSignedIn
+	}
+	refreshToken, err := m.decrypt(s.RefreshToken)
+	if err != nil {
+		return "", fmt.Errorf("decrypt refresh token: %w", err)
+	}
+
+	if err = m.refresh(ctx, refreshToken); err != nil {
+		return "", err
+	}
+	return m.accessToken, nil
+}
+
+// refresh exchanges the refresh token for an access token, and stores the new refresh token.
+// It should be called with the lock held.
+func (m *SessionManager) refresh(ctx context.Context, refreshToken string) error {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	}
+	req, err := m.prepareReq(ctx, "POST", m.client.joinAuthAddr("/v1/wauth2/access_token"), strings.NewReader(form.Encode()),
+		map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	output, err := m.readResp(req)
+	if err != nil {
+		return fmt.Errorf("refresh access token http res: %w", err)
+	}
+
+	gc, err := gabs.ParseJSON(output)
+	if err != nil {
+		return fmt.Errorf("parse access token JSON: %w", err)
+	}
+	accessToken, ok := gc.S("access_token").Data().(string)
+	if !ok || accessToken == "" {
+		return fmt.Errorf("find access token from JSON")
+	}
+	expiresIn, ok := gc.S("expires_in").Data().(float64)
+	if !ok {
+		return fmt.Errorf("find access token expiry from JSON")
+	}
+	if rotated, ok := gc.S("refresh_token").Data().(string); ok && rotated != "" {
+		refreshToken = rotated
+	}
+
+	encrypted, err := m.encrypt(refreshToken)
+	if err != nil {
+		return fmt.Errorf("encrypt refresh token: %w", err)
+	}
+	if err = m.store.SaveSession(ctx, &session.Session{Account: SessionAccount, RefreshToken: encrypted}); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
+	m.accessToken = accessToken
+	m.expiresAt = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return nil
+}
@@ -0,0 +1,127 @@
+package wolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oriser/bolt/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memorySessionStore struct {
+	sessions map[string]*session.Session
+}
+
+func (s *memorySessionStore) GetSession(_ context.Context, account string) (*session.Session, error) {
+	return s.sessions[account], nil
+}
+
+func (s *memorySessionStore) SaveSession(_ context.Context, sess *session.Session) error {
+	s.sessions[sess.Account] = sess
+	return nil
+}
+
+// newTestAuthServer returns a server exchanging refresh tokens like Wolt, rotating them on every use
+func newTestAuthServer(t *testing.T, initialRefreshToken string) (*httptest.Server, *int) {
+	refreshToken := initialRefreshToken
+	exchanges := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/v1/wauth2/access_token", req.URL.Path)
+		require.NoError(t, req.ParseForm())
+		if req.PostForm.Get("refresh_token") != refreshToken {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		exchanges++
+		refreshToken = fmt.Sprintf("refresh-%d", exchanges)
+		_ = json.NewEncoder(res).Encode(map[string]interface{}{
+			"access_token":  fmt.Sprintf("access-%d", exchanges),
+			"refresh_token": refreshToken,
+			"expires_in":    1800,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &exchanges
+}
+
+// Keys of 32 bytes, encoded in base64
+const (
+	testSessionSecret        = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	anotherTestSessionSecret = "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8="
+)
+
+func newTestSessionManager(t *testing.T, authAddr string, store session.Store, secret string) *SessionManager {
+	c, err := NewClient(WoltAddr{
+		BaseAddr:     authAddr,
+		APIBaseAddr:  authAddr,
+		AuthBaseAddr: authAddr,
+	}, RetryConfig{}, ClientConfig{})
+	require.NoError(t, err)
+	m, err := c.NewSessionManager(store, secret)
+	require.NoError(t, err)
+	return m
+}
+
+func TestSessionManager(t *testing.T) {
+	server, exchanges := newTestAuthServer(t, "initial")
+	store := &memorySessionStore{sessions: make(map[string]*session.Session)}
+	m := newTestSessionManager(t, server.URL, store, testSessionSecret)
+
+	_, err := m.AccessToken(context.Background())
+	assert.ErrorIs(t, err, ErrNotSignedIn)
+	signedIn, err := m.SignedIn(context.Background())
+	require.NoError(t, err)
+	assert.False(t, signedIn)
+
+	assert.Error(t, m.Login(context.Background(), "wrong"))
+	require.NoError(t, m.Login(context.Background(), " initial\n"))
+	signedIn, err = m.SignedIn(context.Background())
+	require.NoError(t, err)
+	assert.True(t, signedIn)
+
+	// The rotated refresh token is stored encrypted
+	stored := store.sessions[SessionAccount]
+	require.NotNil(t, stored)
+	assert.False(t, bytes.Contains(stored.RefreshToken, []byte("refresh-1")))
+
+	token, err := m.AccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", token)
+	assert.Equal(t, 1, *exchanges, "a valid access token shouldn't be refreshed")
+
+	// Another manager (like after a restart) refreshes with the stored refresh token
+	restarted := newTestSessionManager(t, server.URL, store, testSessionSecret)
+	token, err = restarted.AccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", token)
+
+	// An access token about to expire is refreshed
+	restarted.expiresAt = time.Now().Add(accessTokenExpiryMargin / 2)
+	token, err = restarted.AccessToken(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access-3", token)
+
+	// The session can't be used without the secret it was encrypted with
+	_, err = newTestSessionManager(t, server.URL, store, anotherTestSessionSecret).AccessToken(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSessionSecret(t *testing.T) {
+	c, err := NewClient(WoltAddr{BaseAddr: "http://wolt", APIBaseAddr: "http://wolt", AuthBaseAddr: "http://wolt"}, RetryConfig{}, ClientConfig{})
+	require.NoError(t, err)
+	store := &memorySessionStore{sessions: make(map[string]*session.Session)}
+
+	for _, secret := range []string{"", "secret", "c2hvcnQ=", testSessionSecret + testSessionSecret} {
+		_, err = c.NewSessionManager(store, secret)
+		assert.Error(t, err, secret)
+	}
+	_, err = c.NewSessionManager(store, testSessionSecret+"\n")
+	assert.NoError(t, err)
+}
@@ -18,6 +18,9 @@ type VenueName struct {
 }
 
 type Venue struct {
+	IDObject struct {
+		OID string `json:"$oid"`
+	} `json:"id"`
 	Alive    uint8 `json:"alive"`
 	Location struct {
 		Coordinates []float64 `json:"coordinates"`
@@ -33,14 +36,24 @@ type Venue struct {
 			DateUnix int64 `json:"$date"`
 		} `json:"end"`
 	} `json:"offline_period"`
-	Online          bool `json:"online"`
-	PreorderEnabled bool `json:"preorder_enabled"`
+	Online          bool        `json:"online"`
+	OpeningTimes    WeeklyHours `json:"opening_times"`
+	PreorderEnabled bool        `json:"preorder_enabled"`
 	PreorderTimes   struct {
-		Delivery map[string][]interface{} `json:"delivery"`
+		Delivery WeeklyHours `json:"delivery"`
 	} `json:"preorder_times"`
-	City     string `json:"city"`
-	Timezone string `json:"timezone"`
-
+	City      string `json:"city"`
+	Timezone  string `json:"timezone"`
+	Slug      string `json:"slug"`
+	Address   string `json:"address"`
+	Estimates struct {
+		Total struct {
+			Min int `json:"min"`
+			Max int `json:"max"`
+		} `json:"total"`
+	} `json:"estimates"` // In minutes
+
+	ID               string `json:"-"`
 	Name             string
 	ParsedCoordinate Coordinate     `json:"-"`
 	TimezoneLocation *time.Location `json:"-"`
@@ -55,6 +68,13 @@ type Coordinate struct {
 type PriceRanges struct {
 	BasePrice      int             `json:"base_price"`
 	DistanceRanges []DistanceRange `json:"distance_ranges"`
+	BasketRanges   []BasketRange   `json:"price_ranges"` // Small order surcharges
+}
+
+type BasketRange struct {
+	AddedPrice int `json:"a"`
+	MinPrice   int `json:"min"`
+	MaxPrice   int `json:"max"`
 }
 
 type DistanceRange struct {
@@ -88,6 +108,7 @@ func ParseVenue(venuesJSON []byte) (*Venue, error) {
 	}
 
 	v.OfflinePeriodEnd = time.UnixMilli(v.OfflinePeriod.End.DateUnix)
+	v.ID = v.IDObject.OID
 
 	for _, name := range v.Names {
 		if name.Lang == "en" || v.Name == "" {
@@ -154,6 +175,17 @@ func (v *Venue) CalculateDeliveryRate(source Coordinate) (int, error) {
 	return price / 100, nil
 }
 
+// MinimumOrder returns the basket price (in NIS) from which there's no small order surcharge, or 0 if there's never a surcharge
+func (v *Venue) MinimumOrder() int {
+	minimum := 0
+	for _, basketRange := range v.DeliverySpecs.DeliveryPricing.BasketRanges {
+		if basketRange.AddedPrice > 0 && basketRange.MaxPrice > minimum {
+			minimum = basketRange.MaxPrice
+		}
+	}
+	return minimum / 100
+}
+
 func (v *Venue) IsDelivering() bool {
 	return v.DeliverySpecs.DeliveryEnabled && v.Online && v.Alive != 0
 }
@@ -161,3 +193,29 @@ func (v *Venue) IsDelivering() bool {
 func (v *Venue) IsOpenForPreorderDelivery() bool {
 	return v.PreorderEnabled && v.PreorderTimes.Delivery != nil
 }
+
+// NextOpening returns when the venue opens after now, according to its opening times.
+// It returns false if it's open now or won't open in the following week.
+func (v *Venue) NextOpening(now time.Time) (time.Time, bool) {
+	window, ok := v.OpeningTimes.NextWindow(now, v.TimezoneLocation)
+	if !ok || !window.Start.After(now) {
+		return time.Time{}, false
+	}
+	return window.Start, true
+}
+
+// NextPreorderSlot returns the earliest time from now a pre-order delivery can be scheduled to.
+// It returns false if the venue doesn't accept pre-orders in the following week.
+func (v *Venue) NextPreorderSlot(now time.Time) (time.Time, bool) {
+	if !v.IsOpenForPreorderDelivery() {
+		return time.Time{}, false
+	}
+	window, ok := v.PreorderTimes.Delivery.NextWindow(now, v.TimezoneLocation)
+	if !ok {
+		return time.Time{}, false
+	}
+	if window.Start.Before(now) {
+		return now, true
+	}
+	return window.Start, true
+}
@@ -0,0 +1,92 @@
+package wolt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// VenueCache caches venues by their ID, shared by all the group orders of the client.
+// Static data of venues (name, link, location and delivery pricing) rarely changes, while their state (online, delivering, offline periods) may change any minute,
+// so a cached venue is used for its static data for longer than for its state.
+// Concurrent fetches of the same venue are coalesced to a single request.
+type VenueCache struct {
+	fetch      func(ctx context.Context, id string) (*Venue, error)
+	staticTTL  time.Duration
+	dynamicTTL time.Duration
+	requests   singleflight.Group
+
+	l      sync.Mutex
+	venues map[string]*cachedVenue
+}
+
+type cachedVenue struct {
+	venue     *Venue
+	fetchedAt time.Time
+}
+
+func newVenueCache(fetch func(ctx context.Context, id string) (*Venue, error), staticTTL, dynamicTTL time.Duration) *VenueCache {
+	return &VenueCache{
+		fetch:      fetch,
+		staticTTL:  staticTTL,
+		dynamicTTL: dynamicTTL,
+		venues:     make(map[string]*cachedVenue),
+	}
+}
+
+// Venue returns the venue for its static data, which may be up to the static TTL old
+func (vc *VenueCache) Venue(ctx context.Context, id string) (*Venue, error) {
+	return vc.get(ctx, id, vc.staticTTL)
+}
+
+// VenueStatus returns the venue for its state (like IsDelivering), which may be up to the dynamic TTL old
+func (vc *VenueCache) VenueStatus(ctx context.Context, id string) (*Venue, error) {
+	return vc.get(ctx, id, vc.dynamicTTL)
+}
+
+func (vc *VenueCache) get(ctx context.Context, id string, maxAge time.Duration) (*Venue, error) {
+	vc.l.Lock()
+	cached, ok := vc.venues[id]
+	vc.l.Unlock()
+	if ok && time.Since(cached.fetchedAt) < maxAge {
+		return cached.venue, nil
+	}
+
+	// The fetch is shared by everyone waiting for the venue, so it isn't aborted when one of them gives up
+	resCh := vc.requests.DoChan(id, func() (interface{}, error) {
+		v, err := vc.fetch(context.Background(), id)
+		if err != nil {
+			return nil, err
+		}
+		vc.store(v)
+		return v, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resCh:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*Venue), nil
+	}
+}
+
+// store caches the venue, fetched right now, and evicts venues which are too old to be used
+func (vc *VenueCache) store(v *Venue) {
+	if v == nil || v.ID == "" {
+		return
+	}
+
+	now := time.Now()
+	vc.l.Lock()
+	defer vc.l.Unlock()
+	vc.venues[v.ID] = &cachedVenue{venue: v, fetchedAt: now}
+	for id, cached := range vc.venues {
+		if now.Sub(cached.fetchedAt) >= vc.staticTTL {
+			delete(vc.venues, id)
+		}
+	}
+}
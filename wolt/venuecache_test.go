@@ -0,0 +1,104 @@
+package wolt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVenueCacheTTLs(t *testing.T) {
+	var fetches int32
+	vc := newVenueCache(func(ctx context.Context, id string) (*Venue, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &Venue{ID: id, Name: "A Tasty Venue"}, nil
+	}, time.Hour, time.Minute)
+	ctx := context.Background()
+
+	v, err := vc.Venue(ctx, "venue")
+	require.NoError(t, err)
+	assert.Equal(t, "A Tasty Venue", v.Name)
+	_, err = vc.VenueStatus(ctx, "venue")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches), "a fresh venue should be used for both its static data and its state")
+
+	// Older than the dynamic TTL, but not than the static one
+	vc.venues["venue"].fetchedAt = time.Now().Add(-2 * time.Minute)
+	_, err = vc.Venue(ctx, "venue")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+	_, err = vc.VenueStatus(ctx, "venue")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+
+	// Older than the static TTL
+	vc.venues["venue"].fetchedAt = time.Now().Add(-2 * time.Hour)
+	_, err = vc.Venue(ctx, "venue")
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&fetches))
+
+	// Other venues are cached separately, and too old venues are evicted
+	vc.venues["venue"].fetchedAt = time.Now().Add(-2 * time.Hour)
+	_, err = vc.Venue(ctx, "another")
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, atomic.LoadInt32(&fetches))
+	assert.NotContains(t, vc.venues, "venue")
+}
+
+func TestVenueCacheCoalescing(t *testing.T) {
+	var fetches int32
+	release := make(chan struct{})
+	vc := newVenueCache(func(ctx context.Context, id string) (*Venue, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return &Venue{ID: id}, nil
+	}, time.Hour, time.Minute)
+
+	const waiters = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := vc.VenueStatus(context.Background(), "venue")
+			if err == nil && v.ID != "venue" {
+				err = fmt.Errorf("unexpected venue %q", v.ID)
+			}
+			errs <- err
+		}()
+	}
+
+	// A waiter which gives up doesn't abort the fetch of the others
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := vc.VenueStatus(ctx, "venue")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+}
+
+func TestVenueCacheErrors(t *testing.T) {
+	var fetches int32
+	vc := newVenueCache(func(ctx context.Context, id string) (*Venue, error) {
+		atomic.AddInt32(&fetches, 1)
+		return nil, fmt.Errorf("wolt is down")
+	}, time.Hour, time.Minute)
+
+	_, err := vc.Venue(context.Background(), "venue")
+	assert.Error(t, err)
+	_, err = vc.Venue(context.Background(), "venue")
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&fetches), "errors shouldn't be cached")
+}
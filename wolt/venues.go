@@ -0,0 +1,71 @@
+package wolt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// VenueBySlug fetches the venue with the given slug, the last part of the venue's link (for example "tasty-venue" of https://wolt.com/en/isr/tel-aviv/restaurant/tasty-venue).
+// The fetched venue is cached for orders from it.
+func (c *Client) VenueBySlug(ctx context.Context, slug string) (*Venue, error) {
+	v, err := c.getVenue(ctx, c.joinApiAddr(fmt.Sprintf("/v3/venues/slug/%s", url.PathEscape(slug))))
+	if err != nil {
+		return nil, err
+	}
+	c.venues.store(v)
+	return v, nil
+}
+
+// Venues returns the cache of venues, shared by everything using the client
+func (c *Client) Venues() *VenueCache {
+	return c.venues
+}
+
+// VenueByID fetches the venue with the given venue ID, without the cache
+func (c *Client) VenueByID(ctx context.Context, id string) (*Venue, error) {
+	return c.getVenue(ctx, c.joinApiAddr(fmt.Sprintf("/v3/venues/%s", url.PathEscape(id))))
+}
+
+func (c *Client) getVenue(ctx context.Context, url string) (*Venue, error) {
+	req, err := c.venueReqs.prepareReq(ctx, "GET", url, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("prepare venue request: %w", err)
+	}
+
+	output, err := c.venueReqs.readResp(req)
+	if err != nil {
+		return nil, fmt.Errorf("send venue details request: %w", err)
+	}
+
+	v, err := ParseVenue(output)
+	if err != nil {
+		return nil, fmt.Errorf("parse venue: %w", err)
+	}
+	return v, nil
+}
+
+// ParseCoordinate parses a "<latitude>,<longitude>" coordinate.
+// Coordinates from Wolt are longitude first (like GeoJSON), so the returned coordinate keeps them in that order, for comparing with them.
+func ParseCoordinate(coordinate string) (Coordinate, error) {
+	parts := strings.Split(coordinate, ",")
+	if len(parts) != 2 {
+		return Coordinate{}, fmt.Errorf("expected <latitude>,<longitude> but got %q", coordinate)
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("parse latitude: %w", err)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("parse longitude: %w", err)
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return Coordinate{}, fmt.Errorf("coordinate %q is out of range", coordinate)
+	}
+
+	return CoordinateFromArray([]float64{lon, lat})
+}